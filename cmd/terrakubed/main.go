@@ -7,8 +7,10 @@ import (
 	"sync"
 
 	api "github.com/ilkerispir/terrakubed/internal/api"
+	"github.com/ilkerispir/terrakubed/internal/client"
 	"github.com/ilkerispir/terrakubed/internal/config"
 	"github.com/ilkerispir/terrakubed/internal/executor"
+	"github.com/ilkerispir/terrakubed/internal/git"
 	"github.com/ilkerispir/terrakubed/internal/registry"
 )
 
@@ -41,6 +43,9 @@ func main() {
 
 	log.Printf("Starting Terrakubed (Service Type: %s)\n", serviceType)
 
+	configureGit(cfg)
+	configureGitHubApp(cfg)
+
 	var wg sync.WaitGroup
 
 	switch serviceType {
@@ -122,3 +127,64 @@ func startExecutor(cfg *config.Config) {
 	log.Println("Executor service is starting...")
 	executor.Start(cfg)
 }
+
+// configureGit sets the clone backend and the optional mirror cache shared by
+// the executor and registry.
+func configureGit(cfg *config.Config) {
+	opts := git.Options{
+		InProcess:       cfg.GitClient == "go-git",
+		Submodules:      cfg.GitSubmodules,
+		LFS:             cfg.GitLfs,
+		HostKeyChecking: cfg.SshHostKeyChecking,
+		HostKeys:        client.NewClient(cfg.AzBuilderApiUrl, cfg.InternalSecret),
+	}
+	switch opts.HostKeyChecking {
+	case "", git.HostKeysTOFU, git.HostKeysStrict:
+	case git.HostKeysOff:
+		log.Printf("Warning: SSH host key checking is disabled (SSH_HOST_KEY_CHECKING=off)")
+	default:
+		log.Printf("Warning: unknown SSH_HOST_KEY_CHECKING %q, using %s", opts.HostKeyChecking, git.HostKeysStrict)
+		opts.HostKeyChecking = git.HostKeysStrict
+	}
+	if cfg.GitMirrorDir != "" {
+		if opts.InProcess {
+			log.Printf("Warning: GIT_MIRROR_DIR is ignored with GIT_CLIENT=go-git")
+		} else {
+			maxMb, _ := strconv.ParseInt(cfg.GitMirrorMaxSizeMb, 10, 64)
+			mirrors, err := git.NewMirrorCache(cfg.GitMirrorDir, maxMb<<20)
+			if err != nil {
+				log.Printf("Warning: %v", err)
+			} else {
+				opts.Mirrors = mirrors
+				log.Printf("Git mirror cache enabled at %s", cfg.GitMirrorDir)
+			}
+		}
+	}
+	git.Configure(opts)
+}
+
+// configureGitHubApp enables GitHub App installation tokens for workspace and
+// module clones when an app ID and private key are configured.
+func configureGitHubApp(cfg *config.Config) {
+	if cfg.GitHubAppId == "" {
+		return
+	}
+	key := cfg.GitHubAppPrivateKey
+	if cfg.GitHubAppPrivateKeyFile != "" {
+		data, err := os.ReadFile(cfg.GitHubAppPrivateKeyFile)
+		if err != nil {
+			log.Printf("Warning: GitHub App disabled: %v", err)
+			return
+		}
+		key = string(data)
+	}
+	// Installations are registered per organization as github_app_token resources
+	installations := client.NewClient(cfg.AzBuilderApiUrl, cfg.InternalSecret)
+	app, err := git.NewGitHubApp(cfg.GitHubAppId, key, cfg.GitHubAppApiUrl, installations)
+	if err != nil {
+		log.Printf("Warning: GitHub App disabled: %v", err)
+		return
+	}
+	git.UseGitHubApp(app)
+	log.Printf("GitHub App %s enabled for repository clones", cfg.GitHubAppId)
+}
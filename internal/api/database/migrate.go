@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// migrations are additive, idempotent DDL statements for columns introduced by
+// the Go API on top of the schema managed by the Java API's Liquibase changelog.
+// They run on every startup, before column validation, so ValidateColumns does
+// not drop the new fields from the registered models.
+var migrations = []string{
+	// Plan summary reported by the executor after each plan
+	`ALTER TABLE job ADD COLUMN IF NOT EXISTS plan_add INTEGER`,
+	`ALTER TABLE job ADD COLUMN IF NOT EXISTS plan_change INTEGER`,
+	`ALTER TABLE job ADD COLUMN IF NOT EXISTS plan_destroy INTEGER`,
+	`ALTER TABLE job ADD COLUMN IF NOT EXISTS plan_replace INTEGER`,
+
+	// Speculative plan jobs created for pull requests
+	`ALTER TABLE job ADD COLUMN IF NOT EXISTS pull_request_number INTEGER`,
+	`ALTER TABLE job ADD COLUMN IF NOT EXISTS pull_request_url TEXT`,
+	`ALTER TABLE workspace ADD COLUMN IF NOT EXISTS allow_fork_pull_requests BOOLEAN NOT NULL DEFAULT false`,
+
+	// Result of the last terraformDrift job
+	`ALTER TABLE workspace ADD COLUMN IF NOT EXISTS drift_status VARCHAR(32)`,
+	`ALTER TABLE workspace ADD COLUMN IF NOT EXISTS drift_checked_date TIMESTAMP`,
+
+	// Policy as code
+	`CREATE TABLE IF NOT EXISTS policy_set (
+		id              UUID PRIMARY KEY,
+		name            VARCHAR(128) NOT NULL,
+		description     TEXT,
+		override_team   VARCHAR(128),
+		organization_id UUID NOT NULL REFERENCES organization(id),
+		created_date    TIMESTAMP,
+		created_by      VARCHAR(128),
+		updated_date    TIMESTAMP,
+		updated_by      VARCHAR(128)
+	)`,
+	`CREATE TABLE IF NOT EXISTS policy (
+		id                UUID PRIMARY KEY,
+		name              VARCHAR(128) NOT NULL,
+		description       TEXT,
+		rego              TEXT NOT NULL,
+		enforcement_level VARCHAR(32) NOT NULL DEFAULT 'advisory',
+		policy_set_id     UUID NOT NULL REFERENCES policy_set(id) ON DELETE CASCADE,
+		created_date      TIMESTAMP,
+		created_by        VARCHAR(128),
+		updated_date      TIMESTAMP,
+		updated_by        VARCHAR(128)
+	)`,
+
+	// SSH host keys trusted for git clones, per organization
+	`CREATE TABLE IF NOT EXISTS ssh_known_host (
+		id              UUID PRIMARY KEY,
+		host            VARCHAR(255) NOT NULL,
+		key_type        VARCHAR(64) NOT NULL,
+		public_key      TEXT NOT NULL,
+		source          VARCHAR(16),
+		organization_id UUID NOT NULL REFERENCES organization(id),
+		created_date    TIMESTAMP,
+		created_by      VARCHAR(128),
+		updated_date    TIMESTAMP,
+		updated_by      VARCHAR(128)
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS ssh_known_host_key ON ssh_known_host (organization_id, host, key_type)`,
+
+	// Signing key for private provider releases, one per organization
+	`CREATE TABLE IF NOT EXISTS gpg_key (
+		id              UUID PRIMARY KEY,
+		name            VARCHAR(128) NOT NULL,
+		private_key     TEXT NOT NULL,
+		organization_id UUID NOT NULL REFERENCES organization(id),
+		created_date    TIMESTAMP,
+		created_by      VARCHAR(128),
+		updated_date    TIMESTAMP,
+		updated_by      VARCHAR(128)
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS gpg_key_organization ON gpg_key (organization_id)`,
+
+	// GitHub App installations an organization clones with, per repository owner
+	`ALTER TABLE IF EXISTS github_app_token ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organization(id)`,
+
+	// Notification destinations
+	`CREATE TABLE IF NOT EXISTS notification (
+		id               UUID PRIMARY KEY,
+		name             VARCHAR(128) NOT NULL,
+		destination_type VARCHAR(32) NOT NULL,
+		url              TEXT,
+		token            TEXT,
+		email_addresses  TEXT,
+		events           TEXT,
+		enabled          BOOLEAN NOT NULL DEFAULT TRUE,
+		organization_id  UUID NOT NULL REFERENCES organization(id),
+		workspace_id     UUID REFERENCES workspace(id) ON DELETE CASCADE,
+		created_date     TIMESTAMP,
+		created_by       VARCHAR(128),
+		updated_date     TIMESTAMP,
+		updated_by       VARCHAR(128)
+	)`,
+
+	// Secret that inbound VCS webhooks (/webhook/v1/{vcsId}) are verified with
+	`ALTER TABLE vcs ADD COLUMN IF NOT EXISTS webhook_secret TEXT`,
+
+	// Outbound webhooks
+	`CREATE TABLE IF NOT EXISTS webhook_endpoint (
+		id              UUID PRIMARY KEY,
+		name            VARCHAR(128) NOT NULL,
+		url             TEXT NOT NULL,
+		secret          TEXT,
+		events          TEXT,
+		enabled         BOOLEAN NOT NULL DEFAULT TRUE,
+		organization_id UUID NOT NULL REFERENCES organization(id),
+		created_date    TIMESTAMP,
+		created_by      VARCHAR(128),
+		updated_date    TIMESTAMP,
+		updated_by      VARCHAR(128)
+	)`,
+	`CREATE TABLE IF NOT EXISTS webhook_delivery (
+		id                UUID PRIMARY KEY,
+		endpoint_id       UUID NOT NULL REFERENCES webhook_endpoint(id) ON DELETE CASCADE,
+		event_type        VARCHAR(64) NOT NULL,
+		payload           TEXT NOT NULL,
+		status            VARCHAR(32) NOT NULL DEFAULT 'pending',
+		attempts          INTEGER NOT NULL DEFAULT 0,
+		response_code     INTEGER,
+		last_error        TEXT,
+		next_attempt_date TIMESTAMPTZ,
+		last_attempt_date TIMESTAMPTZ,
+		created_date      TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS webhook_delivery_due_idx ON webhook_delivery (status, next_attempt_date)`,
+
+	// Registry module and provider downloads, reported by the registry
+	`CREATE TABLE IF NOT EXISTS registry_download (
+		id              UUID PRIMARY KEY,
+		organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+		module_id       UUID REFERENCES module(id) ON DELETE CASCADE,
+		provider_id     UUID REFERENCES provider(id) ON DELETE CASCADE,
+		version         VARCHAR(64) NOT NULL,
+		os              VARCHAR(32),
+		arch            VARCHAR(32),
+		client_ip       VARCHAR(64),
+		user_agent      VARCHAR(256),
+		created_date    TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS registry_download_module_idx ON registry_download (module_id, created_date)`,
+	`CREATE INDEX IF NOT EXISTS registry_download_provider_idx ON registry_download (provider_id, created_date)`,
+}
+
+// Migrate applies all migrations in order.
+func (p *Pool) Migrate(ctx context.Context) error {
+	for _, stmt := range migrations {
+		if _, err := p.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("migration failed (%s): %w", stmt, err)
+		}
+	}
+	log.Printf("Database migrations applied (%d statements)", len(migrations))
+	return nil
+}
@@ -0,0 +1,219 @@
+// Package events records job lifecycle events and delivers them to the
+// organization's outbound webhook endpoints.
+//
+// Every event becomes one webhook_delivery row per subscribed endpoint. A
+// background worker POSTs due deliveries with an HMAC-SHA256 signature, retries
+// failures with exponential backoff and moves a delivery to "deadLetter" after
+// MaxAttempts. Deliveries can be re-sent with Redeliver.
+package events
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Event types.
+const (
+	TypeJobStatus  = "job.status"
+	TypeStepStatus = "step.status"
+)
+
+// Delivery statuses.
+const (
+	StatusPending    = "pending"
+	StatusDelivered  = "delivered"
+	StatusDeadLetter = "deadLetter"
+)
+
+// Event is the JSON body POSTed to webhook endpoints.
+type Event struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	OccurredAt     time.Time `json:"occurredAt"`
+	OrganizationID string    `json:"organizationId"`
+	WorkspaceID    string    `json:"workspaceId"`
+	JobID          int       `json:"jobId"`
+	StepID         string    `json:"stepId,omitempty"`
+	Status         string    `json:"status"`
+}
+
+// Dispatcher stores events as deliveries and runs the delivery worker.
+type Dispatcher struct {
+	pool *pgxpool.Pool
+	// wake triggers an immediate delivery pass after new events are stored
+	wake chan struct{}
+}
+
+// NewDispatcher creates a new Dispatcher.
+func NewDispatcher(pool *pgxpool.Pool) *Dispatcher {
+	return &Dispatcher{pool: pool, wake: make(chan struct{}, 1)}
+}
+
+// EmitJobStatus records a job status change.
+func (d *Dispatcher) EmitJobStatus(ctx context.Context, jobID interface{}, status string) {
+	ev := Event{Type: TypeJobStatus, Status: status}
+	err := d.pool.QueryRow(ctx,
+		`SELECT id, organization_id, workspace_id FROM job WHERE id = $1`, jobID,
+	).Scan(&ev.JobID, &ev.OrganizationID, &ev.WorkspaceID)
+	if err != nil {
+		log.Printf("[events] job %v not found: %v", jobID, err)
+		return
+	}
+	d.Emit(ctx, ev)
+}
+
+// EmitStepStatus records a step status change.
+func (d *Dispatcher) EmitStepStatus(ctx context.Context, stepID interface{}, status string) {
+	ev := Event{Type: TypeStepStatus, StepID: fmt.Sprint(stepID), Status: status}
+	err := d.pool.QueryRow(ctx,
+		`SELECT j.id, j.organization_id, j.workspace_id
+		 FROM step s JOIN job j ON j.id = s.job_id
+		 WHERE s.id = $1`, stepID,
+	).Scan(&ev.JobID, &ev.OrganizationID, &ev.WorkspaceID)
+	if err != nil {
+		log.Printf("[events] step %v not found: %v", stepID, err)
+		return
+	}
+	d.Emit(ctx, ev)
+}
+
+// OnUpdate is a repository update hook: it emits an event whenever the status
+// of a job or step is written, whether by the UI, the executor or GraphQL.
+func (d *Dispatcher) OnUpdate(ctx context.Context, resourceType string, id interface{}, data map[string]interface{}) {
+	status, ok := data["status"]
+	if !ok || status == nil {
+		return
+	}
+	switch resourceType {
+	case "job":
+		d.EmitJobStatus(ctx, id, fmt.Sprint(status))
+	case "step":
+		d.EmitStepStatus(ctx, id, fmt.Sprint(status))
+	}
+}
+
+// Emit creates a pending delivery for every enabled endpoint of the event's
+// organization subscribed to its type.
+func (d *Dispatcher) Emit(ctx context.Context, ev Event) {
+	if ev.ID == "" {
+		ev.ID = uuid.New().String()
+	}
+	if ev.OccurredAt.IsZero() {
+		ev.OccurredAt = time.Now().UTC()
+	}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("[events] marshal %s: %v", ev.Type, err)
+		return
+	}
+
+	rows, err := d.pool.Query(ctx,
+		`SELECT id, COALESCE(events, '') FROM webhook_endpoint
+		 WHERE organization_id = $1 AND enabled = true`, ev.OrganizationID)
+	if err != nil {
+		log.Printf("[events] list endpoints: %v", err)
+		return
+	}
+	var endpointIDs []string
+	for rows.Next() {
+		var id, subscribed string
+		if err := rows.Scan(&id, &subscribed); err != nil {
+			log.Printf("[events] scan endpoint: %v", err)
+			continue
+		}
+		if subscribes(subscribed, ev.Type) {
+			endpointIDs = append(endpointIDs, id)
+		}
+	}
+	rows.Close()
+
+	for _, endpointID := range endpointIDs {
+		if _, err := d.insertDelivery(ctx, endpointID, ev.Type, string(payload)); err != nil {
+			log.Printf("[events] create delivery for endpoint %s: %v", endpointID, err)
+		}
+	}
+	if len(endpointIDs) > 0 {
+		d.nudge()
+	}
+}
+
+// DeliveryOrganization returns the organization of a delivery's endpoint.
+func (d *Dispatcher) DeliveryOrganization(ctx context.Context, deliveryID string) (string, error) {
+	var orgID string
+	err := d.pool.QueryRow(ctx,
+		`SELECT e.organization_id::text FROM webhook_delivery d
+		 JOIN webhook_endpoint e ON e.id = d.endpoint_id
+		 WHERE d.id = $1`, deliveryID,
+	).Scan(&orgID)
+	if err != nil {
+		return "", fmt.Errorf("delivery %s not found: %w", deliveryID, err)
+	}
+	return orgID, nil
+}
+
+// Redeliver queues a new delivery with the same endpoint and payload as an
+// existing one and returns its ID. The endpoint must still be enabled.
+func (d *Dispatcher) Redeliver(ctx context.Context, deliveryID string) (string, error) {
+	var endpointID, eventType, payload string
+	err := d.pool.QueryRow(ctx,
+		`SELECT d.endpoint_id, d.event_type, d.payload FROM webhook_delivery d
+		 JOIN webhook_endpoint e ON e.id = d.endpoint_id AND e.enabled = true
+		 WHERE d.id = $1`, deliveryID,
+	).Scan(&endpointID, &eventType, &payload)
+	if err != nil {
+		return "", fmt.Errorf("delivery %s not found or its endpoint is disabled: %w", deliveryID, err)
+	}
+	id, err := d.insertDelivery(ctx, endpointID, eventType, payload)
+	if err != nil {
+		return "", err
+	}
+	d.nudge()
+	return id, nil
+}
+
+func (d *Dispatcher) insertDelivery(ctx context.Context, endpointID, eventType, payload string) (string, error) {
+	id := uuid.New().String()
+	_, err := d.pool.Exec(ctx,
+		`INSERT INTO webhook_delivery (id, endpoint_id, event_type, payload, status, attempts, next_attempt_date, created_date)
+		 VALUES ($1, $2, $3, $4, $5, 0, now(), now())`,
+		id, endpointID, eventType, payload, StatusPending)
+	return id, err
+}
+
+func (d *Dispatcher) nudge() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// subscribes reports whether a comma-separated event list includes eventType.
+// An empty list subscribes to every event.
+func subscribes(list, eventType string) bool {
+	if strings.TrimSpace(list) == "" {
+		return true
+	}
+	for _, e := range strings.Split(list, ",") {
+		if strings.TrimSpace(e) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the X-Terrakube-Signature value for body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
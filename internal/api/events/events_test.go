@@ -0,0 +1,74 @@
+package events
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestSubscribes(t *testing.T) {
+	tests := []struct {
+		list, eventType string
+		want            bool
+	}{
+		{"", TypeJobStatus, true},
+		{"job.status", TypeJobStatus, true},
+		{"job.status", TypeStepStatus, false},
+		{"step.status, job.status", TypeJobStatus, true},
+	}
+	for _, tt := range tests {
+		if got := subscribes(tt.list, tt.eventType); got != tt.want {
+			t.Errorf("subscribes(%q, %q) = %t, want %t", tt.list, tt.eventType, got, tt.want)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	var gotSig, gotDelivery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get("X-Terrakube-Signature")
+		gotDelivery = r.Header.Get("X-Terrakube-Delivery")
+		if r.Header.Get("X-Terrakube-Event") != TypeJobStatus {
+			http.Error(w, "bad event", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	dd := dueDelivery{id: "d-1", eventType: TypeJobStatus, payload: `{"status":"completed"}`, url: srv.URL, secret: "s3cret"}
+	code, err := send(context.Background(), dd)
+	if err != nil || code != http.StatusOK {
+		t.Fatalf("send() = %d, %v", code, err)
+	}
+	if want := Sign("s3cret", []byte(dd.payload)); gotSig != want {
+		t.Errorf("signature = %q, want %q", gotSig, want)
+	}
+	if gotDelivery != "d-1" {
+		t.Errorf("delivery header = %q, want d-1", gotDelivery)
+	}
+
+	dd.eventType = TypeStepStatus
+	if code, err := send(context.Background(), dd); err == nil || code != http.StatusBadRequest {
+		t.Errorf("send() = %d, %v; want 400 error", code, err)
+	}
+}
@@ -0,0 +1,156 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// MaxAttempts is the number of failed attempts before a delivery is dead-lettered.
+	MaxAttempts = 8
+
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+
+	pollInterval = 10 * time.Second
+	batchSize    = 20
+	// claimLease keeps other API replicas from picking up a delivery in flight.
+	claimLease = 2 * time.Minute
+)
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// backoff returns the delay before the next attempt after `attempts` failures:
+// 30s, 1m, 2m, 4m … capped at 1h.
+func backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
+
+// Start runs the delivery worker until ctx is cancelled.
+func (d *Dispatcher) Start(ctx context.Context) {
+	log.Printf("Webhook delivery worker starting (interval: %s)", pollInterval)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		d.deliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+type dueDelivery struct {
+	id, eventType, payload string
+	attempts               int
+	url, secret            string
+}
+
+// deliverDue claims a batch of due deliveries and attempts each once.
+// Deliveries to disabled endpoints wait until the endpoint is enabled again.
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	rows, err := d.pool.Query(ctx,
+		`UPDATE webhook_delivery d SET next_attempt_date = now() + make_interval(secs => $2)
+		 FROM webhook_endpoint e
+		 WHERE e.id = d.endpoint_id AND e.enabled = true AND d.id IN (
+			SELECT p.id FROM webhook_delivery p
+			JOIN webhook_endpoint pe ON pe.id = p.endpoint_id AND pe.enabled = true
+			WHERE p.status = $3 AND p.next_attempt_date <= now()
+			ORDER BY p.next_attempt_date
+			LIMIT $1
+			FOR UPDATE OF p SKIP LOCKED)
+		 RETURNING d.id, d.event_type, d.payload, d.attempts, e.url, COALESCE(e.secret, '')`,
+		batchSize, claimLease.Seconds(), StatusPending)
+	if err != nil {
+		log.Printf("[events] claim deliveries: %v", err)
+		return
+	}
+	var due []dueDelivery
+	for rows.Next() {
+		var dd dueDelivery
+		if err := rows.Scan(&dd.id, &dd.eventType, &dd.payload, &dd.attempts, &dd.url, &dd.secret); err != nil {
+			log.Printf("[events] scan delivery: %v", err)
+			continue
+		}
+		due = append(due, dd)
+	}
+	rows.Close()
+
+	for _, dd := range due {
+		code, err := send(ctx, dd)
+		d.recordAttempt(ctx, dd, code, err)
+	}
+}
+
+// send POSTs the payload and returns the HTTP status code.
+func send(ctx context.Context, dd dueDelivery) (int, error) {
+	body := []byte(dd.payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dd.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Terrakube-Event", dd.eventType)
+	req.Header.Set("X-Terrakube-Delivery", dd.id)
+	if dd.secret != "" {
+		req.Header.Set("X-Terrakube-Signature", Sign(dd.secret, body))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(b))
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) recordAttempt(ctx context.Context, dd dueDelivery, code int, sendErr error) {
+	attempts := dd.attempts + 1
+	var respCode *int
+	if code != 0 {
+		respCode = &code
+	}
+
+	var err error
+	switch {
+	case sendErr == nil:
+		_, err = d.pool.Exec(ctx,
+			`UPDATE webhook_delivery SET status = $2, attempts = $3, response_code = $4,
+			 last_error = NULL, last_attempt_date = now() WHERE id = $1`,
+			dd.id, StatusDelivered, attempts, respCode)
+	case attempts >= MaxAttempts:
+		log.Printf("[events] delivery %s dead-lettered after %d attempts: %v", dd.id, attempts, sendErr)
+		_, err = d.pool.Exec(ctx,
+			`UPDATE webhook_delivery SET status = $2, attempts = $3, response_code = $4,
+			 last_error = $5, last_attempt_date = now() WHERE id = $1`,
+			dd.id, StatusDeadLetter, attempts, respCode, sendErr.Error())
+	default:
+		next := backoff(attempts)
+		log.Printf("[events] delivery %s attempt %d failed, retrying in %s: %v", dd.id, attempts, next, sendErr)
+		_, err = d.pool.Exec(ctx,
+			`UPDATE webhook_delivery SET attempts = $2, response_code = $3, last_error = $4,
+			 last_attempt_date = now(), next_attempt_date = now() + make_interval(secs => $5) WHERE id = $1`,
+			dd.id, attempts, respCode, sendErr.Error(), next.Seconds())
+	}
+	if err != nil {
+		log.Printf("[events] update delivery %s: %v", dd.id, err)
+	}
+}
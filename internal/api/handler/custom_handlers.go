@@ -137,8 +137,12 @@ func (h *ContextHandler) GetContext(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract jobId from path: /context/v1/{jobId}
+	// Extract jobId from path: /context/v1/{jobId} or /context/v1/{jobId}/diff
 	jobId := strings.TrimPrefix(r.URL.Path, "/context/v1/")
+	if id, ok := strings.CutSuffix(jobId, "/diff"); ok && id != "" && !strings.Contains(id, "/") {
+		h.GetPlanDiff(w, r, id)
+		return
+	}
 	if jobId == "" || strings.Contains(jobId, "/") {
 		http.Error(w, "invalid path — expected /context/v1/{jobId}", http.StatusBadRequest)
 		return
@@ -161,8 +165,14 @@ func (h *ContextHandler) GetContext(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "failed to read context", http.StatusInternalServerError)
 		return
 	}
+	masked, err := maskPlanContext(data)
+	if err != nil {
+		log.Printf("Failed to parse plan context for job %s: %v", jobId, err)
+		http.Error(w, "failed to parse context", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	w.Write(data)
+	w.Write(masked)
 }
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -98,6 +99,7 @@ func (h *JSONAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Parse the path: /api/v1/{type}[/{id}[/{relationship}]]
 	// or nested: /api/v1/{parentType}/{parentId}/{childRel}[/{childId}]
+	// or /api/v1/{grandParentType}/{grandParentId}/{parentType}/{parentId}/{childRel}
 	path := strings.TrimPrefix(r.URL.Path, "/api/v1/")
 	path = strings.TrimSuffix(path, "/")
 	segments := strings.Split(path, "/")
@@ -124,6 +126,10 @@ func (h *JSONAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		// /api/v1/{parentType}/{parentId}/{childType}/{childId}
 		// Nested resource access: resolve child by its ID directly
 		h.handleResource(w, r, segments[2], segments[3])
+	case len(segments) == 5:
+		// /api/v1/{grandParentType}/{grandParentId}/{parentType}/{parentId}/{childRel}
+		// e.g. /api/v1/organization/{orgId}/job/{jobId}/address — List or Create under the parent
+		h.handleRelated(w, r, segments[2], segments[3], segments[4])
 	default:
 		writeError(w, http.StatusNotFound, "Invalid path")
 	}
@@ -498,6 +504,10 @@ func (h *JSONAPIHandler) updateResource(w http.ResponseWriter, r *http.Request,
 	data := jsonapi.Deserialize(config, reqDoc.Data)
 
 	if err := h.repo.Update(r.Context(), resourceType, id, data); err != nil {
+		if errors.Is(err, repository.ErrUpdateRejected) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
 		log.Printf("Error updating %s/%v: %v", resourceType, id, err)
 		writeError(w, http.StatusInternalServerError, "Failed to update resource")
 		return
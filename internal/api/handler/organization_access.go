@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ilkerispir/terrakubed/internal/api/middleware"
+)
+
+// isOrganizationMember checks that a user may act on an organization's
+// resources: internal callers, the owner group and members of any of the
+// organization's teams.
+func isOrganizationMember(ctx context.Context, pool *pgxpool.Pool, ownerGroup string, user *middleware.UserInfo, orgID string) (bool, error) {
+	if user == nil {
+		return false, nil
+	}
+	if user.IsInternal() || (ownerGroup != "" && user.IsMember(ownerGroup)) {
+		return true, nil
+	}
+	if len(user.Groups) == 0 {
+		return false, nil
+	}
+	var member bool
+	err := pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM team WHERE organization_id = $1 AND name = ANY($2))`,
+		orgID, user.Groups).Scan(&member)
+	return member, err
+}
@@ -0,0 +1,430 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+const (
+	sensitiveMask = "(sensitive value)"
+
+	defaultDiffPageSize = 50
+	maxDiffPageSize     = 500
+)
+
+// storedPlanContext mirrors the executor's tfplan/{jobId}/context.json.
+type storedPlanContext struct {
+	ResourceChanges []*tfjson.ResourceChange `json:"resourceChanges"`
+}
+
+// AttributeDiff is a single changed attribute. Sensitive values are replaced
+// by a mask and unknown values are reported as null with Unknown=true.
+type AttributeDiff struct {
+	Path      string      `json:"path"`
+	Before    interface{} `json:"before"`
+	After     interface{} `json:"after"`
+	Sensitive bool        `json:"sensitive,omitempty"`
+	Unknown   bool        `json:"unknown,omitempty"`
+}
+
+// ResourceDiff is the normalized change of one resource instance.
+type ResourceDiff struct {
+	Address       string          `json:"address"`
+	ModuleAddress string          `json:"moduleAddress,omitempty"`
+	Mode          string          `json:"mode"`
+	Type          string          `json:"type"`
+	Name          string          `json:"name"`
+	Action        string          `json:"action"`
+	Attributes    []AttributeDiff `json:"attributes"`
+}
+
+// PlanDiffResponse is the body of GET /context/v1/{jobId}/diff.
+type PlanDiffResponse struct {
+	Data []ResourceDiff `json:"data"`
+	Meta PlanDiffMeta   `json:"meta"`
+}
+
+// PlanDiffMeta carries pagination info for the filtered result set.
+type PlanDiffMeta struct {
+	Total      int `json:"total"`
+	PageNumber int `json:"pageNumber"`
+	PageSize   int `json:"pageSize"`
+	TotalPages int `json:"totalPages"`
+}
+
+// GetPlanDiff handles GET /context/v1/{jobId}/diff
+//
+// Query parameters:
+//
+//	filter[action]=create,update   only these actions (create, update, delete, replace, read, no-op)
+//	filter[address]=module.vpc     only addresses starting with this prefix
+//	page[number]=1&page[size]=50   pagination (max page size 500)
+func (h *ContextHandler) GetPlanDiff(w http.ResponseWriter, r *http.Request, jobId string) {
+	remotePath := fmt.Sprintf("tfplan/%s/context.json", jobId)
+	reader, err := h.storage.DownloadFile(remotePath)
+	if err != nil {
+		log.Printf("Plan context not found for job %s: %v", jobId, err)
+		http.Error(w, "plan not found", http.StatusNotFound)
+		return
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		log.Printf("Failed to read plan context for job %s: %v", jobId, err)
+		http.Error(w, "failed to read context", http.StatusInternalServerError)
+		return
+	}
+
+	var planCtx storedPlanContext
+	if err := json.Unmarshal(data, &planCtx); err != nil {
+		log.Printf("Failed to parse plan context for job %s: %v", jobId, err)
+		http.Error(w, "failed to parse context", http.StatusInternalServerError)
+		return
+	}
+
+	q := r.URL.Query()
+	diffs := filterResourceDiffs(buildResourceDiffs(planCtx.ResourceChanges),
+		parseActionFilter(q.Get("filter[action]")), q.Get("filter[address]"))
+
+	pageSize := defaultDiffPageSize
+	if v, err := strconv.Atoi(q.Get("page[size]")); err == nil && v > 0 {
+		pageSize = v
+	}
+	if pageSize > maxDiffPageSize {
+		pageSize = maxDiffPageSize
+	}
+	totalPages := (len(diffs) + pageSize - 1) / pageSize
+	pageNumber := 1
+	if v, err := strconv.Atoi(q.Get("page[number]")); err == nil && v > 0 {
+		pageNumber = v
+	}
+	if pageNumber > totalPages {
+		pageNumber = max(totalPages, 1)
+	}
+
+	resp := PlanDiffResponse{
+		Data: paginateResourceDiffs(diffs, pageNumber, pageSize),
+		Meta: PlanDiffMeta{
+			Total:      len(diffs),
+			PageNumber: pageNumber,
+			PageSize:   pageSize,
+			TotalPages: totalPages,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// buildResourceDiffs normalizes the plan's resource changes into per-attribute diffs.
+func buildResourceDiffs(changes []*tfjson.ResourceChange) []ResourceDiff {
+	diffs := make([]ResourceDiff, 0, len(changes))
+	for _, rc := range changes {
+		if rc == nil || rc.Change == nil {
+			continue
+		}
+		diff := ResourceDiff{
+			Address:       rc.Address,
+			ModuleAddress: rc.ModuleAddress,
+			Mode:          string(rc.Mode),
+			Type:          rc.Type,
+			Name:          rc.Name,
+			Action:        actionLabel(rc.Change.Actions),
+			Attributes:    []AttributeDiff{},
+		}
+		diffAttributes("", rc.Change.Before, rc.Change.After,
+			rc.Change.BeforeSensitive, rc.Change.AfterSensitive, rc.Change.AfterUnknown, &diff.Attributes)
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+// actionLabel collapses Terraform's action list into a single word.
+func actionLabel(actions tfjson.Actions) string {
+	switch {
+	case actions.Replace():
+		return "replace"
+	case actions.Create():
+		return "create"
+	case actions.Delete():
+		return "delete"
+	case actions.Update():
+		return "update"
+	case actions.Read():
+		return "read"
+	default:
+		return "no-op"
+	}
+}
+
+// diffAttributes walks before/after in parallel and appends one AttributeDiff
+// per changed leaf. The sensitivity and unknown trees mirror the value shape;
+// a `true` at any level applies to the whole subtree.
+func diffAttributes(path string, before, after, beforeSens, afterSens, unknown interface{}, out *[]AttributeDiff) {
+	if isTrue(beforeSens) || isTrue(afterSens) {
+		if reflect.DeepEqual(before, after) && !isTrue(unknown) {
+			return
+		}
+		d := AttributeDiff{Path: path, Sensitive: true}
+		if before != nil {
+			d.Before = sensitiveMask
+		}
+		if isTrue(unknown) {
+			d.Unknown = true
+		} else if after != nil {
+			d.After = sensitiveMask
+		}
+		*out = append(*out, d)
+		return
+	}
+
+	if isTrue(unknown) {
+		*out = append(*out, AttributeDiff{Path: path, Before: before, Unknown: true})
+		return
+	}
+
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if (beforeIsMap || before == nil) && (afterIsMap || after == nil) && (beforeIsMap || afterIsMap) {
+		keys := make(map[string]bool)
+		for k := range beforeMap {
+			keys[k] = true
+		}
+		for k := range afterMap {
+			keys[k] = true
+		}
+		// Unknown attributes are absent from after; take their keys from the unknown tree
+		if unknownMap, ok := unknown.(map[string]interface{}); ok {
+			for k, v := range unknownMap {
+				if hasUnknown(v) {
+					keys[k] = true
+				}
+			}
+		}
+		if len(keys) == 0 {
+			appendLeaf(path, before, after, out)
+			return
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			diffAttributes(joinPath(path, k), beforeMap[k], afterMap[k],
+				child(beforeSens, k), child(afterSens, k), child(unknown, k), out)
+		}
+		return
+	}
+
+	beforeList, beforeIsList := before.([]interface{})
+	afterList, afterIsList := after.([]interface{})
+	if (beforeIsList || before == nil) && (afterIsList || after == nil) && (beforeIsList || afterIsList) {
+		n := len(beforeList)
+		if len(afterList) > n {
+			n = len(afterList)
+		}
+		if n == 0 {
+			appendLeaf(path, before, after, out)
+			return
+		}
+		for i := 0; i < n; i++ {
+			var b, a interface{}
+			if i < len(beforeList) {
+				b = beforeList[i]
+			}
+			if i < len(afterList) {
+				a = afterList[i]
+			}
+			diffAttributes(fmt.Sprintf("%s[%d]", path, i), b, a,
+				child(beforeSens, i), child(afterSens, i), child(unknown, i), out)
+		}
+		return
+	}
+
+	appendLeaf(path, before, after, out)
+}
+
+func appendLeaf(path string, before, after interface{}, out *[]AttributeDiff) {
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+	*out = append(*out, AttributeDiff{Path: path, Before: before, After: after})
+}
+
+// hasUnknown reports whether an unknown tree contains any unknown value.
+func hasUnknown(v interface{}) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case map[string]interface{}:
+		for _, c := range t {
+			if hasUnknown(c) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, c := range t {
+			if hasUnknown(c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isTrue(v interface{}) bool {
+	b, ok := v.(bool)
+	return ok && b
+}
+
+// child returns the sub-tree of a sensitivity/unknown tree for a map key or list index.
+func child(tree interface{}, key interface{}) interface{} {
+	switch t := tree.(type) {
+	case map[string]interface{}:
+		if k, ok := key.(string); ok {
+			return t[k]
+		}
+	case []interface{}:
+		if i, ok := key.(int); ok && i < len(t) {
+			return t[i]
+		}
+	}
+	return nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func parseActionFilter(v string) map[string]bool {
+	if v == "" {
+		return nil
+	}
+	actions := make(map[string]bool)
+	for _, a := range strings.Split(v, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			actions[a] = true
+		}
+	}
+	return actions
+}
+
+func filterResourceDiffs(diffs []ResourceDiff, actions map[string]bool, addressPrefix string) []ResourceDiff {
+	if len(actions) == 0 && addressPrefix == "" {
+		return diffs
+	}
+	filtered := make([]ResourceDiff, 0, len(diffs))
+	for _, d := range diffs {
+		if len(actions) > 0 && !actions[d.Action] {
+			continue
+		}
+		if addressPrefix != "" && !strings.HasPrefix(d.Address, addressPrefix) {
+			continue
+		}
+		filtered = append(filtered, d)
+	}
+	return filtered
+}
+
+// paginateResourceDiffs returns one page of diffs; pages past the end are
+// empty. The page number is compared before multiplying, so it cannot overflow.
+func paginateResourceDiffs(diffs []ResourceDiff, pageNumber, pageSize int) []ResourceDiff {
+	if pageNumber < 1 || pageNumber-1 >= (len(diffs)+pageSize-1)/pageSize {
+		return []ResourceDiff{}
+	}
+	start := (pageNumber - 1) * pageSize
+	end := start + pageSize
+	if end > len(diffs) {
+		end = len(diffs)
+	}
+	return diffs[start:end]
+}
+
+// maskPlanContext replaces the sensitive values of a stored plan context with
+// the mask, keeping any other fields as they are.
+func maskPlanContext(data []byte) ([]byte, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if changes, ok := raw["resourceChanges"]; ok {
+		var resourceChanges []*tfjson.ResourceChange
+		if err := json.Unmarshal(changes, &resourceChanges); err != nil {
+			return nil, err
+		}
+		for _, rc := range resourceChanges {
+			if rc != nil {
+				maskChange(rc.Change)
+			}
+		}
+		encoded, err := json.Marshal(resourceChanges)
+		if err != nil {
+			return nil, err
+		}
+		raw["resourceChanges"] = encoded
+	}
+	if changes, ok := raw["outputChanges"]; ok {
+		var outputChanges map[string]*tfjson.Change
+		if err := json.Unmarshal(changes, &outputChanges); err != nil {
+			return nil, err
+		}
+		for _, change := range outputChanges {
+			maskChange(change)
+		}
+		encoded, err := json.Marshal(outputChanges)
+		if err != nil {
+			return nil, err
+		}
+		raw["outputChanges"] = encoded
+	}
+	return json.Marshal(raw)
+}
+
+func maskChange(change *tfjson.Change) {
+	if change == nil {
+		return
+	}
+	change.Before = maskSensitive(change.Before, change.BeforeSensitive)
+	change.After = maskSensitive(change.After, change.AfterSensitive)
+}
+
+// maskSensitive returns value with the parts its sensitivity tree marks
+// replaced by the mask.
+func maskSensitive(value, sensitive interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	if isTrue(sensitive) {
+		return sensitiveMask
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for k, c := range v {
+			masked[k] = maskSensitive(c, child(sensitive, k))
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, c := range v {
+			masked[i] = maskSensitive(c, child(sensitive, i))
+		}
+		return masked
+	}
+	return value
+}
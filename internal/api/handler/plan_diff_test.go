@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid test JSON %q: %v", s, err)
+	}
+	return v
+}
+
+func TestDiffAttributes(t *testing.T) {
+	tests := []struct {
+		name       string
+		before     string
+		after      string
+		beforeSens string
+		afterSens  string
+		unknown    string
+		want       []AttributeDiff
+	}{
+		{
+			name:   "update changes only",
+			before: `{"name":"logs","tags":{"env":"dev","team":"ops"}}`,
+			after:  `{"name":"logs","tags":{"env":"prod","team":"ops"}}`,
+			want:   []AttributeDiff{{Path: "tags.env", Before: "dev", After: "prod"}},
+		},
+		{
+			name:      "sensitive values are masked",
+			before:    `{"password":"old","user":"admin"}`,
+			after:     `{"password":"new","user":"root"}`,
+			afterSens: `{"password":true}`,
+			want: []AttributeDiff{
+				{Path: "password", Before: sensitiveMask, After: sensitiveMask, Sensitive: true},
+				{Path: "user", Before: "admin", After: "root"},
+			},
+		},
+		{
+			name:    "unknown after apply",
+			before:  `null`,
+			after:   `{"ami":"ami-123"}`,
+			unknown: `{"id":true}`,
+			want: []AttributeDiff{
+				{Path: "ami", Before: nil, After: "ami-123"},
+				{Path: "id", Unknown: true},
+			},
+		},
+		{
+			name:   "list elements",
+			before: `{"ports":[80,443]}`,
+			after:  `{"ports":[80]}`,
+			want:   []AttributeDiff{{Path: "ports[1]", Before: float64(443), After: nil}},
+		},
+		{
+			name:   "no-op",
+			before: `{"name":"logs"}`,
+			after:  `{"name":"logs"}`,
+			want:   []AttributeDiff{},
+		},
+	}
+
+	opt := func(s string) interface{} {
+		if s == "" {
+			return nil
+		}
+		return decodeJSON(t, s)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []AttributeDiff{}
+			diffAttributes("", opt(tt.before), opt(tt.after), opt(tt.beforeSens), opt(tt.afterSens), opt(tt.unknown), &got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffAttributes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterAndPaginateResourceDiffs(t *testing.T) {
+	changes := []*tfjson.ResourceChange{
+		{Address: "aws_s3_bucket.logs", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionCreate}}},
+		{Address: "module.vpc.aws_vpc.this", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionUpdate}}},
+		{Address: "module.vpc.aws_subnet.a", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate}}},
+		{Address: "aws_instance.web", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionNoop}}},
+	}
+	diffs := buildResourceDiffs(changes)
+
+	actions := []string{}
+	for _, d := range diffs {
+		actions = append(actions, d.Action)
+	}
+	if want := []string{"create", "update", "replace", "no-op"}; !reflect.DeepEqual(actions, want) {
+		t.Errorf("actions = %v, want %v", actions, want)
+	}
+
+	if got := filterResourceDiffs(diffs, parseActionFilter("update, replace"), ""); len(got) != 2 {
+		t.Errorf("action filter returned %d diffs, want 2", len(got))
+	}
+	if got := filterResourceDiffs(diffs, nil, "module.vpc."); len(got) != 2 {
+		t.Errorf("address filter returned %d diffs, want 2", len(got))
+	}
+	if got := filterResourceDiffs(diffs, parseActionFilter("replace"), "module.vpc."); len(got) != 1 || got[0].Address != "module.vpc.aws_subnet.a" {
+		t.Errorf("combined filter returned %+v", got)
+	}
+
+	if got := paginateResourceDiffs(diffs, 2, 3); len(got) != 1 || got[0].Address != "aws_instance.web" {
+		t.Errorf("page 2 = %+v", got)
+	}
+	if got := paginateResourceDiffs(diffs, 3, 3); len(got) != 0 {
+		t.Errorf("page 3 should be empty, got %d", len(got))
+	}
+}
+
+func TestPaginateResourceDiffsOutOfRange(t *testing.T) {
+	diffs := make([]ResourceDiff, 5)
+	tests := []struct {
+		page int
+		want int
+	}{
+		{0, 0},
+		{3, 1},
+		{4, 0},
+		{math.MaxInt, 0},
+	}
+	for _, tt := range tests {
+		if got := paginateResourceDiffs(diffs, tt.page, 2); len(got) != tt.want {
+			t.Errorf("page %d returned %d diffs, want %d", tt.page, len(got), tt.want)
+		}
+	}
+}
+
+func TestMaskPlanContext(t *testing.T) {
+	data := []byte(`{
+		"resourceChanges": [{
+			"address": "aws_db_instance.main",
+			"change": {
+				"actions": ["update"],
+				"before": {"password": "old", "tags": {"env": "dev"}, "users": ["a", "b"]},
+				"after": {"password": "new", "tags": {"env": "prod"}, "users": ["a", "c"]},
+				"before_sensitive": {"password": true, "users": [false, true]},
+				"after_sensitive": {"password": true, "users": [false, true]}
+			}
+		}],
+		"outputChanges": {
+			"token": {"actions": ["create"], "before": null, "after": "secret", "before_sensitive": false, "after_sensitive": true}
+		},
+		"summary": {"add": 0, "change": 1}
+	}`)
+	masked, err := maskPlanContext(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		ResourceChanges []*tfjson.ResourceChange  `json:"resourceChanges"`
+		OutputChanges   map[string]*tfjson.Change `json:"outputChanges"`
+		Summary         map[string]int            `json:"summary"`
+	}
+	if err := json.Unmarshal(masked, &got); err != nil {
+		t.Fatal(err)
+	}
+	after := got.ResourceChanges[0].Change.After
+	want := decodeJSON(t, `{"password": "(sensitive value)", "tags": {"env": "prod"}, "users": ["a", "(sensitive value)"]}`)
+	if !reflect.DeepEqual(after, want) {
+		t.Errorf("after = %v, want %v", after, want)
+	}
+	if before := got.ResourceChanges[0].Change.Before.(map[string]interface{}); before["password"] != sensitiveMask {
+		t.Errorf("before password = %v, want masked", before["password"])
+	}
+	if token := got.OutputChanges["token"].After; token != sensitiveMask {
+		t.Errorf("output token = %v, want masked", token)
+	}
+	if got.Summary["change"] != 1 {
+		t.Errorf("summary = %v, want it kept", got.Summary)
+	}
+}
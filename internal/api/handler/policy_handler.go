@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ilkerispir/terrakubed/internal/api/events"
+	"github.com/ilkerispir/terrakubed/internal/api/middleware"
+	"github.com/ilkerispir/terrakubed/internal/api/model"
+	"github.com/ilkerispir/terrakubed/internal/storage"
+)
+
+// PolicyHandler serves /policy/v1 — policy check results and overrides.
+//
+//	GET  /policy/v1/organization/{orgId}/job/{jobId}           → tfplan/{jobId}/policy.json
+//	POST /policy/v1/organization/{orgId}/job/{jobId}/override  → release a job blocked by a soft-mandatory failure
+type PolicyHandler struct {
+	pool       *pgxpool.Pool
+	storage    storage.StorageService
+	ownerGroup string
+	events     *events.Dispatcher
+}
+
+// NewPolicyHandler creates a new PolicyHandler.
+func NewPolicyHandler(pool *pgxpool.Pool, storage storage.StorageService, ownerGroup string, dispatcher *events.Dispatcher) *PolicyHandler {
+	return &PolicyHandler{pool: pool, storage: storage, ownerGroup: ownerGroup, events: dispatcher}
+}
+
+// policyCheckResult mirrors the executor's policy.Result stored in policy.json.
+type policyCheckResult struct {
+	Passed       bool                `json:"passed"`
+	HardFailed   bool                `json:"hardFailed"`
+	SoftFailed   bool                `json:"softFailed"`
+	Results      []policyCheckDetail `json:"results"`
+	Overridden   bool                `json:"overridden,omitempty"`
+	OverriddenBy string              `json:"overriddenBy,omitempty"`
+}
+
+type policyCheckDetail struct {
+	PolicySetId      string   `json:"policySetId"`
+	PolicySet        string   `json:"policySet"`
+	OverrideTeam     string   `json:"overrideTeam,omitempty"`
+	Policy           string   `json:"policy"`
+	EnforcementLevel string   `json:"enforcementLevel"`
+	Passed           bool     `json:"passed"`
+	Violations       []string `json:"violations,omitempty"`
+	Error            string   `json:"error,omitempty"`
+}
+
+func (h *PolicyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// organization/{orgId}/job/{jobId}[/override]
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/policy/v1/"), "/"), "/")
+	if len(parts) < 4 || parts[0] != "organization" || parts[2] != "job" {
+		http.Error(w, "invalid path — expected /policy/v1/organization/{orgId}/job/{jobId}", http.StatusBadRequest)
+		return
+	}
+	orgID, jobID := parts[1], parts[3]
+
+	switch {
+	case len(parts) == 4 && r.Method == http.MethodGet:
+		h.getResult(w, jobID)
+	case len(parts) == 5 && parts[4] == "override" && r.Method == http.MethodPost:
+		h.override(w, r, orgID, jobID)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+func (h *PolicyHandler) getResult(w http.ResponseWriter, jobID string) {
+	result, err := h.loadResult(jobID)
+	if err != nil {
+		http.Error(w, "policy result not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (h *PolicyHandler) override(w http.ResponseWriter, r *http.Request, orgID, jobID string) {
+	user := middleware.GetUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var status string
+	err := h.pool.QueryRow(r.Context(),
+		`SELECT status FROM job WHERE id = $1 AND organization_id = $2`, jobID, orgID,
+	).Scan(&status)
+	if err != nil {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+	if status != string(model.JobStatusWaitingPolicyOverride) {
+		http.Error(w, fmt.Sprintf("job is %s, not waiting for a policy override", status), http.StatusConflict)
+		return
+	}
+
+	result, err := h.loadResult(jobID)
+	if err != nil {
+		http.Error(w, "policy result not found", http.StatusNotFound)
+		return
+	}
+	if result.HardFailed {
+		http.Error(w, "hard-mandatory policy failures cannot be overridden", http.StatusConflict)
+		return
+	}
+	if !result.SoftFailed {
+		http.Error(w, "no soft-mandatory policy failures to override", http.StatusConflict)
+		return
+	}
+
+	if team, ok := h.canOverride(user, result); !ok {
+		http.Error(w, fmt.Sprintf("override requires membership of team %q", team), http.StatusForbidden)
+		return
+	}
+
+	result.Overridden = true
+	result.OverriddenBy = user.Email
+	if data, err := json.Marshal(result); err == nil {
+		if err := h.storage.UploadFile(fmt.Sprintf("tfplan/%s/policy.json", jobID), bytes.NewReader(data)); err != nil {
+			log.Printf("Failed to record policy override for job %s: %v", jobID, err)
+		}
+	}
+
+	// "pending" lets the scheduler continue with the next step of the flow
+	if _, err := h.pool.Exec(r.Context(),
+		`UPDATE job SET status = 'pending' WHERE id = $1 AND status = $2`, jobID, model.JobStatusWaitingPolicyOverride,
+	); err != nil {
+		log.Printf("Failed to release job %s after policy override: %v", jobID, err)
+		http.Error(w, "failed to update job", http.StatusInternalServerError)
+		return
+	}
+
+	h.events.EmitJobStatus(r.Context(), jobID, "pending")
+
+	log.Printf("Policy override for job %s by %s", jobID, user.Email)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "pending", "overriddenBy": user.Email})
+}
+
+// GuardJobStatus is a repository update guard: a job waiting for a policy
+// override can only be cancelled or rejected through the JSON:API and GraphQL
+// endpoints. Releasing it requires the override endpoint, which checks the
+// override team.
+func (h *PolicyHandler) GuardJobStatus(ctx context.Context, resourceType string, id interface{}, data map[string]interface{}) error {
+	if resourceType != "job" {
+		return nil
+	}
+	next, ok := data["status"]
+	if !ok {
+		return nil
+	}
+	switch fmt.Sprint(next) {
+	case string(model.JobStatusWaitingPolicyOverride), string(model.JobStatusCancelled), string(model.JobStatusRejected):
+		return nil
+	}
+	var status string
+	if err := h.pool.QueryRow(ctx, `SELECT status FROM job WHERE id = $1`, id).Scan(&status); err != nil {
+		return nil // the update reports unknown jobs
+	}
+	if status == string(model.JobStatusWaitingPolicyOverride) {
+		return fmt.Errorf("job is waiting for a policy override, use POST /policy/v1/organization/{orgId}/job/{jobId}/override")
+	}
+	return nil
+}
+
+// canOverride checks that the user belongs to the override team of every
+// policy set with a failed soft-mandatory policy. Owners can always override.
+// Returns the first team the user is missing.
+func (h *PolicyHandler) canOverride(user *middleware.UserInfo, result *policyCheckResult) (string, bool) {
+	if h.ownerGroup != "" && user.IsMember(h.ownerGroup) {
+		return "", true
+	}
+	for _, pr := range result.Results {
+		if pr.Passed || pr.EnforcementLevel != "soft-mandatory" {
+			continue
+		}
+		if pr.OverrideTeam == "" || !user.IsMember(pr.OverrideTeam) {
+			team := pr.OverrideTeam
+			if team == "" {
+				team = h.ownerGroup
+			}
+			return team, false
+		}
+	}
+	return "", true
+}
+
+func (h *PolicyHandler) loadResult(jobID string) (*policyCheckResult, error) {
+	reader, err := h.storage.DownloadFile(fmt.Sprintf("tfplan/%s/policy.json", jobID))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	var result policyCheckResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
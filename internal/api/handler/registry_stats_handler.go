@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ilkerispir/terrakubed/internal/api/middleware"
+)
+
+// RegistryStatsHandler serves /registry-stats/v1 — download statistics of
+// registry modules and providers. The registry reports downloads in batches
+// with an internal token. Statistics are read by members of the
+// organization's teams and the owner group.
+//
+//	POST /registry-stats/v1/downloads                                          → record download events
+//	GET  /registry-stats/v1/organization/{orgId}/module/{moduleId}[?interval=day&days=30&version=]
+//	GET  /registry-stats/v1/organization/{orgId}/provider/{providerId}[?interval=day&days=30&version=]
+type RegistryStatsHandler struct {
+	pool       *pgxpool.Pool
+	ownerGroup string
+}
+
+// NewRegistryStatsHandler creates a new RegistryStatsHandler.
+func NewRegistryStatsHandler(pool *pgxpool.Pool, ownerGroup string) *RegistryStatsHandler {
+	return &RegistryStatsHandler{pool: pool, ownerGroup: ownerGroup}
+}
+
+// downloadEvent is one download reported by the registry.
+type downloadEvent struct {
+	Type         string    `json:"type"` // module or provider
+	Organization string    `json:"organization"`
+	Name         string    `json:"name"`
+	Provider     string    `json:"provider"` // module provider
+	Version      string    `json:"version"`
+	OS           string    `json:"os"`
+	Arch         string    `json:"arch"`
+	ClientIP     string    `json:"clientIp"`
+	UserAgent    string    `json:"userAgent"`
+	Time         time.Time `json:"time"`
+}
+
+// maxDownloadBatch bounds the events accepted per request.
+const maxDownloadBatch = 1000
+
+func (h *RegistryStatsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/registry-stats/v1/"), "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "downloads" && r.Method == http.MethodPost:
+		h.record(w, r)
+	case len(parts) == 4 && parts[0] == "organization" && (parts[2] == "module" || parts[2] == "provider") && r.Method == http.MethodGet:
+		h.stats(w, r, parts[1], parts[2], parts[3])
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+func (h *RegistryStatsHandler) record(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUser(r.Context())
+	if user == nil || !user.IsInternal() {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	var body struct {
+		Downloads []downloadEvent `json:"downloads"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if len(body.Downloads) > maxDownloadBatch {
+		http.Error(w, fmt.Sprintf("at most %d downloads per request", maxDownloadBatch), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	batch := &pgx.Batch{}
+	for _, e := range body.Downloads {
+		if e.Time.IsZero() {
+			e.Time = time.Now()
+		}
+		switch e.Type {
+		case "module":
+			// Unknown modules insert nothing
+			batch.Queue(`
+				WITH m AS (
+					SELECT m.id, m.organization_id FROM module m
+					JOIN organization o ON o.id = m.organization_id
+					WHERE o.name = $2 AND m.name = $3 AND m.provider = $4
+				), ins AS (
+					INSERT INTO registry_download (id, organization_id, module_id, version, client_ip, user_agent, created_date)
+					SELECT $1, m.organization_id, m.id, $5, $6, $7, $8 FROM m
+					RETURNING module_id
+				)
+				UPDATE module SET download_quantity = COALESCE(download_quantity, 0) + 1
+				WHERE id IN (SELECT module_id FROM ins)`,
+				uuid.New(), e.Organization, e.Name, e.Provider, clip(e.Version, 64),
+				clip(e.ClientIP, 64), clip(e.UserAgent, 256), e.Time)
+		case "provider":
+			batch.Queue(`
+				INSERT INTO registry_download (id, organization_id, provider_id, version, os, arch, client_ip, user_agent, created_date)
+				SELECT $1, p.organization_id, p.id, $4, $5, $6, $7, $8, $9 FROM provider p
+				JOIN organization o ON o.id = p.organization_id
+				WHERE o.name = $2 AND p.name = $3`,
+				uuid.New(), e.Organization, e.Name, clip(e.Version, 64), clip(e.OS, 32), clip(e.Arch, 32),
+				clip(e.ClientIP, 64), clip(e.UserAgent, 256), e.Time)
+		}
+	}
+	if batch.Len() > 0 {
+		if err := h.pool.SendBatch(r.Context(), batch).Close(); err != nil {
+			log.Printf("Failed to record registry downloads: %v", err)
+			http.Error(w, "failed to record downloads", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// statsQuery selects the time series of a statistics request.
+type statsQuery struct {
+	interval string // date_trunc unit
+	since    time.Time
+	version  string
+}
+
+// parseStatsQuery reads interval (hour, day, week or month; default day),
+// days (1-366; default 30) and version.
+func parseStatsQuery(values url.Values, now time.Time) (statsQuery, error) {
+	q := statsQuery{interval: "day", version: values.Get("version")}
+	if interval := values.Get("interval"); interval != "" {
+		switch interval {
+		case "hour", "day", "week", "month":
+			q.interval = interval
+		default:
+			return q, fmt.Errorf("interval must be hour, day, week or month")
+		}
+	}
+	days := 30
+	if value := values.Get("days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 366 {
+			return q, fmt.Errorf("days must be between 1 and 366")
+		}
+		days = n
+	}
+	q.since = now.AddDate(0, 0, -days)
+	return q, nil
+}
+
+type versionDownloads struct {
+	Version      string    `json:"version"`
+	Downloads    int64     `json:"downloads"`
+	LastDownload time.Time `json:"lastDownload"`
+}
+
+type downloadPoint struct {
+	Time      time.Time `json:"time"`
+	Downloads int64     `json:"downloads"`
+}
+
+func (h *RegistryStatsHandler) stats(w http.ResponseWriter, r *http.Request, orgIDParam, kind, idParam string) {
+	q, err := parseStatsQuery(r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// kind is module or provider, checked by ServeHTTP
+	column := kind + "_id"
+
+	orgID, orgErr := uuid.Parse(orgIDParam)
+	if orgErr != nil {
+		http.Error(w, kind+" not found", http.StatusNotFound)
+		return
+	}
+	allowed, err := isOrganizationMember(r.Context(), h.pool, h.ownerGroup, middleware.GetUser(r.Context()), orgID.String())
+	if err != nil {
+		log.Printf("Registry stats: failed to check organization access: %v", err)
+		http.Error(w, "failed to check organization access", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	id, idErr := uuid.Parse(idParam)
+	var exists bool
+	if idErr != nil || h.pool.QueryRow(r.Context(),
+		fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1 AND organization_id = $2)`, kind), id, orgID,
+	).Scan(&exists) != nil || !exists {
+		http.Error(w, kind+" not found", http.StatusNotFound)
+		return
+	}
+
+	result := struct {
+		Total    int64              `json:"total"`
+		Versions []versionDownloads `json:"versions"`
+		Interval string             `json:"interval"`
+		Series   []downloadPoint    `json:"series"`
+	}{Versions: []versionDownloads{}, Interval: q.interval, Series: []downloadPoint{}}
+
+	rows, err := h.pool.Query(r.Context(), fmt.Sprintf(`
+		SELECT version, COUNT(*), MAX(created_date) FROM registry_download
+		WHERE %s = $1 AND created_date >= $2 AND ($3 = '' OR version = $3)
+		GROUP BY version ORDER BY COUNT(*) DESC, version`, column), id, q.since, q.version)
+	if err != nil {
+		log.Printf("Registry stats for %s %s: %v", kind, id, err)
+		http.Error(w, "failed to read statistics", http.StatusInternalServerError)
+		return
+	}
+	for rows.Next() {
+		var v versionDownloads
+		if err := rows.Scan(&v.Version, &v.Downloads, &v.LastDownload); err != nil {
+			rows.Close()
+			http.Error(w, "failed to read statistics", http.StatusInternalServerError)
+			return
+		}
+		result.Total += v.Downloads
+		result.Versions = append(result.Versions, v)
+	}
+	rows.Close()
+
+	rows, err = h.pool.Query(r.Context(), fmt.Sprintf(`
+		SELECT date_trunc($2, created_date), COUNT(*) FROM registry_download
+		WHERE %s = $1 AND created_date >= $3 AND ($4 = '' OR version = $4)
+		GROUP BY 1 ORDER BY 1`, column), id, q.interval, q.since, q.version)
+	if err != nil {
+		log.Printf("Registry stats for %s %s: %v", kind, id, err)
+		http.Error(w, "failed to read statistics", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p downloadPoint
+		if err := rows.Scan(&p.Time, &p.Downloads); err != nil {
+			http.Error(w, "failed to read statistics", http.StatusInternalServerError)
+			return
+		}
+		result.Series = append(result.Series, p)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// clip cuts s to the n bytes its column holds.
+func clip(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
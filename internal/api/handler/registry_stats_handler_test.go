@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ilkerispir/terrakubed/internal/api/middleware"
+)
+
+func TestParseStatsQuery(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		query        string
+		wantInterval string
+		wantSince    time.Time
+		wantErr      bool
+	}{
+		{"", "day", now.AddDate(0, 0, -30), false},
+		{"interval=hour&days=2&version=1.0.0", "hour", now.AddDate(0, 0, -2), false},
+		{"interval=month&days=366", "month", now.AddDate(0, 0, -366), false},
+		{"interval=minute", "", time.Time{}, true},
+		{"days=0", "", time.Time{}, true},
+		{"days=400", "", time.Time{}, true},
+		{"days=week", "", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			q, err := parseStatsQuery(values, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.interval != tt.wantInterval || !q.since.Equal(tt.wantSince) || q.version != values.Get("version") {
+				t.Errorf("query = %+v", q)
+			}
+		})
+	}
+}
+
+func TestRegistryStatsForbidden(t *testing.T) {
+	h := NewRegistryStatsHandler(nil, "TERRAKUBE_ADMIN")
+	path := "/registry-stats/v1/organization/" + uuid.NewString() + "/module/" + uuid.NewString()
+	tests := []struct {
+		name string
+		user *middleware.UserInfo
+	}{
+		{"anonymous", nil},
+		{"no groups", &middleware.UserInfo{Email: "dev@example.com", Issuer: "Terrakube"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			if tt.user != nil {
+				req = req.WithContext(context.WithValue(req.Context(), middleware.ContextKeyUser, tt.user))
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
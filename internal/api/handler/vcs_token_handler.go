@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ilkerispir/terrakubed/internal/api/middleware"
+	"github.com/ilkerispir/terrakubed/internal/api/vcstoken"
+)
+
+// VcsTokenHandler serves /vcs-token/v1 — a valid access token for a VCS
+// connection, refreshed first when the OAuth token has expired. Only internal
+// service tokens (registry, executor) may call it.
+//
+//	GET /vcs-token/v1/organization/{orgId}/vcs/{vcsId}
+type VcsTokenHandler struct {
+	pool   *pgxpool.Pool
+	tokens *vcstoken.Service
+}
+
+// NewVcsTokenHandler creates a new VcsTokenHandler.
+func NewVcsTokenHandler(pool *pgxpool.Pool, tokens *vcstoken.Service) *VcsTokenHandler {
+	return &VcsTokenHandler{pool: pool, tokens: tokens}
+}
+
+func (h *VcsTokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// organization/{orgId}/vcs/{vcsId}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/vcs-token/v1/"), "/"), "/")
+	if len(parts) != 4 || parts[0] != "organization" || parts[2] != "vcs" {
+		http.Error(w, "invalid path — expected /vcs-token/v1/organization/{orgId}/vcs/{vcsId}", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := middleware.GetUser(r.Context())
+	if user == nil || !user.IsInternal() {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	orgID, vcsID := parts[1], parts[3]
+
+	var exists bool
+	if err := h.pool.QueryRow(r.Context(),
+		`SELECT EXISTS (SELECT 1 FROM vcs WHERE id::text = $1 AND organization_id::text = $2)`, vcsID, orgID,
+	).Scan(&exists); err != nil || !exists {
+		http.Error(w, "vcs not found", http.StatusNotFound)
+		return
+	}
+
+	token, err := h.tokens.Token(r.Context(), vcsID)
+	if err != nil {
+		log.Printf("VCS token for %s: %v", vcsID, err)
+		http.Error(w, "failed to refresh vcs token", http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"accessToken": token})
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ilkerispir/terrakubed/internal/api/repository"
+	"github.com/ilkerispir/terrakubed/internal/api/webhook"
+)
+
+const maxWebhookBody = 5 << 20
+
+// VcsWebhookHandler serves /webhook/v1 — inbound push, tag and pull request
+// webhooks from the VCS providers. The path is public; requests are
+// authenticated with the VCS connection's webhook secret.
+//
+//	POST /webhook/v1/{vcsId}
+type VcsWebhookHandler struct {
+	pool *pgxpool.Pool
+	repo *repository.GenericRepository
+}
+
+// NewVcsWebhookHandler creates a new VcsWebhookHandler.
+func NewVcsWebhookHandler(pool *pgxpool.Pool, repo *repository.GenericRepository) *VcsWebhookHandler {
+	return &VcsWebhookHandler{pool: pool, repo: repo}
+}
+
+func (h *VcsWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	vcsID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/webhook/v1/"), "/")
+	if _, err := uuid.Parse(vcsID); err != nil {
+		http.Error(w, "invalid path — expected /webhook/v1/{vcsId}", http.StatusBadRequest)
+		return
+	}
+
+	var vcsType, secret, orgID string
+	err := h.pool.QueryRow(r.Context(),
+		`SELECT vcs_type, COALESCE(webhook_secret, ''), organization_id FROM vcs WHERE id = $1`, vcsID,
+	).Scan(&vcsType, &secret, &orgID)
+	if err != nil {
+		http.Error(w, "vcs not found", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	ev, err := webhook.Parse(vcsType, r.Header, body, secret)
+	switch {
+	case errors.Is(err, webhook.ErrIgnored):
+		writeWebhookResult(w, "ignored", nil)
+		return
+	case errors.Is(err, webhook.ErrSignature):
+		log.Printf("Rejected webhook for vcs %s: %v", vcsID, err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workspaces, err := h.loadWorkspaces(r.Context(), vcsID)
+	if err != nil {
+		log.Printf("Failed to load workspaces for vcs %s: %v", vcsID, err)
+		http.Error(w, "failed to load workspaces", http.StatusInternalServerError)
+		return
+	}
+
+	jobs := []interface{}{}
+	for _, ws := range workspaces {
+		templateID, ok := webhook.Match(ws, ev)
+		if !ok {
+			continue
+		}
+		var jobID interface{}
+		if ev.Kind == webhook.KindPullRequest {
+			jobID, err = h.createSpeculativeJob(r.Context(), orgID, ws.ID, ev)
+		} else if templateID == "" {
+			log.Printf("Webhook matched workspace %s but it has no default template", ws.ID)
+			continue
+		} else {
+			jobID, err = h.createJob(r.Context(), orgID, ws.ID, templateID, ev)
+		}
+		if err != nil {
+			log.Printf("Failed to create webhook job for workspace %s: %v", ws.ID, err)
+			continue
+		}
+		log.Printf("Webhook %s %s %s@%s → job %v in workspace %s", ev.Provider, ev.Kind, ev.Branch+ev.Tag, ev.CommitID, jobID, ws.ID)
+		jobs = append(jobs, jobID)
+	}
+	writeWebhookResult(w, "accepted", jobs)
+}
+
+// loadWorkspaces returns the VCS connection's workspaces with their webhook_event rules.
+func (h *VcsWebhookHandler) loadWorkspaces(ctx context.Context, vcsID string) ([]webhook.Workspace, error) {
+	rows, err := h.pool.Query(ctx,
+		`SELECT id::text, COALESCE(source, ''), COALESCE(branch, ''), COALESCE(folder, ''), COALESCE(default_template, ''),
+		        COALESCE(allow_fork_pull_requests, false)
+		 FROM workspace WHERE vcs_id = $1 AND deleted = false`, vcsID)
+	if err != nil {
+		return nil, err
+	}
+	var workspaces []webhook.Workspace
+	index := map[string]int{}
+	for rows.Next() {
+		var ws webhook.Workspace
+		if err := rows.Scan(&ws.ID, &ws.Source, &ws.Branch, &ws.Folder, &ws.DefaultTemplate, &ws.AllowForkPullRequests); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[ws.ID] = len(workspaces)
+		workspaces = append(workspaces, ws)
+	}
+	rows.Close()
+	if len(workspaces) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(workspaces))
+	for _, ws := range workspaces {
+		ids = append(ids, ws.ID)
+	}
+	rows, err = h.pool.Query(ctx,
+		`SELECT w.workspace_id::text, COALESCE(e.event, ''), COALESCE(e.branch, ''), COALESCE(e.path, ''),
+		        COALESCE(e.template_id, ''), COALESCE(e.priority, 0)
+		 FROM webhook_event e JOIN webhook w ON w.id = e.webhook_id
+		 WHERE w.workspace_id::text = ANY($1)`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var wsID string
+		var rule webhook.Rule
+		if err := rows.Scan(&wsID, &rule.Event, &rule.Branch, &rule.Path, &rule.TemplateID, &rule.Priority); err != nil {
+			return nil, err
+		}
+		if i, ok := index[wsID]; ok {
+			workspaces[i].Rules = append(workspaces[i].Rules, rule)
+		}
+	}
+	return workspaces, rows.Err()
+}
+
+// createJob queues a job like the UI does; the scheduler picks up "pending" jobs.
+func (h *VcsWebhookHandler) createJob(ctx context.Context, orgID, workspaceID, templateID string, ev *webhook.Event) (interface{}, error) {
+	data := map[string]interface{}{
+		"organization_id":    orgID,
+		"workspace_id":       workspaceID,
+		"template_reference": templateID,
+		"commit_id":          ev.CommitID,
+		"via":                "webhook",
+	}
+	var tcl string
+	if err := h.pool.QueryRow(ctx, `SELECT tcl FROM template WHERE id::text = $1`, templateID).Scan(&tcl); err == nil {
+		data["tcl"] = tcl
+	}
+	if ev.Kind == webhook.KindTag {
+		data["override_branch"] = ev.Tag
+	}
+	return h.repo.Create(ctx, "job", data)
+}
+
+// speculativeTcl is the flow of pull request jobs: a single plan, so there is
+// never anything to approve or apply. Stored base64-encoded like template TCL.
+var speculativeTcl = base64.StdEncoding.EncodeToString([]byte(`flow:
+  - type: "terraformPlan"
+    name: "Speculative Plan"
+    step: 100
+`))
+
+// createSpeculativeJob queues a plan-only job for the pull request head
+// commit. The executor reports the result back on the pull request.
+//
+// The head branch of a fork does not exist in the workspace repository, so
+// fork jobs keep the workspace branch and are fetched by the pinned commit,
+// which the provider exposes through the pull request ref.
+func (h *VcsWebhookHandler) createSpeculativeJob(ctx context.Context, orgID, workspaceID string, ev *webhook.Event) (interface{}, error) {
+	pr := ev.PullRequest
+	if pr.HeadCommit == "" {
+		return nil, errors.New("pull request has no head commit")
+	}
+	data := map[string]interface{}{
+		"organization_id":     orgID,
+		"workspace_id":        workspaceID,
+		"tcl":                 speculativeTcl,
+		"commit_id":           pr.HeadCommit,
+		"pull_request_number": pr.Number,
+		"pull_request_url":    pr.URL,
+		"via":                 "webhook",
+	}
+	if !pr.Fork {
+		data["override_branch"] = pr.HeadBranch
+	}
+	return h.repo.Create(ctx, "job", data)
+}
+
+func writeWebhookResult(w http.ResponseWriter, status string, jobs []interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "jobs": jobs})
+}
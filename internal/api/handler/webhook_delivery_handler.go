@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ilkerispir/terrakubed/internal/api/events"
+	"github.com/ilkerispir/terrakubed/internal/api/middleware"
+)
+
+// WebhookDeliveryHandler serves /webhook-delivery/v1 — actions on outbound webhook
+// deliveries. The delivery history itself is the webhook_delivery JSON:API resource.
+// (/webhook/v1 is the public inbound VCS webhook path and must not be used here.)
+//
+//	POST /webhook-delivery/v1/{deliveryId}/redeliver  → queue a new delivery with the same payload
+//
+// Deliveries are redelivered by members of the endpoint's organization.
+type WebhookDeliveryHandler struct {
+	pool       *pgxpool.Pool
+	dispatcher *events.Dispatcher
+	ownerGroup string
+}
+
+// NewWebhookDeliveryHandler creates a new WebhookDeliveryHandler.
+func NewWebhookDeliveryHandler(pool *pgxpool.Pool, dispatcher *events.Dispatcher, ownerGroup string) *WebhookDeliveryHandler {
+	return &WebhookDeliveryHandler{pool: pool, dispatcher: dispatcher, ownerGroup: ownerGroup}
+}
+
+func (h *WebhookDeliveryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// {deliveryId}/redeliver
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/webhook-delivery/v1/"), "/"), "/")
+	if len(parts) != 2 || parts[1] != "redeliver" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := middleware.GetUser(r.Context())
+	if user == nil {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	orgID, err := h.dispatcher.DeliveryOrganization(r.Context(), parts[0])
+	if err != nil {
+		http.Error(w, "delivery not found", http.StatusNotFound)
+		return
+	}
+	allowed, err := isOrganizationMember(r.Context(), h.pool, h.ownerGroup, user, orgID)
+	if err != nil {
+		log.Printf("Redeliver %s: failed to check organization access: %v", parts[0], err)
+		http.Error(w, "failed to check organization access", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	id, err := h.dispatcher.Redeliver(r.Context(), parts[0])
+	if err != nil {
+		log.Printf("Redeliver %s failed: %v", parts[0], err)
+		http.Error(w, "delivery not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"id": id, "status": events.StatusPending})
+}
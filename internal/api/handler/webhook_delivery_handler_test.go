@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedeliverRequiresUser(t *testing.T) {
+	h := NewWebhookDeliveryHandler(nil, nil, "TERRAKUBE_ADMIN")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook-delivery/v1/6f1c2d4e-8a3b-4c5d-9e7f-0a1b2c3d4e5f/redeliver", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	jobmodel "github.com/ilkerispir/terrakubed/internal/model"
 )
 
 // ──────────────────────────────────────────────────
@@ -28,6 +30,10 @@ const (
 	JobStatusNeverExecuted   JobStatus = "NeverExecuted"
 )
 
+// JobStatusWaitingPolicyOverride parks a job after a soft-mandatory policy
+// failure; only the policy override endpoint releases it.
+const JobStatusWaitingPolicyOverride JobStatus = "waitingPolicyOverride"
+
 type LogStatus string
 
 const (
@@ -58,7 +64,8 @@ const (
 type VcsConnectionType string
 
 const (
-	VcsConnectionTypeOAuth VcsConnectionType = "OAUTH"
+	VcsConnectionTypeOAuth      VcsConnectionType = "OAUTH"
+	VcsConnectionTypeStandalone VcsConnectionType = "STANDALONE"
 )
 
 type VcsStatus string
@@ -81,6 +88,39 @@ const (
 	VariableCategoryEnv       VariableCategory = "ENV"
 )
 
+// DriftStatus is shared with the executor, which writes it.
+type DriftStatus = jobmodel.DriftStatus
+
+const (
+	DriftStatusInSync  = jobmodel.DriftStatusInSync
+	DriftStatusDrifted = jobmodel.DriftStatusDrifted
+)
+
+type NotificationDestinationType string
+
+const (
+	NotificationDestinationSlack   NotificationDestinationType = "slack"
+	NotificationDestinationTeams   NotificationDestinationType = "teams"
+	NotificationDestinationWebhook NotificationDestinationType = "webhook"
+	NotificationDestinationEmail   NotificationDestinationType = "email"
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending    WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusDelivered  WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryStatusDeadLetter WebhookDeliveryStatus = "deadLetter"
+)
+
+type EnforcementLevel string
+
+const (
+	EnforcementLevelAdvisory      EnforcementLevel = "advisory"
+	EnforcementLevelSoftMandatory EnforcementLevel = "soft-mandatory"
+	EnforcementLevelHardMandatory EnforcementLevel = "hard-mandatory"
+)
+
 type AddressType string
 
 const (
@@ -147,11 +187,16 @@ type Workspace struct {
 	ModuleSshKey     string        `json:"moduleSshKey"     db:"module_ssh_key"`
 	TerraformVersion string        `json:"terraformVersion" db:"terraform_version"`
 	ExecutionMode    ExecutionMode `json:"executionMode"    db:"execution_mode"`
+	DriftStatus      *DriftStatus  `json:"driftStatus"      db:"drift_status"`
+	DriftCheckedDate *time.Time    `json:"driftCheckedDate" db:"drift_checked_date"`
 	OrganizationID   uuid.UUID     `json:"organizationId"   db:"organization_id"`
 	ProjectID        *uuid.UUID    `json:"projectId"        db:"project_id"`
 	VcsID            *uuid.UUID    `json:"vcsId"            db:"vcs_id"`
 	SshID            *uuid.UUID    `json:"sshId"            db:"ssh_id"`
 	AgentID          *uuid.UUID    `json:"agentId"          db:"agent_id"`
+
+	// Run speculative plans for pull requests from forks of the repository
+	AllowForkPullRequests bool `json:"allowForkPullRequests" db:"allow_fork_pull_requests"`
 }
 
 // Job — table "job" (integer PK, auto-increment)
@@ -172,7 +217,13 @@ type Job struct {
 	Via               string    `json:"via"               db:"via"`
 	Refresh           bool      `json:"refresh"           db:"refresh"`
 	PlanChanges       bool      `json:"planChanges"       db:"plan_changes"`
+	PlanAdd           *int      `json:"planAdd"           db:"plan_add"`
+	PlanChange        *int      `json:"planChange"        db:"plan_change"`
+	PlanDestroy       *int      `json:"planDestroy"       db:"plan_destroy"`
+	PlanReplace       *int      `json:"planReplace"       db:"plan_replace"`
 	RefreshOnly       bool      `json:"refreshOnly"       db:"refresh_only"`
+	PullRequestNumber *int      `json:"pullRequestNumber" db:"pull_request_number"`
+	PullRequestURL    *string   `json:"pullRequestUrl"    db:"pull_request_url"`
 	OrganizationID    uuid.UUID `json:"organizationId"    db:"organization_id"`
 	WorkspaceID       uuid.UUID `json:"workspaceId"       db:"workspace_id"`
 }
@@ -237,6 +288,7 @@ type Vcs struct {
 	RefreshToken    string            `json:"-"               db:"refresh_token"`
 	TokenExpiration *time.Time        `json:"-"               db:"token_expiration"`
 	RedirectURL     string            `json:"redirectUrl"     db:"redirect_url"`
+	WebhookSecret   string            `json:"webhookSecret"   db:"webhook_secret"`
 	OrganizationID  uuid.UUID         `json:"organizationId"  db:"organization_id"`
 }
 
@@ -248,6 +300,10 @@ type GitHubAppToken struct {
 	InstallationID string    `json:"installationId" db:"installation_id"`
 	Token          string    `json:"token"          db:"token"`
 	AppID          string    `json:"appId"          db:"app_id"`
+
+	// Organization whose clones may use the installation; rows without one
+	// are not used
+	OrganizationID *uuid.UUID `json:"organizationId" db:"organization_id"`
 }
 
 // Ssh — table "ssh"
@@ -261,6 +317,29 @@ type Ssh struct {
 	OrganizationID uuid.UUID `json:"organizationId" db:"organization_id"`
 }
 
+// SshKnownHost — table "ssh_known_host": an SSH host key trusted for git
+// clones of the organization. Source is "manual" for keys added by users and
+// "tofu" for keys pinned on first use.
+type SshKnownHost struct {
+	AuditFields
+	ID             uuid.UUID `json:"id"             db:"id"`
+	Host           string    `json:"host"           db:"host"`
+	KeyType        string    `json:"keyType"        db:"key_type"`
+	PublicKey      string    `json:"publicKey"      db:"public_key"`
+	Source         string    `json:"source"         db:"source"`
+	OrganizationID uuid.UUID `json:"organizationId" db:"organization_id"`
+}
+
+// GpgKey — table "gpg_key": the ASCII-armored private key, without passphrase,
+// the registry signs the organization's provider releases (SHA256SUMS) with.
+type GpgKey struct {
+	AuditFields
+	ID             uuid.UUID `json:"id"             db:"id"`
+	Name           string    `json:"name"           db:"name"`
+	PrivateKey     string    `json:"privateKey"     db:"private_key"`
+	OrganizationID uuid.UUID `json:"organizationId" db:"organization_id"`
+}
+
 // ──────────────────────────────────────────────────
 // Module & Provider registry
 // ──────────────────────────────────────────────────
@@ -503,6 +582,81 @@ type WebhookEvent struct {
 	WebhookID  uuid.UUID        `json:"webhookId"  db:"webhook_id"`
 }
 
+// ──────────────────────────────────────────────────
+// Policy as code
+// ──────────────────────────────────────────────────
+
+// PolicySet — table "policy_set" (organization-scoped group of Rego policies)
+type PolicySet struct {
+	AuditFields
+	ID             uuid.UUID `json:"id"             db:"id"`
+	Name           string    `json:"name"           db:"name"`
+	Description    string    `json:"description"    db:"description"`
+	OverrideTeam   string    `json:"overrideTeam"   db:"override_team"`
+	OrganizationID uuid.UUID `json:"organizationId" db:"organization_id"`
+}
+
+// Policy — table "policy"
+type Policy struct {
+	AuditFields
+	ID               uuid.UUID        `json:"id"               db:"id"`
+	Name             string           `json:"name"             db:"name"`
+	Description      string           `json:"description"      db:"description"`
+	Rego             string           `json:"rego"             db:"rego"`
+	EnforcementLevel EnforcementLevel `json:"enforcementLevel" db:"enforcement_level"`
+	PolicySetID      uuid.UUID        `json:"policySetId"      db:"policy_set_id"`
+}
+
+// ──────────────────────────────────────────────────
+// Notifications
+// ──────────────────────────────────────────────────
+
+// Notification — table "notification" (organization-wide when WorkspaceID is nil).
+// Events and EmailAddresses are comma-separated; an empty Events list subscribes
+// to every event (planPending, noChanges, approved, success, failure, drift).
+type Notification struct {
+	AuditFields
+	ID              uuid.UUID                   `json:"id"              db:"id"`
+	Name            string                      `json:"name"            db:"name"`
+	DestinationType NotificationDestinationType `json:"destinationType" db:"destination_type"`
+	URL             string                      `json:"url"             db:"url"`
+	Token           string                      `json:"token"           db:"token"`
+	EmailAddresses  string                      `json:"emailAddresses"  db:"email_addresses"`
+	Events          string                      `json:"events"          db:"events"`
+	Enabled         bool                        `json:"enabled"         db:"enabled"`
+	OrganizationID  uuid.UUID                   `json:"organizationId"  db:"organization_id"`
+	WorkspaceID     *uuid.UUID                  `json:"workspaceId"     db:"workspace_id"`
+}
+
+// WebhookEndpoint — table "webhook_endpoint" (outbound job lifecycle events).
+// Events is a comma-separated list of event types (job.status, step.status);
+// empty subscribes to all. Deliveries are signed with Secret.
+type WebhookEndpoint struct {
+	AuditFields
+	ID             uuid.UUID `json:"id"             db:"id"`
+	Name           string    `json:"name"           db:"name"`
+	URL            string    `json:"url"            db:"url"`
+	Secret         string    `json:"secret"         db:"secret"`
+	Events         string    `json:"events"         db:"events"`
+	Enabled        bool      `json:"enabled"        db:"enabled"`
+	OrganizationID uuid.UUID `json:"organizationId" db:"organization_id"`
+}
+
+// WebhookDelivery — table "webhook_delivery" (delivery history of an endpoint)
+type WebhookDelivery struct {
+	ID              uuid.UUID             `json:"id"              db:"id"`
+	EventType       string                `json:"eventType"       db:"event_type"`
+	Payload         string                `json:"payload"         db:"payload"`
+	Status          WebhookDeliveryStatus `json:"status"          db:"status"`
+	Attempts        int                   `json:"attempts"        db:"attempts"`
+	ResponseCode    *int                  `json:"responseCode"    db:"response_code"`
+	LastError       *string               `json:"lastError"       db:"last_error"`
+	NextAttemptDate *time.Time            `json:"nextAttemptDate" db:"next_attempt_date"`
+	LastAttemptDate *time.Time            `json:"lastAttemptDate" db:"last_attempt_date"`
+	CreatedDate     *time.Time            `json:"createdDate"     db:"created_date"`
+	EndpointID      uuid.UUID             `json:"endpointId"      db:"endpoint_id"`
+}
+
 // ──────────────────────────────────────────────────
 // Auth & Tokens
 // ──────────────────────────────────────────────────
@@ -20,19 +20,25 @@ func RegisterAll(repo *repository.GenericRepository) {
 		SoftDeleteColumn: "disabled",
 		Parents:          map[string]repository.ParentRelation{},
 		Children: map[string]repository.ChildRelation{
-			"workspace":  {ChildType: "workspace", FKColumn: "organization_id"},
-			"job":        {ChildType: "job", FKColumn: "organization_id"},
-			"team":       {ChildType: "team", FKColumn: "organization_id"},
-			"template":   {ChildType: "template", FKColumn: "organization_id"},
-			"module":     {ChildType: "module", FKColumn: "organization_id"},
-			"provider":   {ChildType: "provider", FKColumn: "organization_id"},
-			"vcs":        {ChildType: "vcs", FKColumn: "organization_id"},
-			"ssh":        {ChildType: "ssh", FKColumn: "organization_id"},
-			"agent":      {ChildType: "agent", FKColumn: "organization_id"},
-			"globalvar":  {ChildType: "globalvar", FKColumn: "organization_id"},
-			"tag":        {ChildType: "tag", FKColumn: "organization_id"},
-			"collection": {ChildType: "collection", FKColumn: "organization_id"},
-			"project":    {ChildType: "project", FKColumn: "organization_id"},
+			"workspace":       {ChildType: "workspace", FKColumn: "organization_id"},
+			"job":             {ChildType: "job", FKColumn: "organization_id"},
+			"team":            {ChildType: "team", FKColumn: "organization_id"},
+			"template":        {ChildType: "template", FKColumn: "organization_id"},
+			"module":          {ChildType: "module", FKColumn: "organization_id"},
+			"provider":        {ChildType: "provider", FKColumn: "organization_id"},
+			"vcs":             {ChildType: "vcs", FKColumn: "organization_id"},
+			"ssh":             {ChildType: "ssh", FKColumn: "organization_id"},
+			"knownHost":       {ChildType: "ssh_known_host", FKColumn: "organization_id"},
+			"gpgKey":          {ChildType: "gpg_key", FKColumn: "organization_id"},
+			"githubAppToken":  {ChildType: "github_app_token", FKColumn: "organization_id"},
+			"agent":           {ChildType: "agent", FKColumn: "organization_id"},
+			"globalvar":       {ChildType: "globalvar", FKColumn: "organization_id"},
+			"tag":             {ChildType: "tag", FKColumn: "organization_id"},
+			"collection":      {ChildType: "collection", FKColumn: "organization_id"},
+			"project":         {ChildType: "project", FKColumn: "organization_id"},
+			"policySet":       {ChildType: "policy_set", FKColumn: "organization_id"},
+			"notification":    {ChildType: "notification", FKColumn: "organization_id"},
+			"webhookEndpoint": {ChildType: "webhook_endpoint", FKColumn: "organization_id"},
 		},
 	})
 
@@ -58,6 +64,7 @@ func RegisterAll(repo *repository.GenericRepository) {
 			"workspaceTag": {ChildType: "workspacetag", FKColumn: "workspace_id"},
 			"access":       {ChildType: "access", FKColumn: "workspace_id"},
 			"reference":    {ChildType: "reference", FKColumn: "workspace_id"},
+			"notification": {ChildType: "notification", FKColumn: "workspace_id"},
 		},
 	})
 
@@ -153,14 +160,43 @@ func RegisterAll(repo *repository.GenericRepository) {
 		Children: map[string]repository.ChildRelation{},
 	})
 
+	repo.Register(&repository.ResourceMeta{
+		Type:      "ssh_known_host",
+		Table:     "ssh_known_host",
+		PKColumn:  "id",
+		PKType:    "uuid",
+		ModelType: reflect.TypeOf(model.SshKnownHost{}),
+		Parents: map[string]repository.ParentRelation{
+			"organization": {FKColumn: "organization_id", ParentType: "organization"},
+		},
+		Children: map[string]repository.ChildRelation{},
+		DefaultValues: map[string]interface{}{
+			"source": "manual",
+		},
+	})
+
+	repo.Register(&repository.ResourceMeta{
+		Type:      "gpg_key",
+		Table:     "gpg_key",
+		PKColumn:  "id",
+		PKType:    "uuid",
+		ModelType: reflect.TypeOf(model.GpgKey{}),
+		Parents: map[string]repository.ParentRelation{
+			"organization": {FKColumn: "organization_id", ParentType: "organization"},
+		},
+		Children: map[string]repository.ChildRelation{},
+	})
+
 	repo.Register(&repository.ResourceMeta{
 		Type:      "github_app_token",
 		Table:     "github_app_token",
 		PKColumn:  "id",
 		PKType:    "uuid",
 		ModelType: reflect.TypeOf(model.GitHubAppToken{}),
-		Parents:   map[string]repository.ParentRelation{},
-		Children:  map[string]repository.ChildRelation{},
+		Parents: map[string]repository.ParentRelation{
+			"organization": {FKColumn: "organization_id", ParentType: "organization"},
+		},
+		Children: map[string]repository.ChildRelation{},
 	})
 
 	// ── Modules & Providers ────────────────────────
@@ -428,6 +464,84 @@ func RegisterAll(repo *repository.GenericRepository) {
 		Children: map[string]repository.ChildRelation{},
 	})
 
+	// ── Policy as code ─────────────────────────────
+
+	repo.Register(&repository.ResourceMeta{
+		Type:      "policy_set",
+		Table:     "policy_set",
+		PKColumn:  "id",
+		PKType:    "uuid",
+		ModelType: reflect.TypeOf(model.PolicySet{}),
+		Parents: map[string]repository.ParentRelation{
+			"organization": {FKColumn: "organization_id", ParentType: "organization"},
+		},
+		Children: map[string]repository.ChildRelation{
+			"policy": {ChildType: "policy", FKColumn: "policy_set_id"},
+		},
+	})
+
+	repo.Register(&repository.ResourceMeta{
+		Type:      "policy",
+		Table:     "policy",
+		PKColumn:  "id",
+		PKType:    "uuid",
+		ModelType: reflect.TypeOf(model.Policy{}),
+		Parents: map[string]repository.ParentRelation{
+			"policySet": {FKColumn: "policy_set_id", ParentType: "policy_set"},
+		},
+		Children: map[string]repository.ChildRelation{},
+		DefaultValues: map[string]interface{}{
+			"enforcement_level": "advisory",
+		},
+	})
+
+	// ── Notifications ──────────────────────────────
+
+	repo.Register(&repository.ResourceMeta{
+		Type:      "notification",
+		Table:     "notification",
+		PKColumn:  "id",
+		PKType:    "uuid",
+		ModelType: reflect.TypeOf(model.Notification{}),
+		Parents: map[string]repository.ParentRelation{
+			"organization": {FKColumn: "organization_id", ParentType: "organization"},
+			"workspace":    {FKColumn: "workspace_id", ParentType: "workspace"},
+		},
+		Children: map[string]repository.ChildRelation{},
+		DefaultValues: map[string]interface{}{
+			"enabled": true,
+		},
+	})
+
+	repo.Register(&repository.ResourceMeta{
+		Type:      "webhook_endpoint",
+		Table:     "webhook_endpoint",
+		PKColumn:  "id",
+		PKType:    "uuid",
+		ModelType: reflect.TypeOf(model.WebhookEndpoint{}),
+		Parents: map[string]repository.ParentRelation{
+			"organization": {FKColumn: "organization_id", ParentType: "organization"},
+		},
+		Children: map[string]repository.ChildRelation{
+			"delivery": {ChildType: "webhook_delivery", FKColumn: "endpoint_id"},
+		},
+		DefaultValues: map[string]interface{}{
+			"enabled": true,
+		},
+	})
+
+	repo.Register(&repository.ResourceMeta{
+		Type:      "webhook_delivery",
+		Table:     "webhook_delivery",
+		PKColumn:  "id",
+		PKType:    "uuid",
+		ModelType: reflect.TypeOf(model.WebhookDelivery{}),
+		Parents: map[string]repository.ParentRelation{
+			"endpoint": {FKColumn: "endpoint_id", ParentType: "webhook_endpoint"},
+		},
+		Children: map[string]repository.ChildRelation{},
+	})
+
 	// ── Auth ───────────────────────────────────────
 
 	repo.Register(&repository.ResourceMeta{
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"reflect"
@@ -51,10 +52,22 @@ type ChildRelation struct {
 	FKColumn  string // FK column in child table, e.g. "organization_id"
 }
 
+// UpdateHook is called after a successful Update with the patched columns.
+type UpdateHook func(ctx context.Context, resourceType string, id interface{}, data map[string]interface{})
+
+// UpdateGuard is called before an Update with the patched columns; an error
+// rejects the update.
+type UpdateGuard func(ctx context.Context, resourceType string, id interface{}, data map[string]interface{}) error
+
+// ErrUpdateRejected wraps the error of a guard that rejected an Update.
+var ErrUpdateRejected = errors.New("update rejected")
+
 // GenericRepository provides CRUD operations for any registered resource type.
 type GenericRepository struct {
-	pool      *pgxpool.Pool
-	resources map[string]*ResourceMeta
+	pool         *pgxpool.Pool
+	resources    map[string]*ResourceMeta
+	updateHooks  []UpdateHook
+	updateGuards []UpdateGuard
 }
 
 // NewGenericRepository creates a new GenericRepository.
@@ -65,6 +78,16 @@ func NewGenericRepository(pool *pgxpool.Pool) *GenericRepository {
 	}
 }
 
+// OnUpdate registers a hook run after every successful Update.
+func (r *GenericRepository) OnUpdate(hook UpdateHook) {
+	r.updateHooks = append(r.updateHooks, hook)
+}
+
+// GuardUpdate registers a guard run before every Update.
+func (r *GenericRepository) GuardUpdate(guard UpdateGuard) {
+	r.updateGuards = append(r.updateGuards, guard)
+}
+
 // Register registers a ResourceMeta for a given JSON:API type.
 func (r *GenericRepository) Register(meta *ResourceMeta) {
 	// Build column list, field map, and JSON name map from struct tags
@@ -346,6 +369,12 @@ func (r *GenericRepository) Update(ctx context.Context, resourceType string, id
 		return fmt.Errorf("unknown resource type: %s", resourceType)
 	}
 
+	for _, guard := range r.updateGuards {
+		if err := guard(ctx, resourceType, id, data); err != nil {
+			return fmt.Errorf("%w: %v", ErrUpdateRejected, err)
+		}
+	}
+
 	var setClauses []string
 	var args []interface{}
 	argIdx := 1
@@ -375,6 +404,9 @@ func (r *GenericRepository) Update(ctx context.Context, resourceType string, id
 	if err != nil {
 		return fmt.Errorf("update failed: %w", err)
 	}
+	for _, hook := range r.updateHooks {
+		hook(ctx, resourceType, id, data)
+	}
 	return nil
 }
 
@@ -8,12 +8,17 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ilkerispir/terrakubed/internal/api/events"
+	"github.com/ilkerispir/terrakubed/internal/api/vcstoken"
 )
 
 // JobScheduler polls for pending jobs and dispatches them to an executor.
 type JobScheduler struct {
 	pool     *pgxpool.Pool
 	executor Executor
+	tokens   *vcstoken.Service
+	events   *events.Dispatcher
 	interval time.Duration
 }
 
@@ -46,10 +51,12 @@ type ExecutionContext struct {
 }
 
 // NewJobScheduler creates a new scheduler.
-func NewJobScheduler(pool *pgxpool.Pool, executor Executor, interval time.Duration) *JobScheduler {
+func NewJobScheduler(pool *pgxpool.Pool, executor Executor, tokens *vcstoken.Service, dispatcher *events.Dispatcher, interval time.Duration) *JobScheduler {
 	return &JobScheduler{
 		pool:     pool,
 		executor: executor,
+		tokens:   tokens,
+		events:   dispatcher,
 		interval: interval,
 	}
 }
@@ -78,9 +85,9 @@ func (s *JobScheduler) pollJobs(ctx context.Context) {
 	rows, err := s.pool.Query(ctx, `
 		SELECT j.id, j.status, j.tcl, j.template_reference, j.commit_id,
 		       j.organization_id, j.workspace_id, j.refresh, j.refresh_only,
-		       w.source, w.branch, w.folder, w.terraform_version, w.iac_type,
+		       w.source, COALESCE(NULLIF(j.override_branch, ''), w.branch), w.folder, w.terraform_version, w.iac_type,
 		       w.module_ssh_key,
-		       v.vcs_type, v.connection_type, v.access_token
+		       v.id::text, v.vcs_type, v.connection_type, v.access_token
 		FROM job j
 		JOIN workspace w ON j.workspace_id = w.id
 		LEFT JOIN vcs v ON w.vcs_id = v.id
@@ -111,6 +118,7 @@ func (s *JobScheduler) pollJobs(ctx context.Context) {
 			terraformVersion *string
 			iacType          *string
 			moduleSshKey     *string
+			vcsID            *string
 			vcsType          *string
 			connectionType   *string
 			accessToken      *string
@@ -121,7 +129,7 @@ func (s *JobScheduler) pollJobs(ctx context.Context) {
 			&orgID, &workspaceID, &refresh, &refreshOnly,
 			&source, &branch, &folder, &terraformVersion, &iacType,
 			&moduleSshKey,
-			&vcsType, &connectionType, &accessToken,
+			&vcsID, &vcsType, &connectionType, &accessToken,
 		); err != nil {
 			log.Printf("Error scanning job row: %v", err)
 			continue
@@ -129,8 +137,7 @@ func (s *JobScheduler) pollJobs(ctx context.Context) {
 
 		if status == "pending" {
 			// Transition to "queue" status
-			_, err := s.pool.Exec(ctx, "UPDATE job SET status = 'queue' WHERE id = $1", jobID)
-			if err != nil {
+			if err := s.setJobStatus(ctx, jobID, "queue"); err != nil {
 				log.Printf("Error updating job %d status: %v", jobID, err)
 				continue
 			}
@@ -149,6 +156,16 @@ func (s *JobScheduler) pollJobs(ctx context.Context) {
 			continue
 		}
 
+		// OAuth tokens may have expired since they were stored
+		if vcsID != nil && s.tokens != nil {
+			token, err := s.tokens.Token(ctx, *vcsID)
+			if err != nil {
+				log.Printf("Job %d: %v — using stored VCS token", jobID, err)
+			} else {
+				accessToken = &token
+			}
+		}
+
 		// Build execution context
 		execCtx := &ExecutionContext{
 			OrganizationID:   orgID,
@@ -175,15 +192,13 @@ func (s *JobScheduler) pollJobs(ctx context.Context) {
 		execCtx.TFVars = s.loadVariables(ctx, orgID, workspaceID, "TERRAFORM")
 
 		// Mark step as running
-		_, err = s.pool.Exec(ctx, "UPDATE step SET status = 'running' WHERE id = $1", stepID)
-		if err != nil {
+		if err := s.setStepStatus(ctx, stepID, "running"); err != nil {
 			log.Printf("Error marking step %s as running: %v", stepID, err)
 			continue
 		}
 
 		// Mark job as running
-		_, err = s.pool.Exec(ctx, "UPDATE job SET status = 'running' WHERE id = $1", jobID)
-		if err != nil {
+		if err := s.setJobStatus(ctx, jobID, "running"); err != nil {
 			log.Printf("Error marking job %d as running: %v", jobID, err)
 			continue
 		}
@@ -194,13 +209,40 @@ func (s *JobScheduler) pollJobs(ctx context.Context) {
 		go func(jID int, sID string, ec *ExecutionContext) {
 			if err := s.executor.Execute(ctx, ec); err != nil {
 				log.Printf("Job %d step %s execution failed: %v", jID, sID, err)
-				s.pool.Exec(ctx, "UPDATE step SET status = 'failed' WHERE id = $1", sID)
-				s.pool.Exec(ctx, "UPDATE job SET status = 'failed' WHERE id = $1", jID)
+				if err := s.setStepStatus(ctx, sID, "failed"); err != nil {
+					log.Printf("Error marking step %s as failed: %v", sID, err)
+				}
+				if err := s.setJobStatus(ctx, jID, "failed"); err != nil {
+					log.Printf("Error marking job %d as failed: %v", jID, err)
+				}
 			}
 		}(jobID, stepID, execCtx)
 	}
 }
 
+// setJobStatus writes a job status and emits its event. The scheduler writes
+// statuses directly, so the repository's update hooks do not see them.
+func (s *JobScheduler) setJobStatus(ctx context.Context, jobID int, status string) error {
+	if _, err := s.pool.Exec(ctx, "UPDATE job SET status = $2 WHERE id = $1", jobID, status); err != nil {
+		return err
+	}
+	if s.events != nil {
+		s.events.EmitJobStatus(ctx, jobID, status)
+	}
+	return nil
+}
+
+// setStepStatus writes a step status and emits its event.
+func (s *JobScheduler) setStepStatus(ctx context.Context, stepID, status string) error {
+	if _, err := s.pool.Exec(ctx, "UPDATE step SET status = $2 WHERE id = $1", stepID, status); err != nil {
+		return err
+	}
+	if s.events != nil {
+		s.events.EmitStepStatus(ctx, stepID, status)
+	}
+	return nil
+}
+
 // loadVariables loads workspace variables and global variables for a given category.
 func (s *JobScheduler) loadVariables(ctx context.Context, orgID, workspaceID, category string) map[string]string {
 	vars := make(map[string]string)
@@ -9,11 +9,13 @@ import (
 	"github.com/redis/go-redis/v9"
 
 	"github.com/ilkerispir/terrakubed/internal/api/database"
+	"github.com/ilkerispir/terrakubed/internal/api/events"
 	"github.com/ilkerispir/terrakubed/internal/api/handler"
 	"github.com/ilkerispir/terrakubed/internal/api/middleware"
 	"github.com/ilkerispir/terrakubed/internal/api/registry"
 	"github.com/ilkerispir/terrakubed/internal/api/repository"
 	"github.com/ilkerispir/terrakubed/internal/api/streaming"
+	"github.com/ilkerispir/terrakubed/internal/api/vcstoken"
 	"github.com/ilkerispir/terrakubed/internal/storage"
 )
 
@@ -34,10 +36,11 @@ type Config struct {
 
 // Server is the main API server.
 type Server struct {
-	config  Config
-	db      *database.Pool
-	repo    *repository.GenericRepository
-	handler http.Handler
+	config     Config
+	db         *database.Pool
+	repo       *repository.GenericRepository
+	dispatcher *events.Dispatcher
+	handler    http.Handler
 }
 
 // NewServer creates a new API server.
@@ -54,9 +57,18 @@ func NewServer(config Config) (*Server, error) {
 	repo := repository.NewGenericRepository(db.Pool)
 	registry.RegisterAll(repo)
 
+	// Add Go API columns missing from the Java-managed schema
+	if err := db.Migrate(ctx); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
 	// Validate model columns against actual DB schema
 	repo.ValidateColumns(ctx)
 
+	// Outbound webhooks: emit an event on every job/step status write
+	dispatcher := events.NewDispatcher(db.Pool)
+	repo.OnUpdate(dispatcher.OnUpdate)
+
 	// Create JSON:API handler
 	jsonapiHandler := handler.NewJSONAPIHandler(repo)
 
@@ -72,6 +84,10 @@ func NewServer(config Config) (*Server, error) {
 
 	contextHandler := handler.NewContextHandler(repo, storageService)
 
+	// Jobs blocked by a soft-mandatory policy are only released by an override
+	policyHandler := handler.NewPolicyHandler(db.Pool, storageService, config.OwnerGroup, dispatcher)
+	repo.GuardUpdate(policyHandler.GuardJobStatus)
+
 	// Create Redis client for live log streaming (optional — degraded gracefully)
 	var redisClient *redis.Client
 	if config.RedisAddress != "" {
@@ -112,6 +128,11 @@ func NewServer(config Config) (*Server, error) {
 	mux.HandleFunc("/logs/", logsHandler.AppendLogs)
 	mux.HandleFunc("/tfoutput/v1/", outputHandler.GetOutput)
 	mux.HandleFunc("/context/v1/", contextHandler.GetContext)
+	mux.Handle("/policy/v1/", policyHandler)
+	mux.Handle("/webhook-delivery/v1/", handler.NewWebhookDeliveryHandler(db.Pool, dispatcher, config.OwnerGroup))
+	mux.Handle("/webhook/v1/", handler.NewVcsWebhookHandler(db.Pool, repo))
+	mux.Handle("/vcs-token/v1/", handler.NewVcsTokenHandler(db.Pool, vcstoken.NewService(db.Pool)))
+	mux.Handle("/registry-stats/v1/", handler.NewRegistryStatsHandler(db.Pool, config.OwnerGroup))
 
 	// Token management endpoints (PAT + Team tokens)
 	patHandler := handler.NewPatHandler(db.Pool, config.PatSecret)
@@ -151,16 +172,18 @@ func NewServer(config Config) (*Server, error) {
 	finalHandler = middleware.CORSMiddleware(config.UIURL)(finalHandler)
 
 	return &Server{
-		config:  config,
-		db:      db,
-		repo:    repo,
-		handler: finalHandler,
+		config:     config,
+		db:         db,
+		repo:       repo,
+		dispatcher: dispatcher,
+		handler:    finalHandler,
 	}, nil
 }
 
 // Start starts the HTTP server.
 func (s *Server) Start() error {
 	addr := fmt.Sprintf(":%d", s.config.Port)
+	go s.dispatcher.Start(context.Background())
 	log.Printf("API server starting on %s", addr)
 	return http.ListenAndServe(addr, s.handler)
 }
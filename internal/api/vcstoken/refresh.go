@@ -0,0 +1,125 @@
+package vcstoken
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultEndpoints are the OAuth servers of the cloud providers, used when
+// the connection has no endpoint of its own.
+var defaultEndpoints = map[string]string{
+	"GITHUB":       "https://github.com",
+	"GITLAB":       "https://gitlab.com",
+	"BITBUCKET":    "https://bitbucket.org",
+	"AZURE_DEVOPS": "https://app.vssps.visualstudio.com",
+}
+
+// tokenResponse is the OAuth token response shared by all providers.
+type tokenResponse struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	ExpiresIn        expiresIn `json:"expires_in"`
+	Error            string    `json:"error"`
+	ErrorDescription string    `json:"error_description"`
+}
+
+// expiresIn accepts both numbers and strings; Azure DevOps sends a string.
+type expiresIn int64
+
+func (e *expiresIn) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		return nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expires_in %s", data)
+	}
+	*e = expiresIn(n)
+	return nil
+}
+
+// refresh exchanges the connection's refresh token for new tokens.
+func refresh(ctx context.Context, client *http.Client, c *connection) (*tokenResponse, error) {
+	endpoint := strings.TrimRight(c.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = defaultEndpoints[c.VcsType]
+	}
+
+	var (
+		tokenURL string
+		form     = url.Values{}
+		basic    bool
+	)
+	switch c.VcsType {
+	case "GITHUB":
+		tokenURL = endpoint + "/login/oauth/access_token"
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", c.RefreshToken)
+		form.Set("client_id", c.ClientID)
+		form.Set("client_secret", c.ClientSecret)
+	case "GITLAB":
+		tokenURL = endpoint + "/oauth/token"
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", c.RefreshToken)
+		form.Set("client_id", c.ClientID)
+		form.Set("client_secret", c.ClientSecret)
+		form.Set("redirect_uri", c.Callback)
+	case "BITBUCKET":
+		tokenURL = endpoint + "/site/oauth2/access_token"
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", c.RefreshToken)
+		basic = true
+	case "AZURE_DEVOPS":
+		tokenURL = endpoint + "/oauth2/token"
+		form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+		form.Set("client_assertion", c.ClientSecret)
+		form.Set("grant_type", "refresh_token")
+		form.Set("assertion", c.RefreshToken)
+		form.Set("redirect_uri", c.Callback)
+	default:
+		return nil, fmt.Errorf("token refresh is not supported for %s", c.VcsType)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if basic {
+		req.SetBasicAuth(c.ClientID, c.ClientSecret)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, body)
+	}
+
+	var tok tokenResponse
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	// GitHub reports errors with a 200 status
+	if tok.Error != "" {
+		return nil, fmt.Errorf("%s: %s", tok.Error, tok.ErrorDescription)
+	}
+	if tok.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access_token")
+	}
+	return &tok, nil
+}
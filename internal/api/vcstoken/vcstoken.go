@@ -0,0 +1,137 @@
+// Package vcstoken hands out VCS access tokens, refreshing expired OAuth
+// tokens first.
+package vcstoken
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// refreshSkew refreshes tokens a little before they expire, so a token is
+// still valid by the time a clone or provider call uses it.
+const refreshSkew = 5 * time.Minute
+
+// ErrNotFound is returned for an unknown VCS connection.
+var ErrNotFound = errors.New("vcs connection not found")
+
+// Service returns the access token of a VCS connection. OAuth tokens are
+// refreshed when expired and the new tokens are written back to the vcs row.
+// Refreshes lock the row, so parallel jobs wait for one refresh instead of
+// each spending the refresh token and invalidating the others' tokens.
+type Service struct {
+	pool   *pgxpool.Pool
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewService creates a new Service.
+func NewService(pool *pgxpool.Pool) *Service {
+	return &Service{
+		pool:   pool,
+		client: &http.Client{Timeout: 30 * time.Second},
+		now:    time.Now,
+	}
+}
+
+// connection is the part of a vcs row needed to refresh its token.
+type connection struct {
+	VcsType      string
+	Endpoint     string
+	ClientID     string
+	ClientSecret string
+	Callback     string
+	AccessToken  string
+	RefreshToken string
+	Expiration   *time.Time
+}
+
+// expired reports whether the token should be refreshed. Tokens without an
+// expiration or a refresh token (personal access tokens) never are.
+func (c *connection) expired(now time.Time) bool {
+	return c.RefreshToken != "" && c.Expiration != nil && now.Add(refreshSkew).After(*c.Expiration)
+}
+
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+const selectConnection = `SELECT COALESCE(vcs_type, ''), COALESCE(endpoint, ''), COALESCE(client_id, ''),
+	COALESCE(client_secret, ''), COALESCE(callback, ''), COALESCE(access_token, ''),
+	COALESCE(refresh_token, ''), token_expiration
+	FROM vcs WHERE id::text = $1`
+
+func loadConnection(ctx context.Context, q querier, vcsID string, forUpdate bool) (*connection, error) {
+	query := selectConnection
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+	var c connection
+	err := q.QueryRow(ctx, query, vcsID).Scan(&c.VcsType, &c.Endpoint, &c.ClientID,
+		&c.ClientSecret, &c.Callback, &c.AccessToken, &c.RefreshToken, &c.Expiration)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Token returns a valid access token for the VCS connection.
+func (s *Service) Token(ctx context.Context, vcsID string) (string, error) {
+	c, err := loadConnection(ctx, s.pool, vcsID, false)
+	if err != nil {
+		return "", err
+	}
+	if !c.expired(s.now()) {
+		return c.AccessToken, nil
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	// Re-read under the row lock: another job may have refreshed meanwhile
+	c, err = loadConnection(ctx, tx, vcsID, true)
+	if err != nil {
+		return "", err
+	}
+	if !c.expired(s.now()) {
+		return c.AccessToken, nil
+	}
+
+	tok, err := refresh(ctx, s.client, c)
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh %s token for vcs %s: %w", c.VcsType, vcsID, err)
+	}
+
+	refreshToken := tok.RefreshToken
+	if refreshToken == "" {
+		refreshToken = c.RefreshToken
+	}
+	var expiration *time.Time
+	if tok.ExpiresIn > 0 {
+		t := s.now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+		expiration = &t
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE vcs SET access_token = $2, refresh_token = $3, token_expiration = $4 WHERE id::text = $1`,
+		vcsID, tok.AccessToken, refreshToken, expiration,
+	); err != nil {
+		return "", fmt.Errorf("failed to save refreshed token: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("failed to save refreshed token: %w", err)
+	}
+	log.Printf("Refreshed %s token for vcs %s", c.VcsType, vcsID)
+	return tok.AccessToken, nil
+}
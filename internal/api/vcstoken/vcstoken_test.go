@@ -0,0 +1,113 @@
+package vcstoken
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRefresh(t *testing.T) {
+	tests := []struct {
+		vcsType   string
+		path      string
+		response  string
+		wantForm  map[string]string
+		wantBasic bool
+		wantErr   bool
+		expiresIn int64
+	}{
+		{
+			vcsType:   "GITHUB",
+			path:      "/login/oauth/access_token",
+			response:  `{"access_token":"new","refresh_token":"r2","expires_in":28800}`,
+			wantForm:  map[string]string{"grant_type": "refresh_token", "refresh_token": "r1", "client_id": "id", "client_secret": "secret"},
+			expiresIn: 28800,
+		},
+		{
+			vcsType:  "GITHUB",
+			path:     "/login/oauth/access_token",
+			response: `{"error":"bad_refresh_token","error_description":"The refresh token passed is incorrect or expired."}`,
+			wantErr:  true,
+		},
+		{
+			vcsType:   "GITLAB",
+			path:      "/oauth/token",
+			response:  `{"access_token":"new","refresh_token":"r2","expires_in":7200}`,
+			wantForm:  map[string]string{"grant_type": "refresh_token", "refresh_token": "r1", "redirect_uri": "https://cb"},
+			expiresIn: 7200,
+		},
+		{
+			vcsType:   "BITBUCKET",
+			path:      "/site/oauth2/access_token",
+			response:  `{"access_token":"new","refresh_token":"r1","expires_in":7200}`,
+			wantForm:  map[string]string{"grant_type": "refresh_token", "refresh_token": "r1"},
+			wantBasic: true,
+			expiresIn: 7200,
+		},
+		{
+			vcsType:   "AZURE_DEVOPS",
+			path:      "/oauth2/token",
+			response:  `{"access_token":"new","refresh_token":"r2","expires_in":"3599"}`,
+			wantForm:  map[string]string{"grant_type": "refresh_token", "assertion": "r1", "client_assertion": "secret"},
+			expiresIn: 3599,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vcsType, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.path {
+					t.Errorf("path = %s, want %s", r.URL.Path, tt.path)
+				}
+				r.ParseForm()
+				for k, v := range tt.wantForm {
+					if got := r.PostForm.Get(k); got != v {
+						t.Errorf("form %s = %q, want %q", k, got, v)
+					}
+				}
+				if user, pass, ok := r.BasicAuth(); ok != tt.wantBasic || (ok && (user != "id" || pass != "secret")) {
+					t.Errorf("basic auth = %v %s:%s", ok, user, pass)
+				}
+				w.Write([]byte(tt.response))
+			}))
+			defer srv.Close()
+
+			c := &connection{VcsType: tt.vcsType, Endpoint: srv.URL, ClientID: "id", ClientSecret: "secret",
+				Callback: "https://cb", RefreshToken: "r1"}
+			tok, err := refresh(context.Background(), srv.Client(), c)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tok.AccessToken != "new" || int64(tok.ExpiresIn) != tt.expiresIn {
+				t.Errorf("got %+v", tok)
+			}
+		})
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Now()
+	soon, later := now.Add(time.Minute), now.Add(time.Hour)
+	tests := []struct {
+		name string
+		c    connection
+		want bool
+	}{
+		{"personal access token", connection{AccessToken: "t"}, false},
+		{"no expiration", connection{RefreshToken: "r"}, false},
+		{"valid", connection{RefreshToken: "r", Expiration: &later}, false},
+		{"expires within skew", connection{RefreshToken: "r", Expiration: &soon}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.c.expired(now); got != tt.want {
+			t.Errorf("%s: expired = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
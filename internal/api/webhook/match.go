@@ -0,0 +1,195 @@
+package webhook
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Workspace is the part of a workspace needed to match events.
+type Workspace struct {
+	ID              string
+	Source          string
+	Branch          string
+	Folder          string
+	DefaultTemplate string
+	// AllowForkPullRequests runs speculative plans for pull requests from
+	// forks, whose code anyone can write.
+	AllowForkPullRequests bool
+	// Rules are the workspace's webhook_event rows.
+	Rules []Rule
+}
+
+// Rule is a webhook_event row: it overrides which pushes or tags trigger the
+// workspace and the template they run. Branch and Path are comma-separated
+// regular expressions; Branch must match the whole branch or tag name and Path
+// must match at least one changed file.
+type Rule struct {
+	Event      string // PUSH or TAG
+	Branch     string
+	Path       string
+	TemplateID string
+	Priority   int
+}
+
+// Match reports whether ev concerns ws and returns the template to run.
+//
+// Without rules a workspace is triggered by pushes to its branch that touch its
+// folder. With rules the first matching rule, lowest Priority first, wins; tags
+// only ever trigger through a TAG rule. Pull requests match workspaces on their
+// base branch and ignore rules: they always run a speculative plan. Pull
+// requests from forks only match workspaces that allow them.
+func Match(ws Workspace, ev *Event) (string, bool) {
+	if !SameRepository(ws.Source, ev.RepoURLs) {
+		return "", false
+	}
+	if ev.PullRequest != nil && ev.PullRequest.Fork && !ws.AllowForkPullRequests {
+		return "", false
+	}
+	branch := ws.Branch
+	if branch == "" {
+		branch = "main"
+	}
+
+	if len(ws.Rules) == 0 || ev.Kind == KindPullRequest {
+		if ev.Kind == KindTag || ev.Branch != branch || !folderMatches(ws.Folder, ev.ChangedFiles) {
+			return "", false
+		}
+		return ws.DefaultTemplate, true
+	}
+
+	rules := append([]Rule(nil), ws.Rules...)
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Priority < rules[j].Priority })
+	for _, r := range rules {
+		var name string
+		switch {
+		case r.Event == "PUSH" && ev.Kind == KindPush:
+			name = ev.Branch
+			if r.Branch == "" && name != branch {
+				continue
+			}
+		case r.Event == "TAG" && ev.Kind == KindTag:
+			name = ev.Tag
+		default:
+			continue
+		}
+		if r.Branch != "" && !anyRegexp(r.Branch, func(re *regexp.Regexp) bool { return re.MatchString(name) }, true) {
+			continue
+		}
+		if r.Path == "" {
+			if !folderMatches(ws.Folder, ev.ChangedFiles) {
+				continue
+			}
+		} else if ev.ChangedFiles != nil && !anyRegexp(r.Path, func(re *regexp.Regexp) bool {
+			for _, f := range ev.ChangedFiles {
+				if re.MatchString(f) {
+					return true
+				}
+			}
+			return false
+		}, false) {
+			continue
+		}
+		if r.TemplateID != "" {
+			return r.TemplateID, true
+		}
+		return ws.DefaultTemplate, true
+	}
+	return "", false
+}
+
+// anyRegexp compiles each comma-separated pattern and reports whether match
+// accepts any of them. Invalid patterns are skipped.
+func anyRegexp(patterns string, match func(*regexp.Regexp) bool, anchored bool) bool {
+	for _, p := range strings.Split(patterns, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		if anchored {
+			p = "^(?:" + p + ")$"
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			continue
+		}
+		if match(re) {
+			return true
+		}
+	}
+	return false
+}
+
+// folderMatches reports whether any changed file is inside one of the
+// workspace's comma-separated folders. Unknown changes match everything.
+func folderMatches(folders string, files []string) bool {
+	if files == nil {
+		return true
+	}
+	for _, folder := range strings.Split(folders, ",") {
+		folder = strings.Trim(strings.TrimSpace(folder), "/")
+		if folder == "" {
+			return true
+		}
+		for _, f := range files {
+			if strings.HasPrefix(strings.TrimPrefix(f, "/"), folder+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SameRepository reports whether source refers to any of the repository URLs.
+func SameRepository(source string, urls []string) bool {
+	want := NormalizeRepoURL(source)
+	if want == "" {
+		return false
+	}
+	for _, u := range urls {
+		if NormalizeRepoURL(u) == want {
+			return true
+		}
+	}
+	return false
+}
+
+var schemeRe = regexp.MustCompile(`^[a-z+]+://`)
+
+// NormalizeRepoURL reduces HTTPS, SSH and scp-style repository URLs to
+// "host/path" so that different spellings of one repository compare equal:
+// https://github.com/acme/net.git, git@github.com:acme/net and
+// ssh://git@github.com:22/acme/net all become "github.com/acme/net".
+func NormalizeRepoURL(u string) string {
+	u = strings.ToLower(strings.TrimSpace(u))
+	if u == "" {
+		return ""
+	}
+	hasScheme := schemeRe.MatchString(u)
+	u = schemeRe.ReplaceAllString(u, "")
+	if at := strings.Index(u, "@"); at >= 0 && at < strings.Index(u+"/", "/") {
+		u = u[at+1:]
+	}
+	if host, rest, ok := strings.Cut(u, ":"); ok {
+		port, path, _ := strings.Cut(rest, "/")
+		if hasScheme && isDigits(port) {
+			u = host + "/" + path
+		} else if !hasScheme {
+			// scp-style git@host:owner/repo
+			u = host + "/" + rest
+		}
+	}
+	u = strings.TrimSuffix(strings.TrimRight(u, "/"), ".git")
+	return u
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,346 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type pushCommit struct {
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
+func commitFiles(commits []pushCommit) []string {
+	var lists [][]string
+	for _, c := range commits {
+		lists = append(lists, c.Added, c.Modified, c.Removed)
+	}
+	return collectFiles(lists...)
+}
+
+// ── GitHub ─────────────────────────────────────────
+
+func parseGitHub(eventType string, body []byte) (*Event, error) {
+	var p struct {
+		Ref        string       `json:"ref"`
+		After      string       `json:"after"`
+		Deleted    bool         `json:"deleted"`
+		Commits    []pushCommit `json:"commits"`
+		Action     string       `json:"action"`
+		Number     int          `json:"number"`
+		Repository struct {
+			HTMLURL  string `json:"html_url"`
+			CloneURL string `json:"clone_url"`
+			SSHURL   string `json:"ssh_url"`
+		} `json:"repository"`
+		PullRequest struct {
+			HTMLURL string `json:"html_url"`
+			Head    struct {
+				Ref  string `json:"ref"`
+				SHA  string `json:"sha"`
+				Repo struct {
+					FullName string `json:"full_name"`
+				} `json:"repo"` // null when the fork was deleted
+			} `json:"head"`
+			Base struct {
+				Ref  string `json:"ref"`
+				Repo struct {
+					FullName string `json:"full_name"`
+				} `json:"repo"`
+			} `json:"base"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("invalid GitHub payload: %w", err)
+	}
+	ev := &Event{
+		Provider: "GITHUB",
+		RepoURLs: []string{p.Repository.HTMLURL, p.Repository.CloneURL, p.Repository.SSHURL},
+	}
+
+	switch eventType {
+	case "push":
+		if p.Deleted || zeroCommit(p.After) {
+			return nil, ErrIgnored
+		}
+		kind, name := splitRef(p.Ref)
+		if err := ev.setRef(kind, name); err != nil {
+			return nil, err
+		}
+		ev.CommitID = p.After
+		ev.ChangedFiles = commitFiles(p.Commits)
+	case "pull_request":
+		if p.Action != "opened" && p.Action != "synchronize" && p.Action != "reopened" {
+			return nil, ErrIgnored
+		}
+		ev.Kind = KindPullRequest
+		ev.Branch = p.PullRequest.Base.Ref
+		ev.CommitID = p.PullRequest.Head.SHA
+		ev.PullRequest = &PullRequest{
+			Number:     p.Number,
+			Action:     p.Action,
+			HeadBranch: p.PullRequest.Head.Ref,
+			HeadCommit: p.PullRequest.Head.SHA,
+			URL:        p.PullRequest.HTMLURL,
+			Fork:       isFork(p.PullRequest.Head.Repo.FullName, p.PullRequest.Base.Repo.FullName),
+		}
+	default:
+		return nil, ErrIgnored
+	}
+	return ev, nil
+}
+
+// ── GitLab ─────────────────────────────────────────
+
+func parseGitLab(eventType string, body []byte) (*Event, error) {
+	var p struct {
+		Ref     string       `json:"ref"`
+		After   string       `json:"after"`
+		Commits []pushCommit `json:"commits"`
+		Project struct {
+			WebURL     string `json:"web_url"`
+			GitHTTPURL string `json:"git_http_url"`
+			GitSSHURL  string `json:"git_ssh_url"`
+		} `json:"project"`
+		ObjectAttributes struct {
+			IID             int    `json:"iid"`
+			Action          string `json:"action"`
+			URL             string `json:"url"`
+			SourceBranch    string `json:"source_branch"`
+			TargetBranch    string `json:"target_branch"`
+			SourceProjectID int    `json:"source_project_id"`
+			TargetProjectID int    `json:"target_project_id"`
+			LastCommit      struct {
+				ID string `json:"id"`
+			} `json:"last_commit"`
+		} `json:"object_attributes"`
+	}
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("invalid GitLab payload: %w", err)
+	}
+	ev := &Event{
+		Provider: "GITLAB",
+		RepoURLs: []string{p.Project.WebURL, p.Project.GitHTTPURL, p.Project.GitSSHURL},
+	}
+
+	switch eventType {
+	case "Push Hook", "Tag Push Hook":
+		if zeroCommit(p.After) {
+			return nil, ErrIgnored
+		}
+		kind, name := splitRef(p.Ref)
+		if err := ev.setRef(kind, name); err != nil {
+			return nil, err
+		}
+		ev.CommitID = p.After
+		ev.ChangedFiles = commitFiles(p.Commits)
+	case "Merge Request Hook":
+		a := p.ObjectAttributes
+		if a.Action != "open" && a.Action != "update" && a.Action != "reopen" {
+			return nil, ErrIgnored
+		}
+		ev.Kind = KindPullRequest
+		ev.Branch = a.TargetBranch
+		ev.CommitID = a.LastCommit.ID
+		ev.PullRequest = &PullRequest{
+			Number:     a.IID,
+			Action:     a.Action,
+			HeadBranch: a.SourceBranch,
+			HeadCommit: a.LastCommit.ID,
+			URL:        a.URL,
+			Fork:       a.SourceProjectID == 0 || a.SourceProjectID != a.TargetProjectID,
+		}
+	default:
+		return nil, ErrIgnored
+	}
+	return ev, nil
+}
+
+// ── Bitbucket Cloud ────────────────────────────────
+
+func parseBitbucket(eventKey string, body []byte) (*Event, error) {
+	type repository struct {
+		FullName string `json:"full_name"`
+	}
+	type ref struct {
+		Type   string `json:"type"`
+		Name   string `json:"name"`
+		Target struct {
+			Hash string `json:"hash"`
+		} `json:"target"`
+	}
+	var p struct {
+		Repository struct {
+			FullName string `json:"full_name"`
+			Links    struct {
+				HTML struct {
+					Href string `json:"href"`
+				} `json:"html"`
+			} `json:"links"`
+		} `json:"repository"`
+		Push struct {
+			Changes []struct {
+				New *ref `json:"new"`
+			} `json:"changes"`
+		} `json:"push"`
+		PullRequest struct {
+			ID     int `json:"id"`
+			Source struct {
+				Branch struct {
+					Name string `json:"name"`
+				} `json:"branch"`
+				Commit struct {
+					Hash string `json:"hash"`
+				} `json:"commit"`
+				Repository repository `json:"repository"`
+			} `json:"source"`
+			Destination struct {
+				Branch struct {
+					Name string `json:"name"`
+				} `json:"branch"`
+				Repository repository `json:"repository"`
+			} `json:"destination"`
+			Links struct {
+				HTML struct {
+					Href string `json:"href"`
+				} `json:"html"`
+			} `json:"links"`
+		} `json:"pullrequest"`
+	}
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("invalid Bitbucket payload: %w", err)
+	}
+	ev := &Event{
+		Provider: "BITBUCKET",
+		RepoURLs: []string{p.Repository.Links.HTML.Href, "https://bitbucket.org/" + p.Repository.FullName},
+	}
+
+	switch eventKey {
+	case "repo:push":
+		// The last change with a new ref wins; deletions have no "new".
+		var last *ref
+		for _, c := range p.Push.Changes {
+			if c.New != nil {
+				last = c.New
+			}
+		}
+		if last == nil {
+			return nil, ErrIgnored
+		}
+		kind := KindPush
+		if last.Type == "tag" {
+			kind = KindTag
+		}
+		if err := ev.setRef(kind, last.Name); err != nil {
+			return nil, err
+		}
+		ev.CommitID = last.Target.Hash
+	case "pullrequest:created", "pullrequest:updated":
+		pr := p.PullRequest
+		ev.Kind = KindPullRequest
+		ev.Branch = pr.Destination.Branch.Name
+		ev.CommitID = pr.Source.Commit.Hash
+		ev.PullRequest = &PullRequest{
+			Number:     pr.ID,
+			Action:     strings.TrimPrefix(eventKey, "pullrequest:"),
+			HeadBranch: pr.Source.Branch.Name,
+			HeadCommit: pr.Source.Commit.Hash,
+			URL:        pr.Links.HTML.Href,
+			Fork:       isFork(pr.Source.Repository.FullName, pr.Destination.Repository.FullName),
+		}
+	default:
+		return nil, ErrIgnored
+	}
+	return ev, nil
+}
+
+// ── Azure DevOps ───────────────────────────────────
+
+func parseAzureDevOps(body []byte) (*Event, error) {
+	var p struct {
+		EventType string `json:"eventType"`
+		Resource  struct {
+			RefUpdates []struct {
+				Name        string `json:"name"`
+				NewObjectID string `json:"newObjectId"`
+			} `json:"refUpdates"`
+			Repository struct {
+				RemoteURL string `json:"remoteUrl"`
+				SSHURL    string `json:"sshUrl"`
+				WebURL    string `json:"webUrl"`
+			} `json:"repository"`
+			PullRequestID         int    `json:"pullRequestId"`
+			Status                string `json:"status"`
+			SourceRefName         string `json:"sourceRefName"`
+			TargetRefName         string `json:"targetRefName"`
+			URL                   string `json:"url"`
+			LastMergeSourceCommit struct {
+				CommitID string `json:"commitId"`
+			} `json:"lastMergeSourceCommit"`
+			// Set when the source branch is in a fork
+			ForkSource *struct {
+				Name string `json:"name"`
+			} `json:"forkSource"`
+		} `json:"resource"`
+	}
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("invalid Azure DevOps payload: %w", err)
+	}
+	res := p.Resource
+	ev := &Event{
+		Provider: "AZURE_DEVOPS",
+		RepoURLs: []string{res.Repository.RemoteURL, res.Repository.SSHURL, res.Repository.WebURL},
+	}
+
+	switch p.EventType {
+	case "git.push":
+		if len(res.RefUpdates) == 0 || zeroCommit(res.RefUpdates[0].NewObjectID) {
+			return nil, ErrIgnored
+		}
+		kind, name := splitRef(res.RefUpdates[0].Name)
+		if err := ev.setRef(kind, name); err != nil {
+			return nil, err
+		}
+		ev.CommitID = res.RefUpdates[0].NewObjectID
+	case "git.pullrequest.created", "git.pullrequest.updated":
+		if res.Status != "" && res.Status != "active" {
+			return nil, ErrIgnored
+		}
+		_, head := splitRef(res.SourceRefName)
+		_, base := splitRef(res.TargetRefName)
+		ev.Kind = KindPullRequest
+		ev.Branch = base
+		ev.CommitID = res.LastMergeSourceCommit.CommitID
+		ev.PullRequest = &PullRequest{
+			Number:     res.PullRequestID,
+			Action:     strings.TrimPrefix(p.EventType, "git.pullrequest."),
+			HeadBranch: head,
+			HeadCommit: res.LastMergeSourceCommit.CommitID,
+			URL:        res.URL,
+			Fork:       res.ForkSource != nil,
+		}
+	default:
+		return nil, ErrIgnored
+	}
+	return ev, nil
+}
+
+// isFork reports whether a pull request's head repository differs from its
+// base repository. An unknown head repository counts as a fork.
+func isFork(head, base string) bool {
+	return head == "" || !strings.EqualFold(head, base)
+}
+
+func (ev *Event) setRef(kind, name string) error {
+	switch kind {
+	case KindPush:
+		ev.Kind, ev.Branch = KindPush, name
+	case KindTag:
+		ev.Kind, ev.Tag = KindTag, name
+	default:
+		return ErrIgnored
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// Package webhook verifies and normalizes inbound push, tag and pull request
+// webhooks from GitHub, GitLab, Bitbucket and Azure DevOps, and matches them
+// against workspaces.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Event kinds.
+const (
+	KindPush        = "push"
+	KindTag         = "tag"
+	KindPullRequest = "pullRequest"
+)
+
+var (
+	// ErrSignature is returned when the request is not signed with the secret.
+	ErrSignature = errors.New("invalid webhook signature")
+	// ErrIgnored is returned for well-formed events that never trigger runs
+	// (pings, branch deletions, closed pull requests, …).
+	ErrIgnored = errors.New("event ignored")
+)
+
+// Event is a provider-independent push, tag or pull request event.
+type Event struct {
+	Provider string
+	Kind     string
+	// RepoURLs are every URL the provider gives for the repository (web, HTTPS
+	// clone, SSH clone); a workspace matches if its source equals any of them.
+	RepoURLs []string
+	// Branch is the pushed branch, or the base branch of a pull request.
+	Branch   string
+	Tag      string
+	CommitID string
+	// ChangedFiles is nil when the provider does not include them (Bitbucket,
+	// Azure DevOps): path filters then match everything.
+	ChangedFiles []string
+	PullRequest  *PullRequest
+}
+
+// PullRequest carries the pull/merge request fields of a KindPullRequest event.
+type PullRequest struct {
+	Number     int
+	Action     string
+	HeadBranch string
+	HeadCommit string
+	URL        string
+	// Fork is set when the head branch is in another repository than the
+	// base: its code is not trusted and HeadBranch does not exist in the
+	// workspace repository.
+	Fork bool
+}
+
+// Parse verifies the request signature for the VCS type and decodes the body.
+// vcsType is the model.VcsType value of the connection (GITHUB, GITLAB, …).
+func Parse(vcsType string, header http.Header, body []byte, secret string) (*Event, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("%w: no webhook secret configured", ErrSignature)
+	}
+	switch vcsType {
+	case "GITHUB":
+		if !validHMAC(secret, body, strings.TrimPrefix(header.Get("X-Hub-Signature-256"), "sha256=")) {
+			return nil, ErrSignature
+		}
+		return parseGitHub(header.Get("X-GitHub-Event"), body)
+	case "GITLAB":
+		if !hmac.Equal([]byte(header.Get("X-Gitlab-Token")), []byte(secret)) {
+			return nil, ErrSignature
+		}
+		return parseGitLab(header.Get("X-Gitlab-Event"), body)
+	case "BITBUCKET":
+		if !validHMAC(secret, body, strings.TrimPrefix(header.Get("X-Hub-Signature"), "sha256=")) {
+			return nil, ErrSignature
+		}
+		return parseBitbucket(header.Get("X-Event-Key"), body)
+	case "AZURE_DEVOPS", "AZURE_SP_MI":
+		// Service hooks have no signature; the secret is the basic auth password.
+		r := http.Request{Header: header}
+		_, password, ok := r.BasicAuth()
+		if !ok || !hmac.Equal([]byte(password), []byte(secret)) {
+			return nil, ErrSignature
+		}
+		return parseAzureDevOps(body)
+	default:
+		return nil, fmt.Errorf("webhooks are not supported for VCS type %q", vcsType)
+	}
+}
+
+func validHMAC(secret string, body []byte, signatureHex string) bool {
+	got, err := hex.DecodeString(signatureHex)
+	if err != nil || len(got) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// splitRef turns "refs/heads/main" into (KindPush, "main") and
+// "refs/tags/v1" into (KindTag, "v1").
+func splitRef(ref string) (kind, name string) {
+	switch {
+	case strings.HasPrefix(ref, "refs/heads/"):
+		return KindPush, strings.TrimPrefix(ref, "refs/heads/")
+	case strings.HasPrefix(ref, "refs/tags/"):
+		return KindTag, strings.TrimPrefix(ref, "refs/tags/")
+	}
+	return "", ref
+}
+
+// zeroCommit is the "after" SHA of a deleted branch or tag.
+func zeroCommit(sha string) bool {
+	return strings.Trim(sha, "0") == ""
+}
+
+// collectFiles flattens the added/modified/removed lists of push commits.
+func collectFiles(lists ...[]string) []string {
+	seen := map[string]bool{}
+	files := []string{}
+	for _, l := range lists {
+		for _, f := range l {
+			if !seen[f] {
+				seen[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+	return files
+}
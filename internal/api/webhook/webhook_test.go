@@ -0,0 +1,255 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestParse(t *testing.T) {
+	const secret = "s3cret"
+	githubPush := `{"ref":"refs/heads/main","after":"abc123","repository":{"html_url":"https://github.com/acme/net","clone_url":"https://github.com/acme/net.git","ssh_url":"git@github.com:acme/net.git"},"commits":[{"added":["prod/main.tf"],"modified":["README.md"],"removed":[]}]}`
+	githubTagDelete := `{"ref":"refs/tags/v1","after":"0000000000000000000000000000000000000000","deleted":true,"repository":{}}`
+	githubPR := `{"action":"synchronize","number":7,"repository":{"html_url":"https://github.com/acme/net"},"pull_request":{"html_url":"https://github.com/acme/net/pull/7","head":{"ref":"feature","sha":"def456","repo":{"full_name":"acme/net"}},"base":{"ref":"main","repo":{"full_name":"acme/net"}}}}`
+	gitlabTag := `{"ref":"refs/tags/v1.2.0","after":"abc123","project":{"web_url":"https://gitlab.com/acme/net"},"commits":[]}`
+	bitbucketPush := `{"repository":{"full_name":"acme/net","links":{"html":{"href":"https://bitbucket.org/acme/net"}}},"push":{"changes":[{"new":{"type":"branch","name":"main","target":{"hash":"abc123"}}}]}}`
+	azurePush := `{"eventType":"git.push","resource":{"refUpdates":[{"name":"refs/heads/main","newObjectId":"abc123"}],"repository":{"remoteUrl":"https://dev.azure.com/acme/infra/_git/net"}}}`
+
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("terrakube:"+secret))
+
+	tests := []struct {
+		name    string
+		vcsType string
+		header  http.Header
+		body    string
+		want    *Event
+		wantErr error
+	}{
+		{
+			name:    "github push",
+			vcsType: "GITHUB",
+			header:  http.Header{"X-Github-Event": {"push"}, "X-Hub-Signature-256": {sign(secret, githubPush)}},
+			body:    githubPush,
+			want: &Event{Provider: "GITHUB", Kind: KindPush, Branch: "main", CommitID: "abc123",
+				RepoURLs:     []string{"https://github.com/acme/net", "https://github.com/acme/net.git", "git@github.com:acme/net.git"},
+				ChangedFiles: []string{"prod/main.tf", "README.md"}},
+		},
+		{
+			name:    "github bad signature",
+			vcsType: "GITHUB",
+			header:  http.Header{"X-Github-Event": {"push"}, "X-Hub-Signature-256": {sign("other", githubPush)}},
+			body:    githubPush,
+			wantErr: ErrSignature,
+		},
+		{
+			name:    "github tag deletion ignored",
+			vcsType: "GITHUB",
+			header:  http.Header{"X-Github-Event": {"push"}, "X-Hub-Signature-256": {sign(secret, githubTagDelete)}},
+			body:    githubTagDelete,
+			wantErr: ErrIgnored,
+		},
+		{
+			name:    "github pull request",
+			vcsType: "GITHUB",
+			header:  http.Header{"X-Github-Event": {"pull_request"}, "X-Hub-Signature-256": {sign(secret, githubPR)}},
+			body:    githubPR,
+			want: &Event{Provider: "GITHUB", Kind: KindPullRequest, Branch: "main", CommitID: "def456",
+				RepoURLs: []string{"https://github.com/acme/net", "", ""},
+				PullRequest: &PullRequest{Number: 7, Action: "synchronize", HeadBranch: "feature", HeadCommit: "def456",
+					URL: "https://github.com/acme/net/pull/7"}},
+		},
+		{
+			name:    "gitlab tag",
+			vcsType: "GITLAB",
+			header:  http.Header{"X-Gitlab-Event": {"Tag Push Hook"}, "X-Gitlab-Token": {secret}},
+			body:    gitlabTag,
+			want: &Event{Provider: "GITLAB", Kind: KindTag, Tag: "v1.2.0", CommitID: "abc123",
+				RepoURLs: []string{"https://gitlab.com/acme/net", "", ""}, ChangedFiles: []string{}},
+		},
+		{
+			name:    "gitlab wrong token",
+			vcsType: "GITLAB",
+			header:  http.Header{"X-Gitlab-Event": {"Push Hook"}, "X-Gitlab-Token": {"nope"}},
+			body:    gitlabTag,
+			wantErr: ErrSignature,
+		},
+		{
+			name:    "bitbucket push",
+			vcsType: "BITBUCKET",
+			header:  http.Header{"X-Event-Key": {"repo:push"}, "X-Hub-Signature": {sign(secret, bitbucketPush)}},
+			body:    bitbucketPush,
+			want: &Event{Provider: "BITBUCKET", Kind: KindPush, Branch: "main", CommitID: "abc123",
+				RepoURLs: []string{"https://bitbucket.org/acme/net", "https://bitbucket.org/acme/net"}},
+		},
+		{
+			name:    "azure devops push",
+			vcsType: "AZURE_DEVOPS",
+			header:  http.Header{"Authorization": {basic}},
+			body:    azurePush,
+			want: &Event{Provider: "AZURE_DEVOPS", Kind: KindPush, Branch: "main", CommitID: "abc123",
+				RepoURLs: []string{"https://dev.azure.com/acme/infra/_git/net", "", ""}},
+		},
+		{
+			name:    "azure devops without credentials",
+			vcsType: "AZURE_DEVOPS",
+			header:  http.Header{},
+			body:    azurePush,
+			wantErr: ErrSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.vcsType, tt.header, []byte(tt.body), secret)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFork(t *testing.T) {
+	const secret = "s3cret"
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("terrakube:"+secret))
+	github := func(head string) string {
+		return `{"action":"opened","number":7,"pull_request":{"head":{"ref":"main","sha":"def456","repo":` + head + `},"base":{"ref":"main","repo":{"full_name":"acme/net"}}}}`
+	}
+	gitlab := func(source int) string {
+		return fmt.Sprintf(`{"object_attributes":{"iid":7,"action":"open","source_branch":"main","target_branch":"main","source_project_id":%d,"target_project_id":1,"last_commit":{"id":"def456"}}}`, source)
+	}
+	bitbucket := func(source string) string {
+		return `{"pullrequest":{"id":7,"source":{"branch":{"name":"main"},"commit":{"hash":"def456"},"repository":{"full_name":"` + source + `"}},"destination":{"branch":{"name":"main"},"repository":{"full_name":"acme/net"}}}}`
+	}
+	azure := func(fork string) string {
+		return `{"eventType":"git.pullrequest.created","resource":{"pullRequestId":7,"status":"active","sourceRefName":"refs/heads/main","targetRefName":"refs/heads/main","lastMergeSourceCommit":{"commitId":"def456"}` + fork + `}}`
+	}
+
+	tests := []struct {
+		name     string
+		vcsType  string
+		header   func(body string) http.Header
+		body     string
+		wantFork bool
+	}{
+		{"github same repository", "GITHUB", githubHeader(secret), github(`{"full_name":"acme/net"}`), false},
+		{"github fork", "GITHUB", githubHeader(secret), github(`{"full_name":"mallory/net"}`), true},
+		{"github deleted fork", "GITHUB", githubHeader(secret), github(`null`), true},
+		{"gitlab same project", "GITLAB", gitlabHeader(secret), gitlab(1), false},
+		{"gitlab fork", "GITLAB", gitlabHeader(secret), gitlab(2), true},
+		{"bitbucket same repository", "BITBUCKET", bitbucketHeader(secret), bitbucket("acme/net"), false},
+		{"bitbucket fork", "BITBUCKET", bitbucketHeader(secret), bitbucket("mallory/net"), true},
+		{"azure same repository", "AZURE_DEVOPS", func(string) http.Header { return http.Header{"Authorization": {basic}} }, azure(""), false},
+		{"azure fork", "AZURE_DEVOPS", func(string) http.Header { return http.Header{"Authorization": {basic}} }, azure(`,"forkSource":{"name":"refs/heads/main"}`), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := Parse(tt.vcsType, tt.header(tt.body), []byte(tt.body), secret)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if ev.PullRequest == nil || ev.PullRequest.Fork != tt.wantFork {
+				t.Errorf("PullRequest = %+v, want Fork %t", ev.PullRequest, tt.wantFork)
+			}
+		})
+	}
+}
+
+func githubHeader(secret string) func(body string) http.Header {
+	return func(body string) http.Header {
+		return http.Header{"X-Github-Event": {"pull_request"}, "X-Hub-Signature-256": {sign(secret, body)}}
+	}
+}
+
+func gitlabHeader(secret string) func(body string) http.Header {
+	return func(string) http.Header {
+		return http.Header{"X-Gitlab-Event": {"Merge Request Hook"}, "X-Gitlab-Token": {secret}}
+	}
+}
+
+func bitbucketHeader(secret string) func(body string) http.Header {
+	return func(body string) http.Header {
+		return http.Header{"X-Event-Key": {"pullrequest:created"}, "X-Hub-Signature": {sign(secret, body)}}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	repo := []string{"https://github.com/acme/net"}
+	push := func(branch string, files ...string) *Event {
+		return &Event{Kind: KindPush, Branch: branch, RepoURLs: repo, ChangedFiles: files}
+	}
+	ws := Workspace{ID: "ws", Source: "git@github.com:acme/net.git", Branch: "main", Folder: "/prod", DefaultTemplate: "default"}
+	forkPR := &Event{Kind: KindPullRequest, Branch: "main", RepoURLs: repo, PullRequest: &PullRequest{Fork: true}}
+	allowForks := ws
+	allowForks.AllowForkPullRequests = true
+	withRules := ws
+	withRules.Rules = []Rule{
+		{Event: "TAG", Branch: `v\d+\.\d+\.\d+`, TemplateID: "release", Priority: 2},
+		{Event: "PUSH", Branch: "main,release/.*", Path: `\.tf$`, TemplateID: "plan", Priority: 1},
+	}
+
+	tests := []struct {
+		name     string
+		ws       Workspace
+		ev       *Event
+		wantTmpl string
+		wantOK   bool
+	}{
+		{"push to branch and folder", ws, push("main", "prod/main.tf"), "default", true},
+		{"push outside folder", ws, push("main", "dev/main.tf"), "", false},
+		{"push to other branch", ws, push("feature", "prod/main.tf"), "", false},
+		{"unknown changed files", ws, push("main"), "default", true},
+		{"other repository", ws, &Event{Kind: KindPush, Branch: "main", RepoURLs: []string{"https://github.com/acme/other"}}, "", false},
+		{"tag without rules", ws, &Event{Kind: KindTag, Tag: "v1.0.0", RepoURLs: repo}, "", false},
+		{"rule branch regexp", withRules, push("release/2024", "modules/vpc.tf"), "plan", true},
+		{"rule path mismatch", withRules, push("main", "docs/README.md"), "", false},
+		{"tag rule", withRules, &Event{Kind: KindTag, Tag: "v1.2.3", RepoURLs: repo}, "release", true},
+		{"tag rule mismatch", withRules, &Event{Kind: KindTag, Tag: "nightly", RepoURLs: repo}, "", false},
+		{"pull request ignores rules", withRules, &Event{Kind: KindPullRequest, Branch: "main", RepoURLs: repo}, "default", true},
+		{"pull request to other base", ws, &Event{Kind: KindPullRequest, Branch: "develop", RepoURLs: repo}, "", false},
+		{"pull request from fork", ws, forkPR, "", false},
+		{"pull request from allowed fork", allowForks, forkPR, "default", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, ok := Match(tt.ws, tt.ev)
+			if tmpl != tt.wantTmpl || ok != tt.wantOK {
+				t.Errorf("Match() = (%q, %t), want (%q, %t)", tmpl, ok, tt.wantTmpl, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNormalizeRepoURL(t *testing.T) {
+	for _, u := range []string{
+		"https://github.com/acme/net.git",
+		"https://github.com/Acme/Net/",
+		"git@github.com:acme/net.git",
+		"ssh://git@github.com:22/acme/net",
+		"https://token@github.com/acme/net",
+	} {
+		if got := NormalizeRepoURL(u); got != "github.com/acme/net" {
+			t.Errorf("NormalizeRepoURL(%q) = %q", u, got)
+		}
+	}
+}
@@ -115,14 +115,56 @@ func (c *Client) ExecuteQuery(query string, variables map[string]interface{}) ([
 	return graphQLResp.Data, nil
 }
 
+// GetVcsToken returns the access token of a VCS connection. The Go API's
+// /vcs-token/v1 endpoint refreshes expired OAuth tokens first; APIs without it
+// fall back to the access token stored on the vcs resource.
 func (c *Client) GetVcsToken(orgId, vcsId string) (string, error) {
-	// REST API: GET /api/v1/organization/{orgId}/vcs/{vcsId}
 	base := strings.TrimSuffix(c.BaseURL, "/graphql/api/v1")
-	restURL := fmt.Sprintf("%s/api/v1/organization/%s/vcs/%s", base, orgId, vcsId)
 
-	req, err := http.NewRequest("GET", restURL, nil)
+	var token struct {
+		AccessToken string `json:"accessToken"`
+	}
+	status, err := c.getJSON(fmt.Sprintf("%s/vcs-token/v1/organization/%s/vcs/%s", base, orgId, vcsId), &token)
+	if err == nil {
+		return token.AccessToken, nil
+	}
+	if status != http.StatusNotFound {
+		return "", err
+	}
+
+	// REST API: GET /api/v1/organization/{orgId}/vcs/{vcsId}
+	var result struct {
+		Data struct {
+			Attributes struct {
+				AccessToken string `json:"accessToken"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if _, err := c.getJSON(fmt.Sprintf("%s/api/v1/organization/%s/vcs/%s", base, orgId, vcsId), &result); err != nil {
+		return "", err
+	}
+	return result.Data.Attributes.AccessToken, nil
+}
+
+// getJSON sends an authenticated GET and decodes the response into out. The
+// status code is returned alongside errors so callers can tell a 404 apart.
+func (c *Client) getJSON(url string, out interface{}) (int, error) {
+	return c.doJSON("GET", url, nil, out)
+}
+
+// doJSON sends an authenticated JSON:API request. payload and out may be nil.
+func (c *Client) doJSON(method, url string, payload, out interface{}) (int, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, body)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	if c.Token != "" {
@@ -153,26 +195,20 @@ func (c *Client) GetVcsToken(orgId, vcsId string) (string, error) {
 	req.Header.Set("Content-Type", "application/vnd.api+json")
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
+		return 0, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("api returned status %d: %s", resp.StatusCode, string(body))
+		return resp.StatusCode, fmt.Errorf("api returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var result struct {
-		Data struct {
-			Attributes struct {
-				AccessToken string `json:"accessToken"`
-			} `json:"attributes"`
-		} `json:"data"`
+	if out == nil {
+		return resp.StatusCode, nil
 	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
 	}
-
-	return result.Data.Attributes.AccessToken, nil
+	return resp.StatusCode, nil
 }
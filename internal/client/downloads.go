@@ -0,0 +1,31 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DownloadEvent is a module or provider download served by the registry.
+type DownloadEvent struct {
+	Type         string    `json:"type"` // module or provider
+	Organization string    `json:"organization"`
+	Name         string    `json:"name"`
+	Provider     string    `json:"provider,omitempty"` // module provider
+	Version      string    `json:"version"`
+	OS           string    `json:"os,omitempty"`
+	Arch         string    `json:"arch,omitempty"`
+	ClientIP     string    `json:"clientIp"`
+	UserAgent    string    `json:"userAgent"`
+	Time         time.Time `json:"time"`
+}
+
+// RecordDownloads reports a batch of downloads to the API's download
+// statistics.
+func (c *Client) RecordDownloads(events []DownloadEvent) error {
+	base := strings.TrimSuffix(c.BaseURL, "/graphql/api/v1")
+	if _, err := c.doJSON("POST", base+"/registry-stats/v1/downloads", map[string]interface{}{"downloads": events}, nil); err != nil {
+		return fmt.Errorf("failed to record %d downloads: %w", len(events), err)
+	}
+	return nil
+}
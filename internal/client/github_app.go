@@ -0,0 +1,36 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ilkerispir/terrakubed/internal/git"
+)
+
+// GitHubAppInstallations returns the GitHub App installations the
+// organization registered as github_app_token resources.
+func (c *Client) GitHubAppInstallations(orgId string) ([]git.AppInstallation, error) {
+	base := strings.TrimSuffix(c.BaseURL, "/graphql/api/v1")
+	var doc struct {
+		Data []struct {
+			Attributes struct {
+				AppID          string `json:"appId"`
+				InstallationID string `json:"installationId"`
+				Owner          string `json:"owner"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if _, err := c.getJSON(base+"/api/v1/github_app_token?filter[organizationId]="+url.QueryEscape(orgId), &doc); err != nil {
+		return nil, fmt.Errorf("failed to list GitHub App installations: %w", err)
+	}
+	installations := make([]git.AppInstallation, 0, len(doc.Data))
+	for _, d := range doc.Data {
+		installations = append(installations, git.AppInstallation{
+			AppID:          d.Attributes.AppID,
+			InstallationID: d.Attributes.InstallationID,
+			Owner:          d.Attributes.Owner,
+		})
+	}
+	return installations, nil
+}
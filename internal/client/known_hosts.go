@@ -0,0 +1,49 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ilkerispir/terrakubed/internal/git"
+)
+
+// HostKeys returns the SSH host keys the organization trusts for git clones.
+func (c *Client) HostKeys(orgId string) ([]git.HostKey, error) {
+	base := strings.TrimSuffix(c.BaseURL, "/graphql/api/v1")
+	var doc struct {
+		Data []struct {
+			Attributes struct {
+				Host      string `json:"host"`
+				KeyType   string `json:"keyType"`
+				PublicKey string `json:"publicKey"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if _, err := c.getJSON(base+"/api/v1/ssh_known_host?filter[organizationId]="+url.QueryEscape(orgId), &doc); err != nil {
+		return nil, fmt.Errorf("failed to list known hosts: %w", err)
+	}
+	keys := make([]git.HostKey, 0, len(doc.Data))
+	for _, d := range doc.Data {
+		keys = append(keys, git.HostKey{Host: d.Attributes.Host, KeyType: d.Attributes.KeyType, Key: d.Attributes.PublicKey})
+	}
+	return keys, nil
+}
+
+// PinHostKey stores a host key trusted on first use for the organization.
+func (c *Client) PinHostKey(orgId string, key git.HostKey) error {
+	base := strings.TrimSuffix(c.BaseURL, "/graphql/api/v1")
+	payload := map[string]interface{}{
+		"data": map[string]interface{}{
+			"type": "ssh_known_host",
+			"attributes": map[string]interface{}{
+				"host":      key.Host,
+				"keyType":   key.KeyType,
+				"publicKey": key.Key,
+				"source":    "tofu",
+			},
+		},
+	}
+	_, err := c.doJSON("POST", fmt.Sprintf("%s/api/v1/organization/%s/knownHost", base, orgId), payload, nil)
+	return err
+}
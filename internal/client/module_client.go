@@ -4,6 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
+	"strings"
+
+	goversion "github.com/hashicorp/go-version"
 )
 
 const searchOrganizationModuleVersionQuery = `
@@ -187,3 +191,158 @@ func (c *Client) GetModule(organization, module, provider string) (*ModuleDetail
 
 	return nil, "", fmt.Errorf("module not found")
 }
+
+const listModulesQuery = `
+{
+  organization {
+    edges {
+      node {
+        id
+        name
+        module {
+            edges{
+                node{
+                    id
+                    name
+                    provider
+                    source
+                    folder
+                    tagPrefix
+                    vcs {
+                        edges {
+                            node {
+                                id
+                                vcsType
+                                clientId
+                            }
+                        }
+                    }
+                    ssh {
+                        edges {
+                            node {
+                                sshType
+                                privateKey
+                            }
+                        }
+                    }
+                }
+            }
+        }
+      }
+    }
+  }
+}`
+
+// RegistryModule is a module of any organization, as listed by ListModules.
+type RegistryModule struct {
+	ModuleDetails
+	Name           string `json:"name"`
+	Provider       string `json:"provider"`
+	Organization   string `json:"-"`
+	OrganizationID string `json:"-"`
+}
+
+// ListModules returns the modules of every organization.
+func (c *Client) ListModules() ([]RegistryModule, error) {
+	respData, err := c.ExecuteQuery(listModulesQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Organization struct {
+			Edges []struct {
+				Node struct {
+					ID     string `json:"id"`
+					Name   string `json:"name"`
+					Module struct {
+						Edges []struct {
+							Node RegistryModule `json:"node"`
+						} `json:"edges"`
+					} `json:"module"`
+				} `json:"node"`
+			} `json:"edges"`
+		} `json:"organization"`
+	}
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	var modules []RegistryModule
+	for _, orgEdge := range resp.Organization.Edges {
+		for _, modEdge := range orgEdge.Node.Module.Edges {
+			module := modEdge.Node
+			module.Organization = orgEdge.Node.Name
+			module.OrganizationID = orgEdge.Node.ID
+			modules = append(modules, module)
+		}
+	}
+	return modules, nil
+}
+
+// ModuleVersionExists reports whether the module already has the version.
+func (c *Client) ModuleVersionExists(moduleId, version string) (bool, error) {
+	base := strings.TrimSuffix(c.BaseURL, "/graphql/api/v1")
+	id, err := c.findID(fmt.Sprintf("%s/api/v1/module_version?filter[moduleId]=%s&filter[version]=%s",
+		base, url.QueryEscape(moduleId), url.QueryEscape(version)))
+	if err != nil {
+		return false, fmt.Errorf("failed to look up module version: %w", err)
+	}
+	return id != "", nil
+}
+
+// CreateModuleVersion registers a version of the module and makes it the
+// module's latest version if it is newer. commit is the tagged commit, if any.
+func (c *Client) CreateModuleVersion(moduleId, version, commit string) error {
+	base := strings.TrimSuffix(c.BaseURL, "/graphql/api/v1")
+	attributes := map[string]interface{}{"version": version}
+	if commit != "" {
+		attributes["commit"] = commit
+	}
+	if _, err := c.create(fmt.Sprintf("%s/api/v1/module/%s/version", base, moduleId), "module_version", attributes); err != nil {
+		return fmt.Errorf("failed to create module version %s: %w", version, err)
+	}
+
+	var module struct {
+		Data struct {
+			Attributes struct {
+				LatestVersion string `json:"latestVersion"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if _, err := c.getJSON(fmt.Sprintf("%s/api/v1/module/%s", base, moduleId), &module); err != nil {
+		return fmt.Errorf("failed to read module: %w", err)
+	}
+	if !newerVersion(version, module.Data.Attributes.LatestVersion) {
+		return nil
+	}
+	payload := map[string]interface{}{
+		"data": map[string]interface{}{
+			"type":       "module",
+			"id":         moduleId,
+			"attributes": map[string]interface{}{"latestVersion": version},
+		},
+	}
+	if _, err := c.doJSON("PATCH", fmt.Sprintf("%s/api/v1/module/%s", base, moduleId), payload, nil); err != nil {
+		return fmt.Errorf("failed to update latest module version: %w", err)
+	}
+	return nil
+}
+
+// newerVersion reports whether version is newer than latest; an empty or
+// unparsable latest is always replaced. Pre-releases only replace other
+// pre-releases, as terraform never selects them for a range constraint.
+func newerVersion(version, latest string) bool {
+	v, err := goversion.NewVersion(version)
+	if err != nil {
+		return false
+	}
+	l, err := goversion.NewVersion(latest)
+	if err != nil {
+		return true
+	}
+	if v.Prerelease() != "" && l.Prerelease() == "" {
+		return false
+	}
+	return v.GreaterThan(l)
+}
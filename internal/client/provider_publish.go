@@ -0,0 +1,151 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrNoSigningKey is returned by SigningKey when the organization has no GPG key.
+var ErrNoSigningKey = errors.New("organization has no GPG signing key")
+
+// ProviderImplementation is the package of a private provider release for one
+// os/arch, as served to terraform by the provider download endpoint.
+type ProviderImplementation struct {
+	OS                  string
+	Arch                string
+	Filename            string
+	DownloadURL         string
+	ShasumsURL          string
+	ShasumsSignatureURL string
+	Shasum              string
+	KeyID               string
+	AsciiArmor          string
+	Source              string
+}
+
+// OrganizationID looks up an organization by name.
+func (c *Client) OrganizationID(name string) (string, error) {
+	base := strings.TrimSuffix(c.BaseURL, "/graphql/api/v1")
+	id, err := c.findID(base + "/api/v1/organization?filter[name]=" + url.QueryEscape(name))
+	if err != nil {
+		return "", fmt.Errorf("failed to look up organization %s: %w", name, err)
+	}
+	if id == "" {
+		return "", fmt.Errorf("organization %s not found", name)
+	}
+	return id, nil
+}
+
+// SigningKey returns the organization's ASCII-armored GPG private key.
+func (c *Client) SigningKey(orgId string) (string, error) {
+	base := strings.TrimSuffix(c.BaseURL, "/graphql/api/v1")
+	var doc struct {
+		Data []struct {
+			Attributes struct {
+				PrivateKey string `json:"privateKey"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if _, err := c.getJSON(base+"/api/v1/gpg_key?filter[organizationId]="+url.QueryEscape(orgId), &doc); err != nil {
+		return "", fmt.Errorf("failed to read GPG key: %w", err)
+	}
+	if len(doc.Data) == 0 || doc.Data[0].Attributes.PrivateKey == "" {
+		return "", ErrNoSigningKey
+	}
+	return doc.Data[0].Attributes.PrivateKey, nil
+}
+
+// PublishProvider records a private provider package: the provider and version
+// are created if needed, and the implementation for the package's os/arch is
+// created or replaced.
+func (c *Client) PublishProvider(orgId, provider, version string, protocols []string, impl ProviderImplementation) error {
+	base := strings.TrimSuffix(c.BaseURL, "/graphql/api/v1")
+
+	providerID, err := c.findOrCreate(
+		fmt.Sprintf("%s/api/v1/provider?filter[organizationId]=%s&filter[name]=%s", base, url.QueryEscape(orgId), url.QueryEscape(provider)),
+		fmt.Sprintf("%s/api/v1/organization/%s/provider", base, orgId),
+		"provider", map[string]interface{}{"name": provider})
+	if err != nil {
+		return fmt.Errorf("failed to create provider %s: %w", provider, err)
+	}
+
+	versionID, err := c.findOrCreate(
+		fmt.Sprintf("%s/api/v1/version?filter[providerId]=%s&filter[versionNumber]=%s", base, providerID, url.QueryEscape(version)),
+		fmt.Sprintf("%s/api/v1/provider/%s/version", base, providerID),
+		"version", map[string]interface{}{"versionNumber": version, "protocols": strings.Join(protocols, ",")})
+	if err != nil {
+		return fmt.Errorf("failed to create provider version %s: %w", version, err)
+	}
+
+	attributes := map[string]interface{}{
+		"os":                  impl.OS,
+		"arch":                impl.Arch,
+		"filename":            impl.Filename,
+		"downloadUrl":         impl.DownloadURL,
+		"shasumsUrl":          impl.ShasumsURL,
+		"shasumsSignatureUrl": impl.ShasumsSignatureURL,
+		"shasum":              impl.Shasum,
+		"keyId":               impl.KeyID,
+		"asciiArmor":          impl.AsciiArmor,
+		"source":              impl.Source,
+	}
+	implID, err := c.findID(fmt.Sprintf("%s/api/v1/implementation?filter[versionId]=%s&filter[os]=%s&filter[arch]=%s",
+		base, versionID, url.QueryEscape(impl.OS), url.QueryEscape(impl.Arch)))
+	if err != nil {
+		return fmt.Errorf("failed to look up implementation: %w", err)
+	}
+	if implID != "" {
+		payload := map[string]interface{}{
+			"data": map[string]interface{}{"type": "implementation", "id": implID, "attributes": attributes},
+		}
+		_, err = c.doJSON("PATCH", fmt.Sprintf("%s/api/v1/implementation/%s", base, implID), payload, nil)
+	} else {
+		_, err = c.create(fmt.Sprintf("%s/api/v1/version/%s/implementation", base, versionID), "implementation", attributes)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save implementation %s_%s: %w", impl.OS, impl.Arch, err)
+	}
+	return nil
+}
+
+// findID returns the id of the first resource listed at listURL, or "".
+func (c *Client) findID(listURL string) (string, error) {
+	var doc struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if _, err := c.getJSON(listURL, &doc); err != nil {
+		return "", err
+	}
+	if len(doc.Data) == 0 {
+		return "", nil
+	}
+	return doc.Data[0].ID, nil
+}
+
+func (c *Client) findOrCreate(listURL, createURL, resourceType string, attributes map[string]interface{}) (string, error) {
+	id, err := c.findID(listURL)
+	if err != nil || id != "" {
+		return id, err
+	}
+	return c.create(createURL, resourceType, attributes)
+}
+
+// create POSTs a JSON:API resource and returns its id.
+func (c *Client) create(createURL, resourceType string, attributes map[string]interface{}) (string, error) {
+	payload := map[string]interface{}{
+		"data": map[string]interface{}{"type": resourceType, "attributes": attributes},
+	}
+	var doc struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if _, err := c.doJSON("POST", createURL, payload, &doc); err != nil {
+		return "", err
+	}
+	return doc.Data.ID, nil
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Team is an organization team: the group it maps to and what its members
+// may manage in the organization.
+type Team struct {
+	Name           string
+	ManageModule   bool
+	ManageProvider bool
+}
+
+// Teams lists the teams of an organization.
+func (c *Client) Teams(orgId string) ([]Team, error) {
+	base := strings.TrimSuffix(c.BaseURL, "/graphql/api/v1")
+	var doc struct {
+		Data []struct {
+			Attributes struct {
+				Name           string `json:"name"`
+				ManageModule   bool   `json:"manageModule"`
+				ManageProvider bool   `json:"manageProvider"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if _, err := c.getJSON(base+"/api/v1/team?filter[organizationId]="+url.QueryEscape(orgId), &doc); err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	teams := make([]Team, 0, len(doc.Data))
+	for _, d := range doc.Data {
+		teams = append(teams, Team{
+			Name:           d.Attributes.Name,
+			ManageModule:   d.Attributes.ManageModule,
+			ManageProvider: d.Attributes.ManageProvider,
+		})
+	}
+	return teams, nil
+}
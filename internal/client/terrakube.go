@@ -4,8 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"time"
+
+	"github.com/ilkerispir/terrakubed/internal/model"
 )
 
 type TerrakubeClient struct {
@@ -66,6 +70,201 @@ func (c *TerrakubeClient) UpdateJobCommitId(orgId, jobId, commitId string) error
 	return c.patch(fmt.Sprintf("/api/v1/organization/%s/job/%s", orgId, jobId), payload)
 }
 
+// UpdateJobPlanSummary stores the plan result counts on the job.
+func (c *TerrakubeClient) UpdateJobPlanSummary(orgId, jobId string, planChanges bool, add, change, destroy, replace int) error {
+	payload := map[string]interface{}{
+		"data": map[string]interface{}{
+			"type": "job",
+			"id":   jobId,
+			"attributes": map[string]interface{}{
+				"planChanges": planChanges,
+				"planAdd":     add,
+				"planChange":  change,
+				"planDestroy": destroy,
+				"planReplace": replace,
+			},
+		},
+	}
+	return c.patch(fmt.Sprintf("/api/v1/organization/%s/job/%s", orgId, jobId), payload)
+}
+
+// UpdateWorkspaceDrift stores the result of a drift check on the workspace.
+func (c *TerrakubeClient) UpdateWorkspaceDrift(orgId, workspaceId string, driftStatus model.DriftStatus, checkedAt time.Time) error {
+	payload := map[string]interface{}{
+		"data": map[string]interface{}{
+			"type": "workspace",
+			"id":   workspaceId,
+			"attributes": map[string]interface{}{
+				"driftStatus":      driftStatus,
+				"driftCheckedDate": checkedAt.UTC().Format(time.RFC3339),
+			},
+		},
+	}
+	return c.patch(fmt.Sprintf("/api/v1/organization/%s/workspace/%s", orgId, workspaceId), payload)
+}
+
+// jsonAPIList is the subset of a JSON:API collection response the client reads.
+type jsonAPIList struct {
+	Data []struct {
+		ID            string                         `json:"id"`
+		Attributes    map[string]interface{}         `json:"attributes"`
+		Relationships map[string]jsonAPIRelationship `json:"relationships"`
+	} `json:"data"`
+}
+
+// jsonAPIRelationship is a relationship of a JSON:API resource; foreign keys
+// are served as relationships, not attributes. Data is a resource identifier,
+// a list of them or null, so it is decoded on use.
+type jsonAPIRelationship struct {
+	Data json.RawMessage `json:"data"`
+}
+
+// relationshipID returns the ID of a to-one relationship, or "" when it is
+// not set.
+func relationshipID(rels map[string]jsonAPIRelationship, name string) string {
+	var identifier struct {
+		ID string `json:"id"`
+	}
+	if rel, ok := rels[name]; ok && json.Unmarshal(rel.Data, &identifier) == nil {
+		return identifier.ID
+	}
+	return ""
+}
+
+// GetJobPullRequest returns the pull request a job was created for, or nil
+// for regular jobs.
+func (c *TerrakubeClient) GetJobPullRequest(orgId, jobId string) (*model.PullRequest, error) {
+	var doc struct {
+		Data struct {
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := c.get(fmt.Sprintf("/api/v1/organization/%s/job/%s", orgId, jobId), &doc); err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	number, _ := doc.Data.Attributes["pullRequestNumber"].(float64)
+	if number == 0 {
+		return nil, nil
+	}
+	return &model.PullRequest{
+		Number: int(number),
+		URL:    stringAttr(doc.Data.Attributes, "pullRequestUrl"),
+		Branch: stringAttr(doc.Data.Attributes, "overrideBranch"),
+	}, nil
+}
+
+// workspaceVcsId returns the ID of the workspace's VCS connection, or "" when
+// it has none.
+func (c *TerrakubeClient) workspaceVcsId(orgId, workspaceId string) (string, error) {
+	var workspace struct {
+		Data struct {
+			Relationships map[string]jsonAPIRelationship `json:"relationships"`
+		} `json:"data"`
+	}
+	if err := c.get(fmt.Sprintf("/api/v1/organization/%s/workspace/%s", orgId, workspaceId), &workspace); err != nil {
+		return "", fmt.Errorf("failed to get workspace: %w", err)
+	}
+	return relationshipID(workspace.Data.Relationships, "vcs"), nil
+}
+
+// GetWorkspaceVcsApiUrl returns the API URL of the workspace's VCS
+// connection, or "" when it has none.
+func (c *TerrakubeClient) GetWorkspaceVcsApiUrl(orgId, workspaceId string) (string, error) {
+	vcsId, err := c.workspaceVcsId(orgId, workspaceId)
+	if err != nil || vcsId == "" {
+		return "", err
+	}
+	var vcs struct {
+		Data struct {
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := c.get(fmt.Sprintf("/api/v1/organization/%s/vcs/%s", orgId, vcsId), &vcs); err != nil {
+		return "", fmt.Errorf("failed to get vcs connection: %w", err)
+	}
+	return stringAttr(vcs.Data.Attributes, "apiUrl"), nil
+}
+
+// GetWorkspaceVcsToken returns a current access token of the workspace's VCS
+// connection from the Go API's /vcs-token/v1 endpoint, which refreshes
+// expired OAuth tokens first. It returns "" when the workspace has no
+// connection.
+func (c *TerrakubeClient) GetWorkspaceVcsToken(orgId, workspaceId string) (string, error) {
+	vcsId, err := c.workspaceVcsId(orgId, workspaceId)
+	if err != nil || vcsId == "" {
+		return "", err
+	}
+	var token struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if err := c.get(fmt.Sprintf("/vcs-token/v1/organization/%s/vcs/%s", orgId, vcsId), &token); err != nil {
+		return "", fmt.Errorf("failed to get vcs token: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// GetPolicySets returns the organization's policy sets with their policies.
+func (c *TerrakubeClient) GetPolicySets(orgId string) ([]model.PolicySet, error) {
+	var sets jsonAPIList
+	if err := c.get("/api/v1/policy_set?filter[organizationId]="+url.QueryEscape(orgId), &sets); err != nil {
+		return nil, fmt.Errorf("failed to list policy sets: %w", err)
+	}
+
+	result := make([]model.PolicySet, 0, len(sets.Data))
+	for _, s := range sets.Data {
+		set := model.PolicySet{
+			Id:           s.ID,
+			Name:         stringAttr(s.Attributes, "name"),
+			OverrideTeam: stringAttr(s.Attributes, "overrideTeam"),
+		}
+
+		var policies jsonAPIList
+		if err := c.get("/api/v1/policy?filter[policySetId]="+url.QueryEscape(s.ID), &policies); err != nil {
+			return nil, fmt.Errorf("failed to list policies of set %s: %w", set.Name, err)
+		}
+		for _, p := range policies.Data {
+			set.Policies = append(set.Policies, model.Policy{
+				Id:               p.ID,
+				Name:             stringAttr(p.Attributes, "name"),
+				Rego:             stringAttr(p.Attributes, "rego"),
+				EnforcementLevel: stringAttr(p.Attributes, "enforcementLevel"),
+			})
+		}
+		result = append(result, set)
+	}
+	return result, nil
+}
+
+// GetNotifications returns every notification destination of the organization,
+// including the workspace-scoped ones.
+func (c *TerrakubeClient) GetNotifications(orgId string) ([]model.Notification, error) {
+	var list jsonAPIList
+	if err := c.get("/api/v1/notification?filter[organizationId]="+url.QueryEscape(orgId), &list); err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	result := make([]model.Notification, 0, len(list.Data))
+	for _, n := range list.Data {
+		enabled, _ := n.Attributes["enabled"].(bool)
+		result = append(result, model.Notification{
+			Id:              n.ID,
+			Name:            stringAttr(n.Attributes, "name"),
+			DestinationType: stringAttr(n.Attributes, "destinationType"),
+			Url:             stringAttr(n.Attributes, "url"),
+			Token:           stringAttr(n.Attributes, "token"),
+			EmailAddresses:  model.SplitList(stringAttr(n.Attributes, "emailAddresses")),
+			Events:          model.SplitList(stringAttr(n.Attributes, "events")),
+			Enabled:         enabled,
+			WorkspaceId:     relationshipID(n.Relationships, "workspace"),
+		})
+	}
+	return result, nil
+}
+
+func stringAttr(attrs map[string]interface{}, key string) string {
+	s, _ := attrs[key].(string)
+	return s
+}
+
 // CreateHistory creates a workspace history record after apply/destroy.
 func (c *TerrakubeClient) CreateHistory(orgId, workspaceId, stateURL string) error {
 	payload := map[string]interface{}{
@@ -79,21 +278,45 @@ func (c *TerrakubeClient) CreateHistory(orgId, workspaceId, stateURL string) err
 	return c.post(fmt.Sprintf("/api/v1/organization/%s/workspace/%s/history", orgId, workspaceId), payload)
 }
 
+// CreateAddress records a resource address touched by a job.
+func (c *TerrakubeClient) CreateAddress(orgId, jobId, name, addressType string) error {
+	payload := map[string]interface{}{
+		"data": map[string]interface{}{
+			"type": "address",
+			"attributes": map[string]interface{}{
+				"name": name,
+				"type": addressType,
+			},
+		},
+	}
+	return c.post(fmt.Sprintf("/api/v1/organization/%s/job/%s/address", orgId, jobId), payload)
+}
+
+func (c *TerrakubeClient) get(path string, out interface{}) error {
+	return c.doRequest("GET", path, nil, out)
+}
+
 func (c *TerrakubeClient) patch(path string, payload interface{}) error {
-	return c.doRequest("PATCH", path, payload)
+	return c.doRequest("PATCH", path, payload, nil)
 }
 
 func (c *TerrakubeClient) post(path string, payload interface{}) error {
-	return c.doRequest("POST", path, payload)
+	return c.doRequest("POST", path, payload, nil)
 }
 
-func (c *TerrakubeClient) doRequest(method, path string, payload interface{}) error {
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return err
+// doRequest sends a JSON:API request and, when out is non-nil, decodes the
+// response body into it.
+func (c *TerrakubeClient) doRequest(method, path string, payload interface{}, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(data)
 	}
 
-	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", c.ApiUrl, path), bytes.NewBuffer(body))
+	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", c.ApiUrl, path), body)
 	if err != nil {
 		return err
 	}
@@ -112,5 +335,8 @@ func (c *TerrakubeClient) doRequest(method, path string, payload interface{}) er
 		return fmt.Errorf("API request failed with status: %d", resp.StatusCode)
 	}
 
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
 	return nil
 }
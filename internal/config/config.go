@@ -37,11 +37,61 @@ type Config struct {
 	AppClientId        string
 	TerrakubeUiURL     string
 
+	// Upstream registries proxied by the provider network mirror
+	ProviderMirrorUpstreams string
+	// Size limit of uploaded module archives in MB
+	ModuleUploadMaxSizeMb string
+	// Size limit of uploaded provider packages in MB
+	ProviderUploadMaxSizeMb string
+	// How often module versions are discovered from VCS tags (e.g. 15m); off when empty
+	ModuleTagSyncInterval string
+	// Clone versions discovered from tags into storage right away
+	ModuleTagSyncEager bool
+	// Registry lookup cache: "memory" (default) or "redis", entries and TTL (e.g. 10m)
+	RegistryCache     string
+	RegistryCacheSize string
+	RegistryCacheTTL  string
+	// Report module and provider downloads to the API's statistics
+	RegistryDownloadStats bool
+	// HMAC key and lifetime (e.g. 10m) of module download URLs; the key defaults to InternalSecret
+	ModuleDownloadSigningKey string
+	ModuleDownloadUrlTtl     string
+
 	// Executor Specific
 	Mode                    string
 	EphemeralJobData        *model.TerraformJob
 	TerrakubeRegistryDomain string
 	StorageType             string
+	CostPricingFile         string
+
+	// SMTP server for email notification destinations
+	SmtpHost     string
+	SmtpPort     string
+	SmtpUsername string
+	SmtpPassword string
+	SmtpFrom     string
+
+	// Repository clones: "cli" (git binary) or "go-git" (in-process)
+	GitClient     string
+	GitSubmodules bool
+	GitLfs        bool
+	// Shared bare-mirror cache for clones; size limit in MB (0 = unlimited)
+	GitMirrorDir       string
+	GitMirrorMaxSizeMb string
+	// SSH host key checking for git clones: tofu, strict or off
+	SshHostKeyChecking string
+
+	// Provider plugin cache shared by executor jobs, and optional provider
+	// mirrors written to the generated .terraformrc
+	PluginCacheDir           string
+	ProviderFilesystemMirror string
+	ProviderNetworkMirror    string
+
+	// GitHub App used for clones by the executor and registry
+	GitHubAppId             string
+	GitHubAppPrivateKey     string
+	GitHubAppPrivateKeyFile string
+	GitHubAppApiUrl         string
 
 	// API Specific
 	DatabaseURL   string
@@ -204,10 +254,48 @@ func LoadConfig() (*Config, error) {
 		AppClientId:        getEnv("AppClientId", ""),
 		TerrakubeUiURL:     getEnvWithFallback("TerrakubeUiURL", "TERRAKUBE_UI_URL"),
 
+		ProviderMirrorUpstreams:  getEnv("PROVIDER_MIRROR_UPSTREAMS", "registry.terraform.io,registry.opentofu.org"),
+		ModuleUploadMaxSizeMb:    getEnvWithFallback("MODULE_UPLOAD_MAX_SIZE_MB", "ModuleUploadMaxSizeMb"),
+		ProviderUploadMaxSizeMb:  getEnvWithFallback("PROVIDER_UPLOAD_MAX_SIZE_MB", "ProviderUploadMaxSizeMb"),
+		ModuleTagSyncInterval:    getEnvWithFallback("MODULE_TAG_SYNC_INTERVAL", "ModuleTagSyncInterval"),
+		ModuleTagSyncEager:       getEnvWithFallback("MODULE_TAG_SYNC_EAGER", "ModuleTagSyncEager") == "true",
+		RegistryCache:            getEnvWithFallback("REGISTRY_CACHE", "RegistryCache"),
+		RegistryCacheSize:        getEnvWithFallback("REGISTRY_CACHE_SIZE", "RegistryCacheSize"),
+		RegistryCacheTTL:         getEnvWithFallback("REGISTRY_CACHE_TTL", "RegistryCacheTTL"),
+		RegistryDownloadStats:    getEnvWithFallback("REGISTRY_DOWNLOAD_STATS", "RegistryDownloadStats") != "false",
+		ModuleDownloadSigningKey: getEnvWithFallback("MODULE_DOWNLOAD_SIGNING_KEY", "ModuleDownloadSigningKey"),
+		ModuleDownloadUrlTtl:     getEnvWithFallback("MODULE_DOWNLOAD_URL_TTL", "ModuleDownloadUrlTtl"),
+
 		// Executor
 		Mode:                    getExecutorMode(),
 		TerrakubeRegistryDomain: getEnvWithFallback("TERRAKUBE_REGISTRY_DOMAIN", "TerrakubeRegistryDomain"),
 		StorageType:             getStorageType(),
+		CostPricingFile:         getEnvWithFallback("COST_PRICING_FILE", "CostPricingFile"),
+		SmtpHost:                getEnvWithFallback("SMTP_HOST", "SmtpHost"),
+		SmtpPort:                getEnvWithFallback("SMTP_PORT", "SmtpPort"),
+		SmtpUsername:            getEnvWithFallback("SMTP_USERNAME", "SmtpUsername"),
+		SmtpPassword:            getEnvWithFallback("SMTP_PASSWORD", "SmtpPassword"),
+		SmtpFrom:                getEnvWithFallback("SMTP_FROM", "SmtpFrom"),
+
+		// Git
+		GitClient:     getEnvWithFallback("GIT_CLIENT", "GitClient"),
+		GitSubmodules: getEnvWithFallback("GIT_SUBMODULES", "GitSubmodules") == "true",
+		GitLfs:        getEnvWithFallback("GIT_LFS", "GitLfs") == "true",
+
+		GitMirrorDir:       getEnvWithFallback("GIT_MIRROR_DIR", "GitMirrorDir"),
+		GitMirrorMaxSizeMb: getEnvWithFallback("GIT_MIRROR_MAX_SIZE_MB", "GitMirrorMaxSizeMb"),
+		SshHostKeyChecking: getEnvWithFallback("SSH_HOST_KEY_CHECKING", "SshHostKeyChecking"),
+
+		// Terraform providers
+		PluginCacheDir:           getEnvWithFallback("PLUGIN_CACHE_DIR", "PluginCacheDir"),
+		ProviderFilesystemMirror: getEnvWithFallback("PROVIDER_FILESYSTEM_MIRROR", "ProviderFilesystemMirror"),
+		ProviderNetworkMirror:    getEnvWithFallback("PROVIDER_NETWORK_MIRROR", "ProviderNetworkMirror"),
+
+		// GitHub App
+		GitHubAppId:             getEnvWithFallback("GITHUB_APP_ID", "GitHubAppId"),
+		GitHubAppPrivateKey:     getEnvWithFallback("GITHUB_APP_PRIVATE_KEY", "GitHubAppPrivateKey"),
+		GitHubAppPrivateKeyFile: getEnvWithFallback("GITHUB_APP_PRIVATE_KEY_FILE", "GitHubAppPrivateKeyFile"),
+		GitHubAppApiUrl:         getEnvWithFallback("GITHUB_APP_API_URL", "GitHubAppApiUrl"),
 
 		// API
 		DatabaseURL:   buildDatabaseURL(),
@@ -7,19 +7,22 @@ import (
 	"log"
 	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/ilkerispir/terrakubed/internal/auth"
 	"github.com/ilkerispir/terrakubed/internal/config"
+	"github.com/ilkerispir/terrakubed/internal/executor/cost"
 	"github.com/ilkerispir/terrakubed/internal/executor/logs"
+	"github.com/ilkerispir/terrakubed/internal/executor/policy"
 	"github.com/ilkerispir/terrakubed/internal/executor/script"
 	"github.com/ilkerispir/terrakubed/internal/executor/terraform"
 	"github.com/ilkerispir/terrakubed/internal/executor/workspace"
+	"github.com/ilkerispir/terrakubed/internal/git"
 	"github.com/ilkerispir/terrakubed/internal/model"
 	"github.com/ilkerispir/terrakubed/internal/status"
 	"github.com/ilkerispir/terrakubed/internal/storage"
+	"github.com/ilkerispir/terrakubed/internal/vcs"
 )
 
 type JobProcessor struct {
@@ -27,15 +30,26 @@ type JobProcessor struct {
 	Config         *config.Config
 	Storage        storage.StorageService
 	VersionManager *terraform.VersionManager
+	// CostEstimator prices plans; nil disables cost estimation.
+	CostEstimator cost.Estimator
 }
 
 func NewJobProcessor(cfg *config.Config, status status.StatusService, storage storage.StorageService) *JobProcessor {
-	return &JobProcessor{
+	p := &JobProcessor{
 		Config:         cfg,
 		Status:         status,
 		Storage:        storage,
 		VersionManager: terraform.NewVersionManager(),
 	}
+	if cfg.CostPricingFile != "" {
+		estimator, err := cost.NewFileEstimator(cfg.CostPricingFile)
+		if err != nil {
+			log.Printf("Warning: cost estimation disabled: %v", err)
+		} else {
+			p.CostEstimator = estimator
+		}
+	}
+	return p
 }
 
 func stripScheme(domain string) string {
@@ -63,19 +77,15 @@ func (p *JobProcessor) generateTerraformCredentials(job *model.TerraformJob, wor
 		log.Printf("Warning: InternalSecret is empty, skipping token generation")
 	}
 
-	if token == "" {
-		return nil
-	}
-
 	content := ""
 
 	registryHost := stripScheme(p.Config.TerrakubeRegistryDomain)
-	if registryHost != "" {
+	if token != "" && registryHost != "" {
 		content += fmt.Sprintf("credentials \"%s\" {\n  token = \"%s\"\n}\n", registryHost, token)
 		log.Printf("generateTerraformCredentials: added credentials for registryHost: %s", registryHost)
 	}
 
-	if p.Config.AzBuilderApiUrl != "" {
+	if token != "" && p.Config.AzBuilderApiUrl != "" {
 		parsedUrl, err := url.Parse(p.Config.AzBuilderApiUrl)
 		if err == nil && parsedUrl.Hostname() != "" {
 			apiHost := parsedUrl.Hostname()
@@ -86,6 +96,8 @@ func (p *JobProcessor) generateTerraformCredentials(job *model.TerraformJob, wor
 		}
 	}
 
+	content += providerInstallation(p.Config.ProviderFilesystemMirror, p.Config.ProviderNetworkMirror)
+
 	if content == "" {
 		log.Printf("generateTerraformCredentials: no credentials generated, returning")
 		return nil
@@ -101,6 +113,29 @@ func (p *JobProcessor) generateTerraformCredentials(job *model.TerraformJob, wor
 	return os.WriteFile(rcPath, []byte(content), 0644)
 }
 
+// providerInstallation renders the provider_installation block for the
+// configured mirrors. Providers missing from the mirrors still install
+// directly from their origin registry.
+func providerInstallation(filesystemMirror, networkMirror string) string {
+	if filesystemMirror == "" && networkMirror == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("provider_installation {\n")
+	if filesystemMirror != "" {
+		fmt.Fprintf(&b, "  filesystem_mirror {\n    path = %q\n  }\n", filesystemMirror)
+	}
+	if networkMirror != "" {
+		// Terraform requires the mirror base URL to end with a slash.
+		if !strings.HasSuffix(networkMirror, "/") {
+			networkMirror += "/"
+		}
+		fmt.Fprintf(&b, "  network_mirror {\n    url = %q\n  }\n", networkMirror)
+	}
+	b.WriteString("  direct {}\n}\n")
+	return b.String()
+}
+
 // generateBackendOverride creates terrakube_override.tf that redirects Terraform's backend
 // to the same cloud storage bucket Terrakube uses — matching the Java executor's approach.
 // Terraform reads/writes state directly to cloud storage; no separate download/upload needed.
@@ -171,16 +206,6 @@ func (p *JobProcessor) generateGcpBackend(orgId, wsId string) string {
 	return sb.String()
 }
 
-func readCommitHash(workingDir string) string {
-	cmd := exec.Command("git", "rev-parse", "HEAD")
-	cmd.Dir = workingDir
-	output, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-	return strings.TrimSpace(string(output))
-}
-
 func (p *JobProcessor) ProcessJob(job *model.TerraformJob) error {
 	// Ensure nil maps/slices from JSON deserialization are initialized
 	// The Java API may send null for these fields when they are not set
@@ -236,6 +261,13 @@ func (p *JobProcessor) ProcessJob(job *model.TerraformJob) error {
 			apiToken = t
 		}
 	}
+	// Speculative plans for pull requests run on the head branch and report
+	// back to the VCS provider
+	pr := p.pullRequest(job)
+	if pr != nil && pr.Branch != "" {
+		job.Branch = pr.Branch
+	}
+	p.refreshAccessToken(job)
 	ws := workspace.NewWorkspace(job, apiToken)
 	workingDir, err := ws.Setup()
 	if err != nil {
@@ -245,7 +277,7 @@ func (p *JobProcessor) ProcessJob(job *model.TerraformJob) error {
 	defer ws.Cleanup()
 
 	// 3b. Capture and update commit ID
-	commitId := readCommitHash(workingDir)
+	commitId := git.HeadCommit(workingDir)
 	if commitId != "" {
 		if err := p.Status.UpdateCommitId(job, commitId); err != nil {
 			log.Printf("Failed to update commit ID: %v", err)
@@ -265,8 +297,8 @@ func (p *JobProcessor) ProcessJob(job *model.TerraformJob) error {
 	// 5. Execute Command
 	var executionErr error
 	switch job.Type {
-	case "terraformPlan", "terraformPlanDestroy", "terraformApply", "terraformDestroy":
-		executionErr = p.executeTerraform(job, workingDir, streamer, &logBuffer)
+	case "terraformPlan", "terraformPlanDestroy", "terraformApply", "terraformDestroy", "terraformDrift":
+		executionErr = p.executeTerraform(job, pr, workingDir, streamer, &logBuffer)
 
 	case "customScripts", "approval":
 		scriptExecutor := script.NewExecutor(job, workingDir, streamer)
@@ -293,7 +325,7 @@ func (p *JobProcessor) ProcessJob(job *model.TerraformJob) error {
 	return executionErr
 }
 
-func (p *JobProcessor) executeTerraform(job *model.TerraformJob, workingDir string, streamer logs.LogStreamer, logBuffer *bytes.Buffer) error {
+func (p *JobProcessor) executeTerraform(job *model.TerraformJob, pr *model.PullRequest, workingDir string, streamer logs.LogStreamer, logBuffer *bytes.Buffer) error {
 	execPath, err := p.VersionManager.Install(job.TerraformVersion, job.Tofu)
 	if err != nil {
 		return fmt.Errorf("failed to install terraform %s: %w", job.TerraformVersion, err)
@@ -318,9 +350,11 @@ func (p *JobProcessor) executeTerraform(job *model.TerraformJob, workingDir stri
 
 	// Notify: approval was given, operation is starting (apply / destroy only)
 	if job.Type == "terraformApply" || job.Type == "terraformDestroy" {
-		p.notifySlackApproved(job)
+		p.notifyApproved(job)
 	}
 
+	p.setPullRequestStatus(job, pr, vcs.StatePending, "Terraform plan running")
+
 	// Execute beforeInit scripts
 	scriptExec := script.NewExecutor(job, workingDir, streamer)
 	if err := scriptExec.ExecutePhase("beforeInit"); err != nil {
@@ -328,11 +362,17 @@ func (p *JobProcessor) executeTerraform(job *model.TerraformJob, workingDir stri
 	}
 
 	tfExecutor := terraform.NewExecutor(job, workingDir, streamer, execPath)
+	tfExecutor.JSONOutput = jsonLogEnabled(job)
+	tfExecutor.PluginCacheDir = p.Config.PluginCacheDir
 	result, err := tfExecutor.Execute()
+	if tfExecutor.JSONOutput {
+		p.uploadJSONLog(job, tfExecutor.Events())
+	}
 
 	if err != nil {
 		scriptExec.ExecutePhase("onFailure")
-		p.notifySlackOnFailure(job)
+		p.notifyOnFailure(job)
+		p.finishPullRequest(job, pr, nil, nil, err)
 
 		output := logBuffer.String() + "\nError: " + err.Error()
 		if statusErr := p.Status.SetCompleted(job, false, output); statusErr != nil {
@@ -346,11 +386,23 @@ func (p *JobProcessor) executeTerraform(job *model.TerraformJob, workingDir stri
 		log.Printf("Warning: after scripts failed: %v", err)
 	}
 
+	// Drift checks only report: no plan upload, no state changes, never pending
+	if job.Type == "terraformDrift" {
+		p.finishDrift(job, workingDir, execPath, logBuffer.String())
+		return nil
+	}
+
 	isPlan := job.Type == "terraformPlan" || job.Type == "terraformPlanDestroy"
 
 	// For plan jobs, parse and store structured plan JSON for UI
+	var policyResult *policy.Result
+	var costEstimate *cost.Estimate
 	if isPlan {
-		p.uploadPlanJSON(job, workingDir, execPath)
+		plan := p.uploadPlanJSON(job, workingDir, execPath)
+		p.reportPlanSummary(job, plan, result != nil && result.ExitCode == 2)
+		p.recordAddresses(job, planAddresses(plan))
+		costEstimate = p.estimateCost(job, plan)
+		policyResult = p.checkPolicies(job, plan, streamer)
 	}
 
 	// Upload State and Output
@@ -358,28 +410,71 @@ func (p *JobProcessor) executeTerraform(job *model.TerraformJob, workingDir stri
 
 	// Set final status and send matching Slack notification
 	output := logBuffer.String()
+
+	var summary *PlanSummary
+	if isPlan {
+		summary = planSummaryFromEvents(tfExecutor.Events())
+		if summary == nil {
+			summary = parsePlanSummary(output)
+		}
+		if summary != nil {
+			summary.Cost = costEstimate
+		}
+	}
+	p.finishPullRequest(job, pr, summary, policyResult, nil)
+
+	// Policy gate: hard-mandatory failures fail the job, soft-mandatory
+	// failures park it until the override team releases it.
+	if policyResult != nil && policyResult.HardFailed {
+		p.notifyOnFailure(job)
+		if err := p.Status.SetCompleted(job, false, output); err != nil {
+			log.Printf("Failed to set completed (failed) status: %v", err)
+		}
+		return fmt.Errorf("hard-mandatory policy check failed")
+	}
+	// Speculative plans are never applied: they complete once reported on the
+	// pull request, whatever the plan or soft-mandatory policies found
+	if pr != nil {
+		if err := p.Status.SetCompleted(job, true, output); err != nil {
+			log.Printf("Failed to set completed status: %v", err)
+		}
+		return nil
+	}
+	if policyResult != nil && policyResult.SoftFailed {
+		if err := p.Status.SetWaitingPolicyOverride(job, output); err != nil {
+			log.Printf("Failed to set waiting policy override status: %v", err)
+		}
+		return nil
+	}
+
 	if isPlan && result != nil && result.ExitCode == 2 {
 		// Plan has changes → pending approval
 		if err := p.Status.SetPending(job, output); err != nil {
 			log.Printf("Failed to set pending status: %v", err)
 		}
-		p.notifySlackPlanPending(job, parsePlanSummary(output))
+		p.notifyPlanPending(job, summary)
 	} else {
 		if err := p.Status.SetCompleted(job, true, output); err != nil {
 			log.Printf("Failed to set completed status: %v", err)
 		}
 		if isPlan {
 			// Plan exit 0 → no changes
-			p.notifySlackPlanNoChanges(job)
+			p.notifyPlanNoChanges(job)
 		} else {
 			// Apply or Destroy succeeded
-			p.notifySlackSuccess(job)
+			p.notifySuccess(job)
 		}
 	}
 
 	return nil
 }
 
+// jsonLogEnabled reports whether the workspace opted into Terraform's
+// machine-readable output (ENABLE_JSON_LOG=true).
+func jsonLogEnabled(job *model.TerraformJob) bool {
+	return job.EnvironmentVariables["ENABLE_JSON_LOG"] == "true"
+}
+
 func (p *JobProcessor) downloadPlanForApply(job *model.TerraformJob, workingDir string) {
 	// Plan is stored at a job-level path (no step ID) — matches the upload path
 	// used by the plan step. Using the apply step's own ID here would always fail
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/ilkerispir/terrakubed/internal/executor/terraform"
+	"github.com/ilkerispir/terrakubed/internal/model"
+)
+
+// planAddresses returns the resources and data sources a plan touches.
+// No-op changes are skipped so the address table only answers
+// "which jobs changed X", not "which jobs had X in scope".
+func planAddresses(plan *tfjson.Plan) []model.ResourceAddress {
+	if plan == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var addresses []model.ResourceAddress
+	for _, rc := range plan.ResourceChanges {
+		if rc == nil || rc.Change == nil || rc.Change.Actions.NoOp() {
+			continue
+		}
+		if seen[rc.Address] {
+			continue
+		}
+		seen[rc.Address] = true
+		addresses = append(addresses, model.ResourceAddress{Name: rc.Address, Type: addressType(rc.Mode)})
+	}
+	return addresses
+}
+
+// stateAddresses returns every resource and data source in the state,
+// including those in child modules.
+func stateAddresses(state *tfjson.State) []model.ResourceAddress {
+	if state == nil || state.Values == nil {
+		return nil
+	}
+	var addresses []model.ResourceAddress
+	var walk func(m *tfjson.StateModule)
+	walk = func(m *tfjson.StateModule) {
+		if m == nil {
+			return
+		}
+		for _, r := range m.Resources {
+			addresses = append(addresses, model.ResourceAddress{Name: r.Address, Type: addressType(r.Mode)})
+		}
+		for _, child := range m.ChildModules {
+			walk(child)
+		}
+	}
+	walk(state.Values.RootModule)
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].Name < addresses[j].Name })
+	return addresses
+}
+
+func addressType(mode tfjson.ResourceMode) string {
+	if mode == tfjson.DataResourceMode {
+		return model.AddressTypeData
+	}
+	return model.AddressTypeResource
+}
+
+// applyAddresses resolves the addresses touched by an apply. When the apply ran
+// from the plan saved by the plan step, its resource changes are exact;
+// otherwise the state after apply is the best approximation.
+func applyAddresses(tfExecutor *terraform.Executor, workingDir string) ([]model.ResourceAddress, error) {
+	planFile := filepath.Join(workingDir, "terraformLibrary.tfPlan")
+	if _, err := os.Stat(planFile); err == nil {
+		plan, err := tfExecutor.ShowPlanFileJSON(planFile)
+		if err == nil {
+			return planAddresses(plan), nil
+		}
+		log.Printf("Failed to read saved plan for addresses, falling back to state: %v", err)
+	}
+	state, err := tfExecutor.ShowCurrentState()
+	if err != nil {
+		return nil, err
+	}
+	return stateAddresses(state), nil
+}
+
+func (p *JobProcessor) recordAddresses(job *model.TerraformJob, addresses []model.ResourceAddress) {
+	if len(addresses) == 0 {
+		return
+	}
+	if err := p.Status.CreateAddresses(job, addresses); err != nil {
+		log.Printf("Failed to record job addresses: %v", err)
+		return
+	}
+	log.Printf("Recorded %d addresses for job %s", len(addresses), job.JobId)
+}
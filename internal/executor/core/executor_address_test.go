@@ -0,0 +1,84 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/ilkerispir/terrakubed/internal/model"
+)
+
+func TestPlanAddresses(t *testing.T) {
+	change := func(addr string, mode tfjson.ResourceMode, actions ...tfjson.Action) *tfjson.ResourceChange {
+		return &tfjson.ResourceChange{Address: addr, Mode: mode, Change: &tfjson.Change{Actions: actions}}
+	}
+
+	tests := []struct {
+		name string
+		plan *tfjson.Plan
+		want []model.ResourceAddress
+	}{
+		{
+			name: "nil plan",
+			plan: nil,
+			want: nil,
+		},
+		{
+			name: "skips no-op changes",
+			plan: &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{
+				change("aws_s3_bucket.logs", tfjson.ManagedResourceMode, tfjson.ActionCreate),
+				change("aws_s3_bucket.unchanged", tfjson.ManagedResourceMode, tfjson.ActionNoop),
+				change("data.aws_iam_policy_document.logs", tfjson.DataResourceMode, tfjson.ActionRead),
+				change("module.vpc.aws_subnet.private[0]", tfjson.ManagedResourceMode, tfjson.ActionDelete, tfjson.ActionCreate),
+			}},
+			want: []model.ResourceAddress{
+				{Name: "aws_s3_bucket.logs", Type: model.AddressTypeResource},
+				{Name: "data.aws_iam_policy_document.logs", Type: model.AddressTypeData},
+				{Name: "module.vpc.aws_subnet.private[0]", Type: model.AddressTypeResource},
+			},
+		},
+		{
+			name: "no changes",
+			plan: &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{
+				change("aws_s3_bucket.logs", tfjson.ManagedResourceMode, tfjson.ActionNoop),
+			}},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := planAddresses(tt.plan)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planAddresses() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateAddresses(t *testing.T) {
+	state := &tfjson.State{Values: &tfjson.StateValues{RootModule: &tfjson.StateModule{
+		Resources: []*tfjson.StateResource{
+			{Address: "aws_s3_bucket.logs", Mode: tfjson.ManagedResourceMode},
+			{Address: "data.aws_caller_identity.current", Mode: tfjson.DataResourceMode},
+		},
+		ChildModules: []*tfjson.StateModule{{
+			Address: "module.vpc",
+			Resources: []*tfjson.StateResource{
+				{Address: "module.vpc.aws_vpc.this", Mode: tfjson.ManagedResourceMode},
+			},
+		}},
+	}}}
+
+	want := []model.ResourceAddress{
+		{Name: "aws_s3_bucket.logs", Type: model.AddressTypeResource},
+		{Name: "data.aws_caller_identity.current", Type: model.AddressTypeData},
+		{Name: "module.vpc.aws_vpc.this", Type: model.AddressTypeResource},
+	}
+	if got := stateAddresses(state); !reflect.DeepEqual(got, want) {
+		t.Errorf("stateAddresses() = %+v, want %+v", got, want)
+	}
+	if got := stateAddresses(&tfjson.State{}); got != nil {
+		t.Errorf("stateAddresses(empty) = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/ilkerispir/terrakubed/internal/executor/cost"
+	"github.com/ilkerispir/terrakubed/internal/model"
+)
+
+// estimateCost prices the plan and stores the breakdown next to context.json
+// at tfplan/{jobId}/cost.json. Returns nil when estimation is disabled or fails.
+func (p *JobProcessor) estimateCost(job *model.TerraformJob, plan *tfjson.Plan) *cost.Estimate {
+	if p.CostEstimator == nil || plan == nil {
+		return nil
+	}
+	est, err := p.CostEstimator.Estimate(plan)
+	if err != nil {
+		log.Printf("Cost estimation failed: %v", err)
+		return nil
+	}
+
+	data, err := json.Marshal(est)
+	if err != nil {
+		log.Printf("Failed to marshal cost estimate: %v", err)
+		return est
+	}
+	remotePath := fmt.Sprintf("tfplan/%s/cost.json", job.JobId)
+	if err := p.Storage.UploadFile(remotePath, bytes.NewReader(data)); err != nil {
+		log.Printf("Failed to upload cost estimate: %v", err)
+		return est
+	}
+	log.Printf("Uploaded cost estimate to %s (delta=%.2f %s/month)", remotePath, est.DeltaMonthly, est.Currency)
+	return est
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/ilkerispir/terrakubed/internal/executor/terraform"
+	"github.com/ilkerispir/terrakubed/internal/model"
+)
+
+// driftReport is the JSON structure stored at tfdrift/{orgId}/{workspaceId}/{jobId}.json.
+type driftReport struct {
+	JobId         string                   `json:"jobId"`
+	WorkspaceId   string                   `json:"workspaceId"`
+	CheckedAt     time.Time                `json:"checkedAt"`
+	Drifted       bool                     `json:"drifted"`
+	ResourceCount int                      `json:"resourceCount"`
+	ResourceDrift []*tfjson.ResourceChange `json:"resourceDrift"`
+}
+
+// buildDriftReport collects the resources whose real state differs from the
+// Terraform state. No-op entries are ignored.
+func buildDriftReport(job *model.TerraformJob, plan *tfjson.Plan, checkedAt time.Time) *driftReport {
+	report := &driftReport{
+		JobId:         job.JobId,
+		WorkspaceId:   job.WorkspaceId,
+		CheckedAt:     checkedAt,
+		ResourceDrift: []*tfjson.ResourceChange{},
+	}
+	if plan != nil {
+		for _, rc := range plan.ResourceDrift {
+			if rc == nil || rc.Change == nil || rc.Change.Actions.NoOp() {
+				continue
+			}
+			report.ResourceDrift = append(report.ResourceDrift, rc)
+		}
+	}
+	report.ResourceCount = len(report.ResourceDrift)
+	report.Drifted = report.ResourceCount > 0
+	return report
+}
+
+// finishDrift stores the drift report, updates the workspace drift status and
+// completes the job. Drift jobs never go to pending: there is nothing to apply.
+func (p *JobProcessor) finishDrift(job *model.TerraformJob, workingDir, execPath, output string) {
+	tfExecutor := terraform.NewExecutor(job, workingDir, nil, execPath)
+	plan, err := tfExecutor.ShowPlanJSON()
+	if err != nil {
+		log.Printf("Failed to parse drift plan JSON: %v", err)
+	}
+
+	report := buildDriftReport(job, plan, time.Now())
+	if plan != nil {
+		p.uploadDriftReport(job, report)
+
+		driftStatus := model.DriftStatusInSync
+		if report.Drifted {
+			driftStatus = model.DriftStatusDrifted
+		}
+		if err := p.Status.UpdateDriftStatus(job, driftStatus, report.CheckedAt); err != nil {
+			log.Printf("Failed to update workspace drift status: %v", err)
+		}
+	}
+
+	if err := p.Status.SetCompleted(job, true, output); err != nil {
+		log.Printf("Failed to set completed status: %v", err)
+	}
+
+	if report.Drifted {
+		p.notifyDrift(job, report.ResourceCount)
+	}
+}
+
+func (p *JobProcessor) uploadDriftReport(job *model.TerraformJob, report *driftReport) {
+	data, err := json.Marshal(report)
+	if err != nil {
+		log.Printf("Failed to marshal drift report: %v", err)
+		return
+	}
+	remotePath := fmt.Sprintf("tfdrift/%s/%s/%s.json", job.OrganizationId, job.WorkspaceId, job.JobId)
+	if err := p.Storage.UploadFile(remotePath, bytes.NewReader(data)); err != nil {
+		log.Printf("Failed to upload drift report: %v", err)
+		return
+	}
+	log.Printf("Uploaded drift report to %s (drifted=%t resources=%d)", remotePath, report.Drifted, report.ResourceCount)
+}
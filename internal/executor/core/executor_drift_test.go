@@ -0,0 +1,63 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/ilkerispir/terrakubed/internal/model"
+)
+
+func TestBuildDriftReport(t *testing.T) {
+	job := &model.TerraformJob{JobId: "42", WorkspaceId: "ws-1"}
+	checkedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	drift := func(addr string, actions ...tfjson.Action) *tfjson.ResourceChange {
+		return &tfjson.ResourceChange{Address: addr, Change: &tfjson.Change{Actions: actions}}
+	}
+
+	tests := []struct {
+		name      string
+		plan      *tfjson.Plan
+		wantDrift bool
+		wantCount int
+	}{
+		{
+			name:      "no plan",
+			plan:      nil,
+			wantDrift: false,
+			wantCount: 0,
+		},
+		{
+			name:      "in sync",
+			plan:      &tfjson.Plan{ResourceDrift: []*tfjson.ResourceChange{drift("aws_s3_bucket.logs", tfjson.ActionNoop)}},
+			wantDrift: false,
+			wantCount: 0,
+		},
+		{
+			name: "drifted",
+			plan: &tfjson.Plan{ResourceDrift: []*tfjson.ResourceChange{
+				drift("aws_s3_bucket.logs", tfjson.ActionUpdate),
+				drift("aws_instance.web", tfjson.ActionDelete),
+				drift("aws_s3_bucket.unchanged", tfjson.ActionNoop),
+			}},
+			wantDrift: true,
+			wantCount: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := buildDriftReport(job, tt.plan, checkedAt)
+			if report.Drifted != tt.wantDrift {
+				t.Errorf("Drifted = %t, want %t", report.Drifted, tt.wantDrift)
+			}
+			if report.ResourceCount != tt.wantCount || len(report.ResourceDrift) != tt.wantCount {
+				t.Errorf("ResourceCount = %d (%d entries), want %d", report.ResourceCount, len(report.ResourceDrift), tt.wantCount)
+			}
+			if report.JobId != "42" || report.WorkspaceId != "ws-1" || !report.CheckedAt.Equal(checkedAt) {
+				t.Errorf("unexpected report header: %+v", report)
+			}
+		})
+	}
+}
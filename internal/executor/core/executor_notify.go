@@ -1,15 +1,15 @@
 package core
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"log"
-	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/ilkerispir/terrakubed/internal/executor/cost"
+	"github.com/ilkerispir/terrakubed/internal/executor/notify"
+	"github.com/ilkerispir/terrakubed/internal/executor/terraform"
 	"github.com/ilkerispir/terrakubed/internal/model"
 )
 
@@ -19,6 +19,8 @@ type PlanSummary struct {
 	Change  int `json:"change"`
 	Destroy int `json:"destroy"`
 	Replace int `json:"replace"`
+	// Cost is the optional cost estimate shown in notifications; stored separately in cost.json.
+	Cost *cost.Estimate `json:"-"`
 }
 
 // parsePlanSummary extracts add/change/destroy counts from terraform plan text output.
@@ -41,41 +43,46 @@ func parsePlanSummary(output string) *PlanSummary {
 	return &PlanSummary{Add: add, Change: change, Destroy: destroy}
 }
 
-// slackEnabled returns (webhookURL, true) if Slack notifications are active for this job.
-// Requires both ENABLE_SLACK_NOTIFICATIONS=true and SLACK_WEBHOOK_URL to be set.
-func (p *JobProcessor) slackEnabled(job *model.TerraformJob) (string, bool) {
-	if job.EnvironmentVariables["ENABLE_SLACK_NOTIFICATIONS"] != "true" {
-		return "", false
+// planSummaryFromEvents builds a PlanSummary from the change_summary event of a
+// JSON-mode run. Returns nil when the run was not in JSON mode.
+func planSummaryFromEvents(events []terraform.UIEvent) *PlanSummary {
+	cs := terraform.LastChangeSummary(events)
+	if cs == nil {
+		return nil
 	}
+	return &PlanSummary{Add: cs.Add, Change: cs.Change, Destroy: cs.Remove}
+}
+
+// legacySlackURL returns the SLACK_WEBHOOK_URL workspace variable when the
+// env-var based Slack integration wants this event. Failures and drift only
+// need the webhook URL; everything else also needs ENABLE_SLACK_NOTIFICATIONS=true.
+func legacySlackURL(job *model.TerraformJob, event notify.Event) string {
 	url := job.EnvironmentVariables["SLACK_WEBHOOK_URL"]
 	if url == "" {
-		return "", false
+		return ""
 	}
-	return url, true
-}
-
-// slackSend builds and POSTs a Slack attachment message.
-// Pass a non-nil summary to include a Plan Summary block (plan notifications only).
-func (p *JobProcessor) slackSend(webhookURL, color, title string, job *model.TerraformJob, summary *PlanSummary) {
-	// UI URL priority:
-	// 1. TerrakubeUiURL / TERRAKUBE_UI_URL on the executor deployment (explicit)
-	// 2. Org-level TERRAKUBE_UI_URL env var (backward compat)
-	// 3. AzBuilderApiUrl — same host usually serves the UI; avoids needing a separate env var
-	uiURL := p.Config.TerrakubeUiURL
-	if uiURL == "" {
-		uiURL = job.EnvironmentVariables["TERRAKUBE_UI_URL"]
+	if event == notify.EventFailure || event == notify.EventDrift {
+		return url
 	}
-	if uiURL == "" {
-		uiURL = p.Config.AzBuilderApiUrl
+	if job.EnvironmentVariables["ENABLE_SLACK_NOTIFICATIONS"] != "true" {
+		return ""
 	}
+	return url
+}
 
-	wsName := job.EnvironmentVariables["workspaceName"]
-	if wsName == "" {
-		wsName = job.WorkspaceId
+// smtpConfig returns the executor's mail server settings for email destinations.
+func (p *JobProcessor) smtpConfig() notify.SMTPConfig {
+	return notify.SMTPConfig{
+		Host:     p.Config.SmtpHost,
+		Port:     p.Config.SmtpPort,
+		Username: p.Config.SmtpUsername,
+		Password: p.Config.SmtpPassword,
+		From:     p.Config.SmtpFrom,
 	}
+}
 
-	wsText := wsName
-
+// runURL returns the link to the run in the UI, or "" when no URL is known.
+func (p *JobProcessor) runURL(job *model.TerraformJob) string {
 	// Determine the run URL:
 	//   - Explicit UI URL → direct link: {uiURL}/organizations/{orgId}/workspaces/{wsId}/runs/{jobId}
 	//   - API URL fallback  → redirect: {apiURL}/app/{orgId}/{wsId}/runs/{jobId}
@@ -85,157 +92,149 @@ func (p *JobProcessor) slackSend(webhookURL, color, title string, job *model.Ter
 		explicitUI = job.EnvironmentVariables["TERRAKUBE_UI_URL"]
 	}
 
-	var runURL string
 	if explicitUI != "" {
-		base := strings.TrimRight(explicitUI, "/")
-		if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
-			base = "https://" + base
-		}
-		runURL = fmt.Sprintf("%s/organizations/%s/workspaces/%s/runs/%s",
-			base, job.OrganizationId, job.WorkspaceId, job.JobId)
-	} else if uiURL != "" {
-		// uiURL is AzBuilderApiUrl here — use the /app/ redirect endpoint
-		base := strings.TrimRight(uiURL, "/")
-		if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
-			base = "https://" + base
-		}
-		runURL = fmt.Sprintf("%s/app/%s/%s/runs/%s",
-			base, job.OrganizationId, job.WorkspaceId, job.JobId)
+		return fmt.Sprintf("%s/organizations/%s/workspaces/%s/runs/%s",
+			withScheme(explicitUI), job.OrganizationId, job.WorkspaceId, job.JobId)
 	}
-
-	if runURL != "" {
-		wsText = fmt.Sprintf("<%s|%s>", runURL, wsName)
+	// AzBuilderApiUrl usually serves the UI too — use the /app/ redirect endpoint
+	if p.Config.AzBuilderApiUrl != "" {
+		return fmt.Sprintf("%s/app/%s/%s/runs/%s",
+			withScheme(p.Config.AzBuilderApiUrl), job.OrganizationId, job.WorkspaceId, job.JobId)
 	}
+	return ""
+}
 
-	blocks := []map[string]interface{}{
-		{
-			"type": "section",
-			"text": map[string]string{"type": "mrkdwn", "text": title},
-		},
-		{
-			"type": "section",
-			"fields": []map[string]string{
-				{"type": "mrkdwn", "text": "*Workspace:*\n" + wsText},
-				{"type": "mrkdwn", "text": "*Repo:*\n" + job.Source},
-			},
-		},
+func withScheme(u string) string {
+	base := strings.TrimRight(u, "/")
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		base = "https://" + base
 	}
+	return base
+}
 
-	// Append plan summary block when present
+// newMessage fills in the job details shared by every notification.
+func (p *JobProcessor) newMessage(job *model.TerraformJob, event notify.Event, icon, title, color string, summary *PlanSummary) *notify.Message {
+	wsName := job.EnvironmentVariables["workspaceName"]
+	if wsName == "" {
+		wsName = job.WorkspaceId
+	}
+	msg := &notify.Message{
+		Event:            event,
+		Title:            title,
+		Icon:             icon,
+		Color:            color,
+		OrganizationId:   job.OrganizationId,
+		WorkspaceId:      job.WorkspaceId,
+		JobId:            job.JobId,
+		Workspace:        wsName,
+		RunURL:           p.runURL(job),
+		Source:           job.Source,
+		Branch:           job.Branch,
+		TerraformVersion: job.TerraformVersion,
+	}
 	if summary != nil {
-		summaryText := fmt.Sprintf(
-			"*Plan Summary*\n:seedling: Created: *%d*     :hammer_and_wrench: Updated: *%d*     :x: Deleted: *%d*",
-			summary.Add, summary.Change, summary.Destroy,
-		)
-		blocks = append(blocks, map[string]interface{}{
-			"type": "section",
-			"text": map[string]string{"type": "mrkdwn", "text": summaryText},
-		})
-	}
-
-	blocks = append(blocks,
-		map[string]interface{}{"type": "divider"},
-		map[string]interface{}{
-			"type": "context",
-			"elements": []map[string]string{
-				{"type": "mrkdwn", "text": "Branch: `" + job.Branch + "` | Version: `" + job.TerraformVersion + "`"},
-			},
-		},
-	)
-
-	msg := map[string]interface{}{
-		"attachments": []map[string]interface{}{
-			{
-				"color":  color,
-				"blocks": blocks,
-			},
-		},
-	}
-
-	body, err := json.Marshal(msg)
-	if err != nil {
-		log.Printf("[Slack] marshal error: %v", err)
-		return
+		msg.Summary = &notify.Summary{
+			Add:     summary.Add,
+			Change:  summary.Change,
+			Destroy: summary.Destroy,
+			Cost:    summary.Cost,
+		}
+	}
+	return msg
+}
+
+// notify delivers msg to the legacy env-var Slack webhook and to every
+// destination configured for the workspace that subscribes to the event.
+// Delivery errors are logged and never fail the job.
+func (p *JobProcessor) notify(job *model.TerraformJob, msg *notify.Message) {
+	if url := legacySlackURL(job, msg.Event); url != "" {
+		if err := (&notify.SlackNotifier{WebhookURL: url}).Notify(msg); err != nil {
+			log.Printf("[Slack] send %q failed: %v", msg.Title, err)
+		} else {
+			log.Printf("[Slack] sent %q", msg.Title)
+		}
 	}
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewReader(body))
+
+	destinations, err := p.Status.GetNotifications(job)
 	if err != nil {
-		log.Printf("[Slack] send error: %v", err)
+		log.Printf("Failed to load notification destinations: %v", err)
 		return
 	}
-	defer resp.Body.Close()
-	log.Printf("[Slack] sent %q (HTTP %d)", title, resp.StatusCode)
+	for _, dest := range destinations {
+		if !dest.Subscribed(string(msg.Event)) {
+			continue
+		}
+		notifier, err := notify.New(dest, p.smtpConfig())
+		if err != nil {
+			log.Printf("[Notify] %v", err)
+			continue
+		}
+		if err := notifier.Notify(msg); err != nil {
+			log.Printf("[Notify] %s %q: send %q failed: %v", dest.DestinationType, dest.Name, msg.Title, err)
+			continue
+		}
+		log.Printf("[Notify] %s %q: sent %q", dest.DestinationType, dest.Name, msg.Title)
+	}
 }
 
 // --- Public notification helpers ---
 
-// notifySlackApproved fires at the start of terraformApply / terraformDestroy,
+// notifyApproved fires at the start of terraformApply / terraformDestroy,
 // signalling that the approval gate was passed and the operation is beginning.
-func (p *JobProcessor) notifySlackApproved(job *model.TerraformJob) {
-	url, ok := p.slackEnabled(job)
-	if !ok {
-		return
-	}
-	title := ":white_check_mark: *Approved — Applying Changes*"
+func (p *JobProcessor) notifyApproved(job *model.TerraformJob) {
+	title := "Approved — Applying Changes"
 	if job.Type == "terraformDestroy" {
-		title = ":white_check_mark: *Approved — Destroying Resources*"
+		title = "Approved — Destroying Resources"
 	}
-	p.slackSend(url, "#1463fb", title, job, nil)
+	p.notify(job, p.newMessage(job, notify.EventApproved, ":white_check_mark:", title, "#1463fb", nil))
 }
 
-// notifySlackPlanPending fires when a plan detects changes (exit code 2)
+// notifyPlanPending fires when a plan detects changes (exit code 2)
 // and the job moves to the approval-pending state.
-func (p *JobProcessor) notifySlackPlanPending(job *model.TerraformJob, summary *PlanSummary) {
-	url, ok := p.slackEnabled(job)
-	if !ok {
-		return
-	}
-	title := ":hourglass_flowing_sand: *Plan Ready — Awaiting Approval*"
+func (p *JobProcessor) notifyPlanPending(job *model.TerraformJob, summary *PlanSummary) {
+	title := "Plan Ready — Awaiting Approval"
 	if job.Type == "terraformPlanDestroy" {
-		title = ":hourglass_flowing_sand: *Destroy Plan Ready — Awaiting Approval*"
+		title = "Destroy Plan Ready — Awaiting Approval"
 	}
-	p.slackSend(url, "#eda509", title, job, summary)
+	p.notify(job, p.newMessage(job, notify.EventPlanPending, ":hourglass_flowing_sand:", title, "#eda509", summary))
 }
 
-// notifySlackPlanNoChanges fires when a plan detects no changes (exit code 0).
-func (p *JobProcessor) notifySlackPlanNoChanges(job *model.TerraformJob) {
-	url, ok := p.slackEnabled(job)
-	if !ok {
-		return
-	}
-	p.slackSend(url, "#1463fb", ":zzz: *No Changes Detected*", job, nil)
+// notifyPlanNoChanges fires when a plan detects no changes (exit code 0).
+func (p *JobProcessor) notifyPlanNoChanges(job *model.TerraformJob) {
+	p.notify(job, p.newMessage(job, notify.EventNoChanges, ":zzz:", "No Changes Detected", "#1463fb", nil))
 }
 
-// notifySlackSuccess fires when terraformApply or terraformDestroy completes successfully.
-func (p *JobProcessor) notifySlackSuccess(job *model.TerraformJob) {
-	url, ok := p.slackEnabled(job)
-	if !ok {
-		return
-	}
-	title := ":rocket: *Terraform Apply Completed*"
+// notifySuccess fires when terraformApply or terraformDestroy completes successfully.
+func (p *JobProcessor) notifySuccess(job *model.TerraformJob) {
+	icon, title := ":rocket:", "Terraform Apply Completed"
 	if job.Type == "terraformDestroy" {
-		title = ":white_check_mark: *Terraform Destroy Completed*"
+		icon, title = ":white_check_mark:", "Terraform Destroy Completed"
 	}
-	p.slackSend(url, "#36a64f", title, job, nil)
+	p.notify(job, p.newMessage(job, notify.EventSuccess, icon, title, "#36a64f", nil))
 }
 
-// notifySlackOnFailure fires when any terraform step fails.
-// Activated by SLACK_WEBHOOK_URL alone (no ENABLE_SLACK_NOTIFICATIONS guard)
-// so failures are always reported if a webhook is configured.
-func (p *JobProcessor) notifySlackOnFailure(job *model.TerraformJob) {
-	webhookURL := job.EnvironmentVariables["SLACK_WEBHOOK_URL"]
-	if webhookURL == "" {
-		return
-	}
-	var title string
+// notifyDrift fires when a terraformDrift job finds resources that changed
+// outside Terraform. Drift checks usually run on a schedule and nobody is
+// watching the run.
+func (p *JobProcessor) notifyDrift(job *model.TerraformJob, resourceCount int) {
+	title := fmt.Sprintf("Drift Detected — %d resource(s) changed outside Terraform", resourceCount)
+	p.notify(job, p.newMessage(job, notify.EventDrift, ":warning:", title, "#eda509", nil))
+}
+
+// notifyOnFailure fires when any terraform step fails.
+func (p *JobProcessor) notifyOnFailure(job *model.TerraformJob) {
+	var icon, title string
 	switch job.Type {
 	case "terraformApply":
-		title = ":fire: *Terraform Apply Failed*"
+		icon, title = ":fire:", "Terraform Apply Failed"
 	case "terraformDestroy":
-		title = ":fire: *Terraform Destroy Failed*"
+		icon, title = ":fire:", "Terraform Destroy Failed"
 	case "terraformPlanDestroy":
-		title = ":x: *Terraform Plan Destroy Failed*"
+		icon, title = ":x:", "Terraform Plan Destroy Failed"
+	case "terraformDrift":
+		icon, title = ":x:", "Drift Check Failed"
 	default:
-		title = ":x: *Terraform Plan Failed*"
+		icon, title = ":x:", "Terraform Plan Failed"
 	}
-	p.slackSend(webhookURL, "#cc0000", title, job, nil)
+	p.notify(job, p.newMessage(job, notify.EventFailure, icon, title, "#cc0000", nil))
 }
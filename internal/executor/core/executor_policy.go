@@ -0,0 +1,71 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/ilkerispir/terrakubed/internal/executor/logs"
+	"github.com/ilkerispir/terrakubed/internal/executor/policy"
+	"github.com/ilkerispir/terrakubed/internal/model"
+)
+
+// checkPolicies evaluates the plan against the organization's policy sets,
+// writes the report to the job log and stores the result at
+// tfplan/{jobId}/policy.json. Returns nil when there is nothing to enforce.
+//
+// The check fails closed: when the policy sets cannot be loaded or evaluated
+// the result is a hard-mandatory failure.
+func (p *JobProcessor) checkPolicies(job *model.TerraformJob, plan *tfjson.Plan, streamer logs.LogStreamer) *policy.Result {
+	if plan == nil {
+		return nil
+	}
+	result, err := p.evaluatePolicies(job, plan)
+	if err != nil {
+		log.Printf("Policy check failed: %v", err)
+		result = policy.CheckFailed(err)
+	}
+	if result == nil {
+		return nil
+	}
+
+	if streamer != nil {
+		streamer.Write([]byte(result.Report()))
+	}
+	p.uploadPolicyResult(job, result)
+	return result
+}
+
+// evaluatePolicies returns nil when the organization has no policy sets.
+func (p *JobProcessor) evaluatePolicies(job *model.TerraformJob, plan *tfjson.Plan) (*policy.Result, error) {
+	sets, err := p.Status.GetPolicySets(job)
+	if err != nil {
+		return nil, err
+	}
+	if len(sets) == 0 {
+		return nil, nil
+	}
+	planJSON, err := json.Marshal(plan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	return policy.Evaluate(context.Background(), sets, planJSON)
+}
+
+func (p *JobProcessor) uploadPolicyResult(job *model.TerraformJob, result *policy.Result) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Failed to marshal policy result: %v", err)
+		return
+	}
+	remotePath := fmt.Sprintf("tfplan/%s/policy.json", job.JobId)
+	if err := p.Storage.UploadFile(remotePath, bytes.NewReader(data)); err != nil {
+		log.Printf("Failed to upload policy result: %v", err)
+		return
+	}
+	log.Printf("Uploaded policy result to %s (passed=%t hard=%t soft=%t)",
+		remotePath, result.Passed, result.HardFailed, result.SoftFailed)
+}
@@ -131,3 +131,20 @@ func assertContains(t *testing.T, s, substr string) {
 		t.Errorf("expected output to contain %q\ngot:\n%s", substr, s)
 	}
 }
+
+// --- providerInstallation ---
+
+func TestProviderInstallation(t *testing.T) {
+	if got := providerInstallation("", ""); got != "" {
+		t.Errorf("providerInstallation without mirrors = %q, want empty", got)
+	}
+
+	got := providerInstallation("/mnt/providers", "https://mirror.example.com/providers")
+	assertContains(t, got, "provider_installation {")
+	assertContains(t, got, `path = "/mnt/providers"`)
+	assertContains(t, got, `url = "https://mirror.example.com/providers/"`)
+	assertContains(t, got, "direct {}")
+	if strings.Index(got, "filesystem_mirror") > strings.Index(got, "network_mirror") {
+		t.Errorf("filesystem mirror should be listed first:\n%s", got)
+	}
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -21,14 +22,8 @@ type planContext struct {
 	Summary         PlanSummary               `json:"summary"`
 }
 
-func (p *JobProcessor) uploadPlanJSON(job *model.TerraformJob, workingDir string, execPath string) {
-	tfExecutor := terraform.NewExecutor(job, workingDir, nil, execPath)
-	plan, err := tfExecutor.ShowPlanJSON()
-	if err != nil {
-		log.Printf("Failed to parse plan JSON (skipping context upload): %v", err)
-		return
-	}
-
+// summarizePlan counts resource changes by action.
+func summarizePlan(plan *tfjson.Plan) PlanSummary {
 	var summary PlanSummary
 	for _, rc := range plan.ResourceChanges {
 		if rc.Change == nil {
@@ -46,7 +41,32 @@ func (p *JobProcessor) uploadPlanJSON(job *model.TerraformJob, workingDir string
 			summary.Replace++
 		}
 	}
+	return summary
+}
+
+// reportPlanSummary stores the plan counts on the job row. planChanges follows
+// the plan exit code, so output-only changes still count as changes.
+func (p *JobProcessor) reportPlanSummary(job *model.TerraformJob, plan *tfjson.Plan, planChanges bool) {
+	if plan == nil {
+		return
+	}
+	s := summarizePlan(plan)
+	if err := p.Status.UpdatePlanSummary(job, planChanges, s.Add, s.Change, s.Destroy, s.Replace); err != nil {
+		log.Printf("Failed to update job plan summary: %v", err)
+	}
+}
+
+// uploadPlanJSON stores the plan context for the UI and returns the parsed
+// plan (nil if it could not be read) for further processing.
+func (p *JobProcessor) uploadPlanJSON(job *model.TerraformJob, workingDir string, execPath string) *tfjson.Plan {
+	tfExecutor := terraform.NewExecutor(job, workingDir, nil, execPath)
+	plan, err := tfExecutor.ShowPlanJSON()
+	if err != nil {
+		log.Printf("Failed to parse plan JSON (skipping context upload): %v", err)
+		return nil
+	}
 
+	summary := summarizePlan(plan)
 	ctx := planContext{
 		ResourceChanges: plan.ResourceChanges,
 		OutputChanges:   plan.OutputChanges,
@@ -56,16 +76,31 @@ func (p *JobProcessor) uploadPlanJSON(job *model.TerraformJob, workingDir string
 	data, err := json.Marshal(ctx)
 	if err != nil {
 		log.Printf("Failed to marshal plan context JSON: %v", err)
-		return
+		return plan
 	}
 
 	remotePath := fmt.Sprintf("tfplan/%s/context.json", job.JobId)
 	if err := p.Storage.UploadFile(remotePath, strings.NewReader(string(data))); err != nil {
 		log.Printf("Failed to upload plan context JSON: %v", err)
-		return
+		return plan
 	}
 	log.Printf("Uploaded plan context JSON to %s (add=%d change=%d destroy=%d replace=%d)",
 		remotePath, summary.Add, summary.Change, summary.Destroy, summary.Replace)
+	return plan
+}
+
+// uploadJSONLog stores the machine-readable UI events next to the text log
+// (tfoutput/{orgId}/{jobId}/{stepId}.tfoutput) as newline-delimited JSON.
+func (p *JobProcessor) uploadJSONLog(job *model.TerraformJob, events []terraform.UIEvent) {
+	if len(events) == 0 {
+		return
+	}
+	remotePath := fmt.Sprintf("tfoutput/%s/%s/%s.events.jsonl", job.OrganizationId, job.JobId, job.StepId)
+	if err := p.Storage.UploadFile(remotePath, bytes.NewReader(terraform.MarshalEventsNDJSON(events))); err != nil {
+		log.Printf("Failed to upload JSON log events: %v", err)
+		return
+	}
+	log.Printf("Uploaded %d JSON log events to %s", len(events), remotePath)
 }
 
 func (p *JobProcessor) uploadStateAndOutput(job *model.TerraformJob, workingDir string) {
@@ -120,6 +155,17 @@ func (p *JobProcessor) uploadStateAndOutput(job *model.TerraformJob, workingDir
 			}
 		}
 
+		// Record touched addresses. Destroy is skipped: its state is empty
+		// afterwards and there is no saved plan to read them from.
+		if job.Type == "terraformApply" {
+			addresses, err := applyAddresses(tfExecutor, workingDir)
+			if err != nil {
+				log.Printf("Failed to resolve applied addresses: %v", err)
+			} else {
+				p.recordAddresses(job, addresses)
+			}
+		}
+
 		// Get and save terraform output
 		outputJson, err := tfExecutor.Output()
 		if err != nil {
@@ -0,0 +1,28 @@
+package core
+
+import (
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestSummarizePlan(t *testing.T) {
+	rc := func(actions ...tfjson.Action) *tfjson.ResourceChange {
+		return &tfjson.ResourceChange{Change: &tfjson.Change{Actions: actions}}
+	}
+	plan := &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{
+		rc(tfjson.ActionCreate),
+		rc(tfjson.ActionCreate),
+		rc(tfjson.ActionUpdate),
+		rc(tfjson.ActionDelete),
+		rc(tfjson.ActionDelete, tfjson.ActionCreate),
+		rc(tfjson.ActionCreate, tfjson.ActionDelete),
+		rc(tfjson.ActionNoop),
+		{Address: "no change block"},
+	}}
+
+	want := PlanSummary{Add: 2, Change: 1, Destroy: 1, Replace: 2}
+	if got := summarizePlan(plan); got != want {
+		t.Errorf("summarizePlan() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,165 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ilkerispir/terrakubed/internal/executor/policy"
+	"github.com/ilkerispir/terrakubed/internal/model"
+	"github.com/ilkerispir/terrakubed/internal/vcs"
+)
+
+// pullRequest returns the pull request of a speculative plan job, or nil for
+// regular jobs. Speculative jobs are plan-only, so other step types skip the lookup.
+func (p *JobProcessor) pullRequest(job *model.TerraformJob) *model.PullRequest {
+	if job.Type != "terraformPlan" {
+		return nil
+	}
+	pr, err := p.Status.GetPullRequest(job)
+	if err != nil {
+		log.Printf("Failed to look up pull request for job %s: %v", job.JobId, err)
+		return nil
+	}
+	return pr
+}
+
+// refreshAccessToken replaces the token dispatched with the job by a current
+// one, since OAuth tokens may have expired while the job was queued. The
+// dispatched token is kept when the API cannot provide one.
+func (p *JobProcessor) refreshAccessToken(job *model.TerraformJob) {
+	if job.AccessToken == "" || job.Branch == "remote-content" {
+		return
+	}
+	token, err := p.Status.GetVcsToken(job)
+	if err != nil {
+		log.Printf("Failed to refresh VCS token for job %s, using the dispatched token: %v", job.JobId, err)
+		return
+	}
+	if token != "" {
+		job.AccessToken = token
+	}
+}
+
+func vcsClient(job *model.TerraformJob, pr *model.PullRequest) (vcs.Client, vcs.Repo, error) {
+	if job.AccessToken == "" {
+		return nil, vcs.Repo{}, fmt.Errorf("no VCS access token")
+	}
+	repo, err := vcs.ParseRepo(job.VcsType, job.Source)
+	if err != nil {
+		return nil, vcs.Repo{}, err
+	}
+	client, err := vcs.New(job.VcsType, pr.VcsApiUrl, job.AccessToken, repo)
+	return client, repo, err
+}
+
+// statusContext names the commit status of a workspace, e.g. "terrakube/network".
+func statusContext(job *model.TerraformJob) string {
+	ws := job.EnvironmentVariables["workspaceName"]
+	if ws == "" {
+		ws = job.WorkspaceId
+	}
+	return "terrakube/" + ws
+}
+
+// setPullRequestStatus sets the commit status of the pull request head commit.
+func (p *JobProcessor) setPullRequestStatus(job *model.TerraformJob, pr *model.PullRequest, state vcs.State, description string) {
+	if pr == nil || job.CommitId == "" {
+		return
+	}
+	client, repo, err := vcsClient(job, pr)
+	if err != nil {
+		log.Printf("[VCS] cannot report commit status: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err = client.SetCommitStatus(ctx, repo, job.CommitId, vcs.CommitStatus{
+		State:       state,
+		Context:     statusContext(job),
+		Description: description,
+		TargetURL:   p.runURL(job),
+	})
+	if err != nil {
+		log.Printf("[VCS] set commit status %s on %s failed: %v", state, job.CommitId, err)
+	}
+}
+
+// finishPullRequest reports the result of a speculative plan as the final
+// commit status and a pull request comment.
+func (p *JobProcessor) finishPullRequest(job *model.TerraformJob, pr *model.PullRequest, summary *PlanSummary, policyResult *policy.Result, runErr error) {
+	if pr == nil {
+		return
+	}
+	state, description := pullRequestResult(summary, policyResult, runErr)
+	p.setPullRequestStatus(job, pr, state, description)
+
+	client, repo, err := vcsClient(job, pr)
+	if err != nil {
+		log.Printf("[VCS] cannot comment on pull request: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	body := planComment(job, description, summary, policyResult, p.runURL(job))
+	if err := client.CommentPullRequest(ctx, repo, pr.Number, body); err != nil {
+		log.Printf("[VCS] comment on pull request #%d failed: %v", pr.Number, err)
+	}
+}
+
+// pullRequestResult maps a plan outcome to a commit status.
+func pullRequestResult(summary *PlanSummary, policyResult *policy.Result, runErr error) (vcs.State, string) {
+	switch {
+	case runErr != nil:
+		return vcs.StateFailure, "Terraform plan failed"
+	case policyResult != nil && policyResult.HardFailed:
+		return vcs.StateFailure, "Hard-mandatory policy check failed"
+	case policyResult != nil && policyResult.SoftFailed:
+		return vcs.StateFailure, "Soft-mandatory policy check failed"
+	case summary == nil || summary.Add+summary.Change+summary.Destroy == 0:
+		return vcs.StateSuccess, "No changes"
+	default:
+		return vcs.StateSuccess, fmt.Sprintf("Plan: %d to add, %d to change, %d to destroy",
+			summary.Add, summary.Change, summary.Destroy)
+	}
+}
+
+// planComment renders the Markdown comment posted on the pull request.
+func planComment(job *model.TerraformJob, description string, summary *PlanSummary, policyResult *policy.Result, runURL string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Terraform plan for `%s`\n\n", strings.TrimPrefix(statusContext(job), "terrakube/"))
+	fmt.Fprintf(&b, "**%s**\n", description)
+	if summary != nil && summary.Cost != nil {
+		c := summary.Cost
+		fmt.Fprintf(&b, "\nEst. monthly cost: **%+.2f %s** (%.2f → %.2f)\n", c.DeltaMonthly, c.Currency, c.PriorMonthly, c.ProposedMonthly)
+	}
+	if policyResult != nil {
+		b.WriteString("\n| Policy | Enforcement | Result |\n|---|---|---|\n")
+		for _, r := range policyResult.Results {
+			result := "✅ passed"
+			if !r.Passed {
+				reasons := append([]string{}, r.Violations...)
+				if r.Error != "" {
+					reasons = append(reasons, r.Error)
+				}
+				result = "❌ " + strings.Join(reasons, "; ")
+			}
+			fmt.Fprintf(&b, "| %s / %s | %s | %s |\n", r.PolicySet, r.Policy, r.EnforcementLevel, result)
+		}
+	}
+	b.WriteString("\n")
+	if job.CommitId != "" {
+		commit := job.CommitId
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		fmt.Fprintf(&b, "Commit `%s`", commit)
+	}
+	if runURL != "" {
+		fmt.Fprintf(&b, " · [View run](%s)", runURL)
+	}
+	b.WriteString("\n\n_Speculative plan — this run can never be applied._\n")
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ilkerispir/terrakubed/internal/executor/policy"
+	"github.com/ilkerispir/terrakubed/internal/model"
+	"github.com/ilkerispir/terrakubed/internal/vcs"
+)
+
+func TestPullRequestResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary *PlanSummary
+		policy  *policy.Result
+		err     error
+		state   vcs.State
+		desc    string
+	}{
+		{"run error", nil, nil, errors.New("boom"), vcs.StateFailure, "Terraform plan failed"},
+		{"hard failure", &PlanSummary{Add: 1}, &policy.Result{HardFailed: true}, nil, vcs.StateFailure, "Hard-mandatory policy check failed"},
+		{"soft failure", &PlanSummary{Add: 1}, &policy.Result{SoftFailed: true}, nil, vcs.StateFailure, "Soft-mandatory policy check failed"},
+		{"no changes", nil, nil, nil, vcs.StateSuccess, "No changes"},
+		{"changes", &PlanSummary{Add: 2, Change: 1}, &policy.Result{Passed: true}, nil, vcs.StateSuccess, "Plan: 2 to add, 1 to change, 0 to destroy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, desc := pullRequestResult(tt.summary, tt.policy, tt.err)
+			if state != tt.state || desc != tt.desc {
+				t.Errorf("got (%s, %q), want (%s, %q)", state, desc, tt.state, tt.desc)
+			}
+		})
+	}
+}
+
+func TestPlanComment(t *testing.T) {
+	job := &model.TerraformJob{
+		WorkspaceId:          "ws-1",
+		CommitId:             "0123456789abcdef",
+		EnvironmentVariables: map[string]string{"workspaceName": "network"},
+	}
+	result := &policy.Result{Results: []policy.PolicyResult{
+		{PolicySet: "base", Policy: "tags", EnforcementLevel: "advisory", Passed: true},
+		{PolicySet: "base", Policy: "size", EnforcementLevel: "soft-mandatory", Violations: []string{"too big"}},
+	}}
+	body := planComment(job, "Plan: 1 to add, 0 to change, 0 to destroy", &PlanSummary{Add: 1}, result, "https://ui/run")
+
+	for _, want := range []string{
+		"### Terraform plan for `network`",
+		"**Plan: 1 to add, 0 to change, 0 to destroy**",
+		"| base / tags | advisory | ✅ passed |",
+		"| base / size | soft-mandatory | ❌ too big |",
+		"Commit `0123456`",
+		"[View run](https://ui/run)",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("comment missing %q:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package cost produces rough monthly cost estimates for Terraform plans.
+package cost
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// Estimator prices a plan. Implementations must not need network access
+// unless they say so; the default one reads a local pricing file.
+type Estimator interface {
+	Estimate(plan *tfjson.Plan) (*Estimate, error)
+}
+
+// Estimate is the JSON structure stored at tfplan/{jobId}/cost.json.
+type Estimate struct {
+	Currency        string         `json:"currency"`
+	PriorMonthly    float64        `json:"priorMonthly"`
+	ProposedMonthly float64        `json:"proposedMonthly"`
+	DeltaMonthly    float64        `json:"deltaMonthly"`
+	Resources       []ResourceCost `json:"resources"`
+	// UnpricedTypes lists resource types present in the plan but missing from the pricing file.
+	UnpricedTypes []string `json:"unpricedTypes,omitempty"`
+}
+
+// ResourceCost is the cost change of one resource instance.
+type ResourceCost struct {
+	Address         string  `json:"address"`
+	Type            string  `json:"type"`
+	PriorMonthly    float64 `json:"priorMonthly"`
+	ProposedMonthly float64 `json:"proposedMonthly"`
+	DeltaMonthly    float64 `json:"deltaMonthly"`
+}
+
+// Pricing is the pricing file format:
+//
+//	{
+//	  "currency": "USD",
+//	  "resources": {
+//	    "aws_instance": {
+//	      "attribute": "instance_type",
+//	      "prices": {"t3.micro": 7.59, "m5.large": 70.08},
+//	      "monthly": 10
+//	    },
+//	    "aws_nat_gateway": {"monthly": 32.85}
+//	  }
+//	}
+//
+// When Attribute is set, the resource's value for it selects the price from
+// Prices; Monthly is the fallback (and the only price when Attribute is empty).
+type Pricing struct {
+	Currency  string                   `json:"currency"`
+	Resources map[string]ResourcePrice `json:"resources"`
+}
+
+// ResourcePrice prices one resource type.
+type ResourcePrice struct {
+	Attribute string             `json:"attribute,omitempty"`
+	Prices    map[string]float64 `json:"prices,omitempty"`
+	Monthly   float64            `json:"monthly"`
+}
+
+// FileEstimator prices plans from a Pricing table.
+type FileEstimator struct {
+	Pricing Pricing
+}
+
+// NewFileEstimator loads a pricing file.
+func NewFileEstimator(path string) (*FileEstimator, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pricing file: %w", err)
+	}
+	defer f.Close()
+	return LoadFileEstimator(f)
+}
+
+// LoadFileEstimator reads pricing JSON from r.
+func LoadFileEstimator(r io.Reader) (*FileEstimator, error) {
+	var p Pricing
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, fmt.Errorf("invalid pricing file: %w", err)
+	}
+	if p.Currency == "" {
+		p.Currency = "USD"
+	}
+	return &FileEstimator{Pricing: p}, nil
+}
+
+// Estimate prices the before and after value of every managed resource.
+// Unchanged resources count towards the totals but are not listed.
+func (e *FileEstimator) Estimate(plan *tfjson.Plan) (*Estimate, error) {
+	est := &Estimate{Currency: e.Pricing.Currency, Resources: []ResourceCost{}}
+	unpriced := make(map[string]bool)
+
+	for _, rc := range plan.ResourceChanges {
+		if rc == nil || rc.Change == nil || rc.Mode != tfjson.ManagedResourceMode {
+			continue
+		}
+		price, ok := e.Pricing.Resources[rc.Type]
+		if !ok {
+			if !unpriced[rc.Type] {
+				unpriced[rc.Type] = true
+				est.UnpricedTypes = append(est.UnpricedTypes, rc.Type)
+			}
+			continue
+		}
+
+		var prior, proposed float64
+		if rc.Change.Before != nil {
+			prior = price.monthly(rc.Change.Before)
+		}
+		if rc.Change.After != nil && !rc.Change.Actions.Delete() {
+			proposed = price.monthly(rc.Change.After)
+		}
+		est.PriorMonthly += prior
+		est.ProposedMonthly += proposed
+
+		if rc.Change.Actions.NoOp() || prior == proposed {
+			continue
+		}
+		est.Resources = append(est.Resources, ResourceCost{
+			Address:         rc.Address,
+			Type:            rc.Type,
+			PriorMonthly:    round(prior),
+			ProposedMonthly: round(proposed),
+			DeltaMonthly:    round(proposed - prior),
+		})
+	}
+
+	est.PriorMonthly = round(est.PriorMonthly)
+	est.ProposedMonthly = round(est.ProposedMonthly)
+	est.DeltaMonthly = round(est.ProposedMonthly - est.PriorMonthly)
+	return est, nil
+}
+
+func (p ResourcePrice) monthly(values interface{}) float64 {
+	if p.Attribute != "" {
+		if m, ok := values.(map[string]interface{}); ok {
+			if v, ok := m[p.Attribute].(string); ok {
+				if price, ok := p.Prices[v]; ok {
+					return price
+				}
+			}
+		}
+	}
+	return p.Monthly
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,80 @@
+package cost
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+const pricingJSON = `{
+  "currency": "EUR",
+  "resources": {
+    "aws_instance": {"attribute": "instance_type", "prices": {"t3.micro": 7.5, "m5.large": 70}, "monthly": 10},
+    "aws_nat_gateway": {"monthly": 32.85}
+  }
+}`
+
+func rc(addr, typ string, actions tfjson.Actions, before, after interface{}) *tfjson.ResourceChange {
+	return &tfjson.ResourceChange{
+		Address: addr,
+		Type:    typ,
+		Mode:    tfjson.ManagedResourceMode,
+		Change:  &tfjson.Change{Actions: actions, Before: before, After: after},
+	}
+}
+
+func TestFileEstimator(t *testing.T) {
+	e, err := LoadFileEstimator(strings.NewReader(pricingJSON))
+	if err != nil {
+		t.Fatalf("LoadFileEstimator() error: %v", err)
+	}
+
+	plan := &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{
+		// resize: 7.5 → 70
+		rc("aws_instance.web", "aws_instance", tfjson.Actions{tfjson.ActionUpdate},
+			map[string]interface{}{"instance_type": "t3.micro"}, map[string]interface{}{"instance_type": "m5.large"}),
+		// new NAT gateway: +32.85
+		rc("aws_nat_gateway.main", "aws_nat_gateway", tfjson.Actions{tfjson.ActionCreate}, nil, map[string]interface{}{}),
+		// unknown instance type falls back to monthly, unchanged
+		rc("aws_instance.legacy", "aws_instance", tfjson.Actions{tfjson.ActionNoop},
+			map[string]interface{}{"instance_type": "x1.huge"}, map[string]interface{}{"instance_type": "x1.huge"}),
+		// removed instance: -7.5
+		rc("aws_instance.old", "aws_instance", tfjson.Actions{tfjson.ActionDelete},
+			map[string]interface{}{"instance_type": "t3.micro"}, nil),
+		rc("aws_s3_bucket.logs", "aws_s3_bucket", tfjson.Actions{tfjson.ActionCreate}, nil, map[string]interface{}{}),
+		{Address: "data.aws_ami.ubuntu", Type: "aws_ami", Mode: tfjson.DataResourceMode, Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionRead}}},
+	}}
+
+	est, err := e.Estimate(plan)
+	if err != nil {
+		t.Fatalf("Estimate() error: %v", err)
+	}
+
+	if est.Currency != "EUR" {
+		t.Errorf("Currency = %q", est.Currency)
+	}
+	if est.PriorMonthly != 25 || est.ProposedMonthly != 112.85 || est.DeltaMonthly != 87.85 {
+		t.Errorf("totals = prior %.2f proposed %.2f delta %.2f", est.PriorMonthly, est.ProposedMonthly, est.DeltaMonthly)
+	}
+	if len(est.Resources) != 3 {
+		t.Errorf("got %d changed resources, want 3: %+v", len(est.Resources), est.Resources)
+	}
+	if !reflect.DeepEqual(est.UnpricedTypes, []string{"aws_s3_bucket"}) {
+		t.Errorf("UnpricedTypes = %v", est.UnpricedTypes)
+	}
+}
+
+func TestLoadFileEstimatorDefaultsCurrency(t *testing.T) {
+	e, err := LoadFileEstimator(strings.NewReader(`{"resources": {}}`))
+	if err != nil {
+		t.Fatalf("LoadFileEstimator() error: %v", err)
+	}
+	if e.Pricing.Currency != "USD" {
+		t.Errorf("Currency = %q, want USD", e.Pricing.Currency)
+	}
+	if _, err := LoadFileEstimator(strings.NewReader(`not json`)); err == nil {
+		t.Error("expected error for invalid pricing file")
+	}
+}
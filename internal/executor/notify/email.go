@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// EmailNotifier sends a plain-text email through the configured SMTP server.
+type EmailNotifier struct {
+	SMTP SMTPConfig
+	To   []string
+}
+
+func (e *EmailNotifier) Notify(msg *Message) error {
+	port := e.SMTP.Port
+	if port == "" {
+		port = "587"
+	}
+	var auth smtp.Auth
+	if e.SMTP.Username != "" {
+		auth = smtp.PlainAuth("", e.SMTP.Username, e.SMTP.Password, e.SMTP.Host)
+	}
+	return smtp.SendMail(net.JoinHostPort(e.SMTP.Host, port), auth, e.SMTP.From, e.To, buildEmail(e.SMTP.From, e.To, msg))
+}
+
+func buildEmail(from string, to []string, msg *Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	// The workspace name is user input: encoding keeps line breaks in it from
+	// starting new headers.
+	subject := fmt.Sprintf("[Terrakube] %s — %s", msg.Title, msg.Workspace)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+
+	fmt.Fprintf(&b, "%s\r\n\r\n", msg.Title)
+	fmt.Fprintf(&b, "Workspace: %s\r\n", msg.Workspace)
+	fmt.Fprintf(&b, "Repo:      %s\r\n", msg.Source)
+	fmt.Fprintf(&b, "Branch:    %s\r\n", msg.Branch)
+	fmt.Fprintf(&b, "Version:   %s\r\n", msg.TerraformVersion)
+	if s := msg.Summary; s != nil {
+		fmt.Fprintf(&b, "\r\nPlan: %d to add, %d to change, %d to destroy.\r\n", s.Add, s.Change, s.Destroy)
+		if s.Cost != nil {
+			fmt.Fprintf(&b, "%s\r\n", costLine(s.Cost))
+		}
+	}
+	if msg.RunURL != "" {
+		fmt.Fprintf(&b, "\r\n%s\r\n", msg.RunURL)
+	}
+	return []byte(b.String())
+}
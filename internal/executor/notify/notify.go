@@ -0,0 +1,122 @@
+// Package notify delivers job lifecycle notifications to Slack, Microsoft
+// Teams, generic HMAC-signed webhooks and email.
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ilkerispir/terrakubed/internal/executor/cost"
+	"github.com/ilkerispir/terrakubed/internal/model"
+)
+
+// Event is a job lifecycle event a destination can subscribe to.
+type Event string
+
+const (
+	EventApproved    Event = "approved"
+	EventPlanPending Event = "planPending"
+	EventNoChanges   Event = "noChanges"
+	EventSuccess     Event = "success"
+	EventFailure     Event = "failure"
+	EventDrift       Event = "drift"
+)
+
+// Message is the channel-independent content of a notification.
+type Message struct {
+	Event Event
+	// Title is plain text; Icon is a Slack emoji short code shown before it.
+	Title string
+	Icon  string
+	// Color is a hex color such as "#36a64f".
+	Color string
+
+	OrganizationId   string
+	WorkspaceId      string
+	JobId            string
+	Workspace        string
+	RunURL           string
+	Source           string
+	Branch           string
+	TerraformVersion string
+
+	// Summary is set for plan notifications only.
+	Summary *Summary
+}
+
+// Summary holds the plan change counts and the optional cost estimate.
+type Summary struct {
+	Add     int
+	Change  int
+	Destroy int
+	Cost    *cost.Estimate
+}
+
+// Notifier sends a message to one destination.
+type Notifier interface {
+	Notify(msg *Message) error
+}
+
+// SMTPConfig is the executor-wide mail server used by email destinations.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// New builds the Notifier for a configured destination.
+func New(n model.Notification, smtp SMTPConfig) (Notifier, error) {
+	switch n.DestinationType {
+	case model.NotificationSlack:
+		return &SlackNotifier{WebhookURL: n.Url}, nil
+	case model.NotificationTeams:
+		return &TeamsNotifier{WebhookURL: n.Url}, nil
+	case model.NotificationWebhook:
+		return &WebhookNotifier{URL: n.Url, Secret: n.Token}, nil
+	case model.NotificationEmail:
+		if smtp.Host == "" {
+			return nil, fmt.Errorf("email destination %q: SMTP_HOST is not configured", n.Name)
+		}
+		if len(n.EmailAddresses) == 0 {
+			return nil, fmt.Errorf("email destination %q has no addresses", n.Name)
+		}
+		return &EmailNotifier{SMTP: smtp, To: n.EmailAddresses}, nil
+	default:
+		return nil, fmt.Errorf("unknown notification destination type %q", n.DestinationType)
+	}
+}
+
+// postJSON POSTs body and treats any non-2xx response as an error.
+func postJSON(url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(b))
+	}
+	return nil
+}
+
+// costLine renders e.g. "Est. monthly cost: +87.85 USD (25.00 → 112.85)".
+func costLine(est *cost.Estimate) string {
+	return fmt.Sprintf("Est. monthly cost: %+.2f %s (%.2f → %.2f)",
+		est.DeltaMonthly, est.Currency, est.PriorMonthly, est.ProposedMonthly)
+}
@@ -0,0 +1,162 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ilkerispir/terrakubed/internal/executor/cost"
+	"github.com/ilkerispir/terrakubed/internal/model"
+)
+
+func testMessage() *Message {
+	return &Message{
+		Event:            EventPlanPending,
+		Title:            "Plan Ready — Awaiting Approval",
+		Icon:             ":hourglass_flowing_sand:",
+		Color:            "#eda509",
+		OrganizationId:   "org-1",
+		WorkspaceId:      "ws-1",
+		JobId:            "42",
+		Workspace:        "network",
+		RunURL:           "https://terrakube.example.com/organizations/org-1/workspaces/ws-1/runs/42",
+		Source:           "https://github.com/acme/network.git",
+		Branch:           "main",
+		TerraformVersion: "1.9.0",
+		Summary: &Summary{
+			Add: 3, Change: 1, Destroy: 0,
+			Cost: &cost.Estimate{Currency: "USD", PriorMonthly: 25, ProposedMonthly: 112.85, DeltaMonthly: 87.85},
+		},
+	}
+}
+
+func TestWebhookNotifierSignsBody(t *testing.T) {
+	var gotBody []byte
+	var gotSig, gotEvent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSig = r.Header.Get(SignatureHeader)
+		gotEvent = r.Header.Get("X-Terrakube-Event")
+	}))
+	defer srv.Close()
+
+	if err := (&WebhookNotifier{URL: srv.URL, Secret: "s3cret"}).Notify(testMessage()); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if want := Sign("s3cret", gotBody); gotSig != want {
+		t.Errorf("signature = %q, want %q", gotSig, want)
+	}
+	if gotEvent != "planPending" {
+		t.Errorf("event header = %q, want planPending", gotEvent)
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(gotBody, &payload); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	if payload.JobId != "42" || payload.Plan == nil || payload.Plan.Add != 3 {
+		t.Errorf("payload = %+v", payload)
+	}
+	if payload.Plan.CostDelta == nil || *payload.Plan.CostDelta != 87.85 {
+		t.Errorf("cost delta = %v, want 87.85", payload.Plan.CostDelta)
+	}
+}
+
+func TestWebhookNotifierErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	if err := (&WebhookNotifier{URL: srv.URL}).Notify(testMessage()); err == nil {
+		t.Fatal("Notify() error = nil, want HTTP 500 error")
+	}
+}
+
+func TestPayloads(t *testing.T) {
+	msg := testMessage()
+
+	slack, _ := json.Marshal(slackPayload(msg))
+	for _, want := range []string{":hourglass_flowing_sand: *Plan Ready", `\u003c` + msg.RunURL + `|network\u003e`, "*+87.85 USD*"} {
+		if !strings.Contains(string(slack), want) {
+			t.Errorf("slack payload missing %q: %s", want, slack)
+		}
+	}
+
+	teams, _ := json.Marshal(teamsPayload(msg))
+	for _, want := range []string{`"themeColor":"eda509"`, `"MessageCard"`, "3 to add, 1 to change, 0 to destroy", msg.RunURL} {
+		if !strings.Contains(string(teams), want) {
+			t.Errorf("teams payload missing %q: %s", want, teams)
+		}
+	}
+
+	email := string(buildEmail("terrakube@example.com", []string{"a@example.com", "b@example.com"}, msg))
+	for _, want := range []string{"To: a@example.com, b@example.com\r\n", "Est. monthly cost: +87.85 USD"} {
+		if !strings.Contains(email, want) {
+			t.Errorf("email missing %q:\n%s", want, email)
+		}
+	}
+	if subject := emailSubject(t, email); subject != "[Terrakube] Plan Ready — Awaiting Approval — network" {
+		t.Errorf("subject = %q", subject)
+	}
+}
+
+func TestEmailSubjectInjection(t *testing.T) {
+	msg := testMessage()
+	msg.Workspace = "network\r\nBcc: attacker@example.com"
+
+	email := string(buildEmail("terrakube@example.com", []string{"a@example.com"}, msg))
+	headers, _, _ := strings.Cut(email, "\r\n\r\n")
+	if strings.Contains(headers, "\nBcc:") {
+		t.Errorf("workspace name injected a header:\n%s", headers)
+	}
+	if subject := emailSubject(t, email); !strings.HasSuffix(subject, msg.Workspace) {
+		t.Errorf("subject = %q", subject)
+	}
+}
+
+// emailSubject returns the decoded Subject header of an email.
+func emailSubject(t *testing.T, email string) string {
+	t.Helper()
+	for _, line := range strings.Split(email, "\r\n") {
+		if encoded, ok := strings.CutPrefix(line, "Subject: "); ok {
+			subject, err := new(mime.WordDecoder).DecodeHeader(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return subject
+		}
+	}
+	t.Fatalf("email has no subject:\n%s", email)
+	return ""
+}
+
+func TestNew(t *testing.T) {
+	smtp := SMTPConfig{Host: "smtp.example.com"}
+	tests := []struct {
+		name    string
+		dest    model.Notification
+		smtp    SMTPConfig
+		wantErr bool
+	}{
+		{"slack", model.Notification{DestinationType: model.NotificationSlack, Url: "https://hooks.slack.com/x"}, smtp, false},
+		{"teams", model.Notification{DestinationType: model.NotificationTeams, Url: "https://example.webhook.office.com/x"}, smtp, false},
+		{"webhook", model.Notification{DestinationType: model.NotificationWebhook, Url: "https://example.com/hook"}, smtp, false},
+		{"email", model.Notification{DestinationType: model.NotificationEmail, EmailAddresses: []string{"a@example.com"}}, smtp, false},
+		{"email without smtp", model.Notification{DestinationType: model.NotificationEmail, EmailAddresses: []string{"a@example.com"}}, SMTPConfig{}, true},
+		{"email without addresses", model.Notification{DestinationType: model.NotificationEmail}, smtp, true},
+		{"unknown", model.Notification{DestinationType: "pager"}, smtp, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.dest, tt.smtp)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SlackNotifier posts an attachment message to a Slack incoming webhook.
+type SlackNotifier struct {
+	WebhookURL string
+}
+
+func (s *SlackNotifier) Notify(msg *Message) error {
+	body, err := json.Marshal(slackPayload(msg))
+	if err != nil {
+		return err
+	}
+	return postJSON(s.WebhookURL, body, nil)
+}
+
+func slackPayload(msg *Message) map[string]interface{} {
+	title := "*" + msg.Title + "*"
+	if msg.Icon != "" {
+		title = msg.Icon + " " + title
+	}
+
+	wsText := msg.Workspace
+	if msg.RunURL != "" {
+		wsText = fmt.Sprintf("<%s|%s>", msg.RunURL, msg.Workspace)
+	}
+
+	blocks := []map[string]interface{}{
+		{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": title},
+		},
+		{
+			"type": "section",
+			"fields": []map[string]string{
+				{"type": "mrkdwn", "text": "*Workspace:*\n" + wsText},
+				{"type": "mrkdwn", "text": "*Repo:*\n" + msg.Source},
+			},
+		},
+	}
+
+	// Append plan summary block when present
+	if s := msg.Summary; s != nil {
+		summaryText := fmt.Sprintf(
+			"*Plan Summary*\n:seedling: Created: *%d*     :hammer_and_wrench: Updated: *%d*     :x: Deleted: *%d*",
+			s.Add, s.Change, s.Destroy,
+		)
+		if s.Cost != nil {
+			summaryText += fmt.Sprintf("\n:moneybag: Est. monthly cost: *%+.2f %s* (%.2f → %.2f)",
+				s.Cost.DeltaMonthly, s.Cost.Currency, s.Cost.PriorMonthly, s.Cost.ProposedMonthly)
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": summaryText},
+		})
+	}
+
+	blocks = append(blocks,
+		map[string]interface{}{"type": "divider"},
+		map[string]interface{}{
+			"type": "context",
+			"elements": []map[string]string{
+				{"type": "mrkdwn", "text": "Branch: `" + msg.Branch + "` | Version: `" + msg.TerraformVersion + "`"},
+			},
+		},
+	)
+
+	return map[string]interface{}{
+		"attachments": []map[string]interface{}{
+			{
+				"color":  msg.Color,
+				"blocks": blocks,
+			},
+		},
+	}
+}
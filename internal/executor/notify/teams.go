@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// TeamsNotifier posts a MessageCard to a Microsoft Teams incoming webhook.
+type TeamsNotifier struct {
+	WebhookURL string
+}
+
+func (t *TeamsNotifier) Notify(msg *Message) error {
+	body, err := json.Marshal(teamsPayload(msg))
+	if err != nil {
+		return err
+	}
+	return postJSON(t.WebhookURL, body, nil)
+}
+
+func teamsPayload(msg *Message) map[string]interface{} {
+	facts := []map[string]string{
+		{"name": "Workspace", "value": msg.Workspace},
+		{"name": "Repo", "value": msg.Source},
+		{"name": "Branch", "value": msg.Branch},
+		{"name": "Version", "value": msg.TerraformVersion},
+	}
+	if s := msg.Summary; s != nil {
+		facts = append(facts, map[string]string{
+			"name":  "Plan",
+			"value": fmt.Sprintf("%d to add, %d to change, %d to destroy", s.Add, s.Change, s.Destroy),
+		})
+		if s.Cost != nil {
+			facts = append(facts, map[string]string{"name": "Cost", "value": costLine(s.Cost)})
+		}
+	}
+
+	card := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"themeColor": strings.TrimPrefix(msg.Color, "#"),
+		"summary":    msg.Title,
+		"sections": []map[string]interface{}{
+			{"activityTitle": msg.Title, "facts": facts},
+		},
+	}
+	if msg.RunURL != "" {
+		card["potentialAction"] = []map[string]interface{}{
+			{
+				"@type":   "OpenUri",
+				"name":    "View run",
+				"targets": []map[string]string{{"os": "default", "uri": msg.RunURL}},
+			},
+		}
+	}
+	return card
+}
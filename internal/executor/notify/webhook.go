@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// SignatureHeader carries "sha256=<hex HMAC of the body>" when a secret is set.
+const SignatureHeader = "X-Terrakube-Signature"
+
+// WebhookNotifier POSTs a JSON payload to an arbitrary URL.
+type WebhookNotifier struct {
+	URL    string
+	Secret string
+}
+
+// WebhookPayload is the body sent to generic webhook destinations.
+type WebhookPayload struct {
+	Event            Event        `json:"event"`
+	Title            string       `json:"title"`
+	Timestamp        time.Time    `json:"timestamp"`
+	OrganizationId   string       `json:"organizationId"`
+	WorkspaceId      string       `json:"workspaceId"`
+	Workspace        string       `json:"workspace"`
+	JobId            string       `json:"jobId"`
+	RunURL           string       `json:"runUrl,omitempty"`
+	Source           string       `json:"source"`
+	Branch           string       `json:"branch"`
+	TerraformVersion string       `json:"terraformVersion"`
+	Plan             *WebhookPlan `json:"plan,omitempty"`
+}
+
+// WebhookPlan is the plan summary part of a WebhookPayload.
+type WebhookPlan struct {
+	Add          int      `json:"add"`
+	Change       int      `json:"change"`
+	Destroy      int      `json:"destroy"`
+	CostDelta    *float64 `json:"costDeltaMonthly,omitempty"`
+	CostCurrency string   `json:"costCurrency,omitempty"`
+}
+
+func (w *WebhookNotifier) Notify(msg *Message) error {
+	body, err := json.Marshal(webhookPayload(msg, time.Now().UTC()))
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{"X-Terrakube-Event": string(msg.Event)}
+	if w.Secret != "" {
+		headers[SignatureHeader] = Sign(w.Secret, body)
+	}
+	return postJSON(w.URL, body, headers)
+}
+
+// Sign returns the SignatureHeader value for body. Receivers recompute it with
+// the shared secret and compare using hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookPayload(msg *Message, now time.Time) WebhookPayload {
+	p := WebhookPayload{
+		Event:            msg.Event,
+		Title:            msg.Title,
+		Timestamp:        now,
+		OrganizationId:   msg.OrganizationId,
+		WorkspaceId:      msg.WorkspaceId,
+		Workspace:        msg.Workspace,
+		JobId:            msg.JobId,
+		RunURL:           msg.RunURL,
+		Source:           msg.Source,
+		Branch:           msg.Branch,
+		TerraformVersion: msg.TerraformVersion,
+	}
+	if s := msg.Summary; s != nil {
+		p.Plan = &WebhookPlan{Add: s.Add, Change: s.Change, Destroy: s.Destroy}
+		if s.Cost != nil {
+			delta := s.Cost.DeltaMonthly
+			p.Plan.CostDelta = &delta
+			p.Plan.CostCurrency = s.Cost.Currency
+		}
+	}
+	return p
+}
@@ -0,0 +1,194 @@
+// Package policy evaluates Terraform plans against Rego policy sets in-process.
+//
+// Each policy module must define a `deny` rule producing a set of messages,
+// e.g.
+//
+//	package terrakube.s3
+//
+//	deny contains msg if {
+//		rc := input.resource_changes[_]
+//		rc.type == "aws_s3_bucket"
+//		not rc.change.after.tags.owner
+//		msg := sprintf("%s is missing the owner tag", [rc.address])
+//	}
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ilkerispir/terrakubed/internal/model"
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+// PolicyResult is the outcome of one policy.
+type PolicyResult struct {
+	PolicySetId      string   `json:"policySetId"`
+	PolicySet        string   `json:"policySet"`
+	OverrideTeam     string   `json:"overrideTeam,omitempty"`
+	Policy           string   `json:"policy"`
+	EnforcementLevel string   `json:"enforcementLevel"`
+	Passed           bool     `json:"passed"`
+	Violations       []string `json:"violations,omitempty"`
+	Error            string   `json:"error,omitempty"`
+}
+
+// Result aggregates all policy results of a plan. It is stored as
+// tfplan/{jobId}/policy.json.
+type Result struct {
+	Passed       bool           `json:"passed"`
+	HardFailed   bool           `json:"hardFailed"`
+	SoftFailed   bool           `json:"softFailed"`
+	Results      []PolicyResult `json:"results"`
+	Overridden   bool           `json:"overridden,omitempty"`
+	OverriddenBy string         `json:"overriddenBy,omitempty"`
+}
+
+// Evaluate runs every policy of every set against the plan JSON document
+// (the output of `terraform show -json`). A policy that fails to compile or
+// evaluate counts as failed so a broken policy never lets a plan through.
+func Evaluate(ctx context.Context, sets []model.PolicySet, planJSON []byte) (*Result, error) {
+	var input interface{}
+	if err := json.Unmarshal(planJSON, &input); err != nil {
+		return nil, fmt.Errorf("invalid plan JSON: %w", err)
+	}
+
+	res := &Result{Passed: true, Results: []PolicyResult{}}
+	for _, set := range sets {
+		for _, p := range set.Policies {
+			pr := PolicyResult{
+				PolicySetId:      set.Id,
+				PolicySet:        set.Name,
+				OverrideTeam:     set.OverrideTeam,
+				Policy:           p.Name,
+				EnforcementLevel: enforcementLevel(p.EnforcementLevel),
+			}
+			violations, err := evaluatePolicy(ctx, p, input)
+			if err != nil {
+				pr.Error = err.Error()
+				violations = []string{fmt.Sprintf("policy error: %v", err)}
+			}
+			pr.Violations = violations
+			pr.Passed = len(violations) == 0
+
+			if !pr.Passed {
+				switch pr.EnforcementLevel {
+				case model.EnforcementHardMandatory:
+					res.HardFailed = true
+					res.Passed = false
+				case model.EnforcementSoftMandatory:
+					res.SoftFailed = true
+					res.Passed = false
+				}
+			}
+			res.Results = append(res.Results, pr)
+		}
+	}
+	return res, nil
+}
+
+// CheckFailed is the result of a policy check that could not run, such as
+// when the policy sets cannot be loaded. It counts as a hard-mandatory
+// failure, so an unavailable check never lets a plan through.
+func CheckFailed(err error) *Result {
+	return &Result{
+		HardFailed: true,
+		Results: []PolicyResult{{
+			PolicySet:        "terrakube",
+			Policy:           "policy-check",
+			EnforcementLevel: model.EnforcementHardMandatory,
+			Violations:       []string{fmt.Sprintf("policy check failed: %v", err)},
+			Error:            err.Error(),
+		}},
+	}
+}
+
+func evaluatePolicy(ctx context.Context, p model.Policy, input interface{}) ([]string, error) {
+	module, err := ast.ParseModule(p.Name+".rego", p.Rego)
+	if err != nil {
+		return nil, err
+	}
+	if module == nil {
+		return nil, fmt.Errorf("empty policy")
+	}
+
+	query := module.Package.Path.String() + ".deny"
+	rs, err := rego.New(
+		rego.Query(query),
+		rego.Module(p.Name+".rego", p.Rego),
+		rego.Input(input),
+	).Eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []string
+	for _, r := range rs {
+		for _, expr := range r.Expressions {
+			violations = append(violations, messages(expr.Value)...)
+		}
+	}
+	sort.Strings(violations)
+	return violations, nil
+}
+
+// messages converts a deny rule value (a set, decoded as a list) to strings.
+func messages(v interface{}) []string {
+	switch t := v.(type) {
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, m := range t {
+			if s, ok := m.(string); ok {
+				out = append(out, s)
+			} else {
+				b, _ := json.Marshal(m)
+				out = append(out, string(b))
+			}
+		}
+		return out
+	case bool:
+		if t {
+			return []string{"denied"}
+		}
+	}
+	return nil
+}
+
+// enforcementLevel defaults unknown levels to advisory.
+func enforcementLevel(level string) string {
+	switch level {
+	case model.EnforcementSoftMandatory, model.EnforcementHardMandatory:
+		return level
+	default:
+		return model.EnforcementAdvisory
+	}
+}
+
+// Report renders the result as text for the job log.
+func (r *Result) Report() string {
+	var sb strings.Builder
+	sb.WriteString("\n========================================\nPolicy Check\n========================================\n")
+	for _, pr := range r.Results {
+		status := "passed"
+		if !pr.Passed {
+			status = "FAILED"
+		}
+		sb.WriteString(fmt.Sprintf("%s/%s (%s): %s\n", pr.PolicySet, pr.Policy, pr.EnforcementLevel, status))
+		for _, v := range pr.Violations {
+			sb.WriteString("  - " + v + "\n")
+		}
+	}
+	switch {
+	case r.HardFailed:
+		sb.WriteString("\nHard-mandatory policy failed: the run cannot continue.\n")
+	case r.SoftFailed:
+		sb.WriteString("\nSoft-mandatory policy failed: an override from the policy set's override team is required.\n")
+	default:
+		sb.WriteString("\nAll mandatory policies passed.\n")
+	}
+	return sb.String()
+}
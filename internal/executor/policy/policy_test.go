@@ -0,0 +1,99 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ilkerispir/terrakubed/internal/model"
+)
+
+const planJSON = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {"address": "aws_s3_bucket.logs", "type": "aws_s3_bucket", "change": {"actions": ["create"], "after": {"tags": {}}}},
+    {"address": "aws_s3_bucket.data", "type": "aws_s3_bucket", "change": {"actions": ["create"], "after": {"tags": {"owner": "ops"}}}}
+  ]
+}`
+
+const ownerTagRego = `package terrakube.tags
+
+deny contains msg if {
+	rc := input.resource_changes[_]
+	rc.type == "aws_s3_bucket"
+	not rc.change.after.tags.owner
+	msg := sprintf("%s is missing the owner tag", [rc.address])
+}
+`
+
+const allowAllRego = `package terrakube.allow
+
+deny contains msg if {
+	false
+	msg := "never"
+}
+`
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name           string
+		level          string
+		rego           string
+		wantPassed     bool
+		wantHard       bool
+		wantSoft       bool
+		wantViolations int
+	}{
+		{name: "advisory failure does not block", level: model.EnforcementAdvisory, rego: ownerTagRego, wantPassed: true, wantViolations: 1},
+		{name: "soft-mandatory failure", level: model.EnforcementSoftMandatory, rego: ownerTagRego, wantPassed: false, wantSoft: true, wantViolations: 1},
+		{name: "hard-mandatory failure", level: model.EnforcementHardMandatory, rego: ownerTagRego, wantPassed: false, wantHard: true, wantViolations: 1},
+		{name: "passing policy", level: model.EnforcementHardMandatory, rego: allowAllRego, wantPassed: true},
+		{name: "broken policy fails closed", level: model.EnforcementHardMandatory, rego: "package broken\n\ndeny contains msg if {", wantPassed: false, wantHard: true, wantViolations: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sets := []model.PolicySet{{
+				Id:           "ps-1",
+				Name:         "baseline",
+				OverrideTeam: "platform",
+				Policies:     []model.Policy{{Name: "owner-tag", Rego: tt.rego, EnforcementLevel: tt.level}},
+			}}
+			res, err := Evaluate(context.Background(), sets, []byte(planJSON))
+			if err != nil {
+				t.Fatalf("Evaluate() error: %v", err)
+			}
+			if res.Passed != tt.wantPassed || res.HardFailed != tt.wantHard || res.SoftFailed != tt.wantSoft {
+				t.Errorf("got passed=%t hard=%t soft=%t", res.Passed, res.HardFailed, res.SoftFailed)
+			}
+			if got := len(res.Results[0].Violations); got != tt.wantViolations {
+				t.Errorf("violations = %v, want %d", res.Results[0].Violations, tt.wantViolations)
+			}
+		})
+	}
+}
+
+func TestEvaluateViolationMessage(t *testing.T) {
+	sets := []model.PolicySet{{Name: "baseline", Policies: []model.Policy{{Name: "owner-tag", Rego: ownerTagRego}}}}
+	res, err := Evaluate(context.Background(), sets, []byte(planJSON))
+	if err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if want := "aws_s3_bucket.logs is missing the owner tag"; res.Results[0].Violations[0] != want {
+		t.Errorf("violation = %q, want %q", res.Results[0].Violations[0], want)
+	}
+	if !strings.Contains(res.Report(), "baseline/owner-tag (advisory): FAILED") {
+		t.Errorf("unexpected report:\n%s", res.Report())
+	}
+}
+
+func TestCheckFailed(t *testing.T) {
+	res := CheckFailed(errors.New("failed to list policy sets: 503"))
+	if res.Passed || !res.HardFailed {
+		t.Errorf("got passed=%t hard=%t, want a hard failure", res.Passed, res.HardFailed)
+	}
+	if !strings.Contains(res.Report(), "policy check failed: failed to list policy sets: 503") {
+		t.Errorf("unexpected report:\n%s", res.Report())
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -25,6 +26,16 @@ type Executor struct {
 	WorkingDir string
 	Streamer   logs.LogStreamer
 	ExecPath   string
+
+	// JSONOutput runs plan/apply/destroy with -json and renders the human log
+	// from the parsed UI events (see jsonlog.go).
+	JSONOutput bool
+	jsonLog    *JSONLogWriter
+
+	// PluginCacheDir is a provider plugin cache shared with other jobs (see
+	// plugincache.go). Empty disables it.
+	PluginCacheDir string
+	hasLockFile    bool
 }
 
 func NewExecutor(job *model.TerraformJob, workingDir string, streamer logs.LogStreamer, execPath string) *Executor {
@@ -66,6 +77,13 @@ func (e *Executor) buildEnvMap() map[string]string {
 		}
 	}
 
+	if e.PluginCacheDir != "" {
+		env["TF_PLUGIN_CACHE_DIR"] = e.PluginCacheDir
+		if !e.hasLockFile {
+			env["TF_PLUGIN_CACHE_MAY_BREAK_DEPENDENCY_LOCK_FILE"] = "true"
+		}
+	}
+
 	for k, v := range e.Job.EnvironmentVariables {
 		env[k] = v
 	}
@@ -101,6 +119,52 @@ func (e *Executor) runTerraformDirect(args ...string) error {
 	return cmd.Run()
 }
 
+// runTerraformJSON runs a user-facing command with -json. Stdout carries the
+// machine-readable UI stream, which is parsed into events and rendered back to
+// human text on the streamer; stderr is passed through untouched.
+func (e *Executor) runTerraformJSON(args ...string) error {
+	var out io.Writer = os.Stdout
+	if e.Streamer != nil {
+		out = e.Streamer
+	}
+	if e.jsonLog == nil {
+		e.jsonLog = NewJSONLogWriter(out)
+	}
+	defer e.jsonLog.Flush()
+
+	// -json must precede positional arguments such as a saved plan file.
+	jsonArgs := append([]string{args[0], "-json"}, args[1:]...)
+	cmd := exec.Command(e.ExecPath, jsonArgs...)
+	cmd.Dir = e.WorkingDir
+
+	envMap := e.buildEnvMap()
+	envSlice := make([]string, 0, len(envMap))
+	for k, v := range envMap {
+		envSlice = append(envSlice, k+"="+v)
+	}
+	cmd.Env = envSlice
+	cmd.Stdout = e.jsonLog
+	cmd.Stderr = out
+
+	return cmd.Run()
+}
+
+// runOperation runs plan/apply/destroy, in JSON mode when enabled.
+func (e *Executor) runOperation(args ...string) error {
+	if e.JSONOutput {
+		return e.runTerraformJSON(args...)
+	}
+	return e.runTerraformDirect(args...)
+}
+
+// Events returns the UI events collected while running in JSON mode.
+func (e *Executor) Events() []UIEvent {
+	if e.jsonLog == nil {
+		return nil
+	}
+	return e.jsonLog.Events()
+}
+
 func (e *Executor) Execute() (*ExecutionResult, error) {
 	ctx := context.Background()
 
@@ -109,9 +173,7 @@ func (e *Executor) Execute() (*ExecutionResult, error) {
 		e.Streamer.Write([]byte(header))
 	}
 
-	// Init with -reconfigure so Terraform adopts the backend override (terrakube_override.tf)
-	// without prompting for state migration, which would fail in non-interactive mode.
-	err := e.runTerraformDirect("init", "-input=false", "-upgrade", "-reconfigure")
+	err := e.init()
 	if err != nil {
 		return nil, fmt.Errorf("error running Init: %s", err)
 	}
@@ -123,6 +185,8 @@ func (e *Executor) Execute() (*ExecutionResult, error) {
 		result, err = e.executePlan(ctx, false)
 	case "terraformPlanDestroy":
 		result, err = e.executePlan(ctx, true)
+	case "terraformDrift":
+		result, err = e.executeDrift(ctx)
 	case "terraformApply":
 		err = e.executeApply(ctx)
 	case "terraformDestroy":
@@ -156,7 +220,19 @@ func (e *Executor) executePlan(ctx context.Context, isDestroy bool) (*ExecutionR
 		args = append(args, "-refresh-only")
 	}
 
-	err := e.runTerraformDirect(args...)
+	return e.runPlan(args...)
+}
+
+// executeDrift runs a refresh-only plan: it compares real infrastructure with
+// the state without proposing any configuration changes. Exit code 2 means
+// drift was detected.
+func (e *Executor) executeDrift(ctx context.Context) (*ExecutionResult, error) {
+	planFile := filepath.Join(e.WorkingDir, "terraform.tfplan")
+	return e.runPlan("plan", "-input=false", "-detailed-exitcode", "-refresh-only", "-out="+planFile)
+}
+
+func (e *Executor) runPlan(args ...string) (*ExecutionResult, error) {
+	err := e.runOperation(args...)
 	if err != nil {
 		// Exit code 2 = changes present (not an error for plan)
 		if exitErr, ok := err.(*exec.ExitError); ok {
@@ -173,14 +249,14 @@ func (e *Executor) executePlan(ctx context.Context, isDestroy bool) (*ExecutionR
 func (e *Executor) executeApply(ctx context.Context) error {
 	planFile := filepath.Join(e.WorkingDir, "terraformLibrary.tfPlan")
 	if _, err := os.Stat(planFile); err == nil {
-		return e.runTerraformDirect("apply", "-input=false", "-auto-approve", planFile)
+		return e.runOperation("apply", "-input=false", "-auto-approve", planFile)
 	}
 
 	args := []string{"apply", "-input=false", "-auto-approve"}
 	if e.Job.Refresh {
 		args = append(args, "-refresh=true")
 	}
-	return e.runTerraformDirect(args...)
+	return e.runOperation(args...)
 }
 
 func (e *Executor) executeDestroy() error {
@@ -188,7 +264,7 @@ func (e *Executor) executeDestroy() error {
 	if e.Job.Refresh {
 		args = append(args, "-refresh=true")
 	}
-	return e.runTerraformDirect(args...)
+	return e.runOperation(args...)
 }
 
 func (e *Executor) Output() (string, error) {
@@ -241,6 +317,27 @@ func (e *Executor) ShowPlanJSON() (*tfjson.Plan, error) {
 	return tf.ShowPlanFile(context.Background(), planFile)
 }
 
+// ShowPlanFileJSON reads an arbitrary saved plan file, e.g. the plan downloaded
+// for an apply step (terraformLibrary.tfPlan).
+func (e *Executor) ShowPlanFileJSON(planFile string) (*tfjson.Plan, error) {
+	tf, err := tfexec.NewTerraform(e.WorkingDir, e.ExecPath)
+	if err != nil {
+		return nil, fmt.Errorf("error running NewTerraform: %w", err)
+	}
+	tf.SetEnv(e.buildEnvMap())
+	return tf.ShowPlanFile(context.Background(), planFile)
+}
+
+// ShowCurrentState reads the state through the configured backend.
+func (e *Executor) ShowCurrentState() (*tfjson.State, error) {
+	tf, err := tfexec.NewTerraform(e.WorkingDir, e.ExecPath)
+	if err != nil {
+		return nil, fmt.Errorf("error running NewTerraform: %w", err)
+	}
+	tf.SetEnv(e.buildEnvMap())
+	return tf.Show(context.Background())
+}
+
 func (e *Executor) StatePull() (string, error) {
 	tf, err := tfexec.NewTerraform(e.WorkingDir, e.ExecPath)
 	if err != nil {
@@ -0,0 +1,235 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UIEvent is one message of Terraform's machine-readable UI stream (`-json`).
+// Only the fields Terrakube consumes are typed; Raw keeps the original line so
+// the stream can be persisted without loss.
+type UIEvent struct {
+	Level      string          `json:"@level"`
+	Message    string          `json:"@message"`
+	Module     string          `json:"@module"`
+	Timestamp  time.Time       `json:"@timestamp"`
+	Type       string          `json:"type"`
+	Hook       *ResourceHook   `json:"hook,omitempty"`
+	Change     *PlannedChange  `json:"change,omitempty"`
+	Changes    *ChangeSummary  `json:"changes,omitempty"`
+	Diagnostic *Diagnostic     `json:"diagnostic,omitempty"`
+	Raw        json.RawMessage `json:"-"`
+}
+
+// UI stream message types used by Terrakube.
+const (
+	EventVersion       = "version"
+	EventPlannedChange = "planned_change"
+	EventResourceDrift = "resource_drift"
+	EventChangeSummary = "change_summary"
+	EventApplyStart    = "apply_start"
+	EventApplyProgress = "apply_progress"
+	EventApplyComplete = "apply_complete"
+	EventApplyErrored  = "apply_errored"
+	EventRefreshStart  = "refresh_start"
+	EventRefreshDone   = "refresh_complete"
+	EventDiagnostic    = "diagnostic"
+	EventOutputs       = "outputs"
+	EventLog           = "log"
+)
+
+// ResourceAddr identifies the resource an event refers to.
+type ResourceAddr struct {
+	Addr            string `json:"addr"`
+	Module          string `json:"module"`
+	Resource        string `json:"resource"`
+	ImpliedProvider string `json:"implied_provider"`
+	ResourceType    string `json:"resource_type"`
+	ResourceName    string `json:"resource_name"`
+}
+
+// ResourceHook is the payload of apply_* and refresh_* events.
+type ResourceHook struct {
+	Resource       ResourceAddr `json:"resource"`
+	Action         string       `json:"action,omitempty"`
+	IDKey          string       `json:"id_key,omitempty"`
+	IDValue        string       `json:"id_value,omitempty"`
+	ElapsedSeconds float64      `json:"elapsed_seconds,omitempty"`
+}
+
+// PlannedChange is the payload of planned_change and resource_drift events.
+type PlannedChange struct {
+	Resource ResourceAddr `json:"resource"`
+	Action   string       `json:"action"`
+	Reason   string       `json:"reason,omitempty"`
+}
+
+// ChangeSummary is the payload of change_summary events.
+type ChangeSummary struct {
+	Add       int    `json:"add"`
+	Change    int    `json:"change"`
+	Import    int    `json:"import"`
+	Remove    int    `json:"remove"`
+	Operation string `json:"operation"`
+}
+
+// Diagnostic is the payload of diagnostic events.
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail"`
+	Address  string `json:"address,omitempty"`
+	Range    *struct {
+		Filename string `json:"filename"`
+		Start    struct {
+			Line int `json:"line"`
+		} `json:"start"`
+	} `json:"range,omitempty"`
+}
+
+// ParseUIEvent decodes a single line of the UI stream.
+func ParseUIEvent(line []byte) (*UIEvent, error) {
+	var ev UIEvent
+	if err := json.Unmarshal(line, &ev); err != nil {
+		return nil, err
+	}
+	if ev.Type == "" {
+		return nil, fmt.Errorf("not a terraform UI event")
+	}
+	ev.Raw = append(json.RawMessage(nil), line...)
+	return &ev, nil
+}
+
+// RenderEvent converts an event to the human-readable text Terraform would have
+// printed without -json, so log consumers (UI, parsePlanSummary) keep working.
+// Returns "" for events that have no human representation.
+func RenderEvent(ev *UIEvent) string {
+	switch ev.Type {
+	case EventVersion, EventOutputs:
+		return ""
+	case EventDiagnostic:
+		if ev.Diagnostic == nil {
+			return ev.Message + "\n"
+		}
+		var sb strings.Builder
+		severity := "Error"
+		if ev.Diagnostic.Severity == "warning" {
+			severity = "Warning"
+		}
+		sb.WriteString(fmt.Sprintf("\n%s: %s\n", severity, ev.Diagnostic.Summary))
+		if r := ev.Diagnostic.Range; r != nil && r.Filename != "" {
+			sb.WriteString(fmt.Sprintf("\n  on %s line %d\n", r.Filename, r.Start.Line))
+		}
+		if ev.Diagnostic.Detail != "" {
+			sb.WriteString("\n" + ev.Diagnostic.Detail + "\n")
+		}
+		return sb.String()
+	case EventChangeSummary:
+		return "\n" + ev.Message + "\n"
+	default:
+		if ev.Message == "" {
+			return ""
+		}
+		return ev.Message + "\n"
+	}
+}
+
+// JSONLogWriter consumes Terraform's -json output. Each complete line is parsed
+// into a UIEvent and rendered as human text to Out; lines that are not UI events
+// (e.g. provider stderr) are passed through unchanged.
+type JSONLogWriter struct {
+	Out io.Writer
+
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	events []UIEvent
+}
+
+// NewJSONLogWriter creates a JSONLogWriter rendering to out.
+func NewJSONLogWriter(out io.Writer) *JSONLogWriter {
+	return &JSONLogWriter{Out: out}
+}
+
+func (w *JSONLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(p)
+	for {
+		idx := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if idx == -1 {
+			break
+		}
+		line := make([]byte, idx)
+		copy(line, w.buf.Bytes()[:idx])
+		w.buf.Next(idx + 1)
+		w.handleLine(line)
+	}
+	return len(p), nil
+}
+
+// Flush processes any trailing partial line.
+func (w *JSONLogWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() > 0 {
+		line := append([]byte(nil), w.buf.Bytes()...)
+		w.buf.Reset()
+		w.handleLine(line)
+	}
+}
+
+func (w *JSONLogWriter) handleLine(line []byte) {
+	trimmed := bytes.TrimSpace(line)
+	if len(trimmed) == 0 {
+		return
+	}
+	ev, err := ParseUIEvent(trimmed)
+	if err != nil {
+		if w.Out != nil {
+			w.Out.Write(append(line, '\n'))
+		}
+		return
+	}
+	w.events = append(w.events, *ev)
+	if w.Out != nil {
+		if text := RenderEvent(ev); text != "" {
+			w.Out.Write([]byte(text))
+		}
+	}
+}
+
+// Events returns the events parsed so far.
+func (w *JSONLogWriter) Events() []UIEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := make([]UIEvent, len(w.events))
+	copy(out, w.events)
+	return out
+}
+
+// LastChangeSummary returns the final change_summary of an event stream, or nil.
+func LastChangeSummary(events []UIEvent) *ChangeSummary {
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type == EventChangeSummary && events[i].Changes != nil {
+			return events[i].Changes
+		}
+	}
+	return nil
+}
+
+// MarshalEventsNDJSON serializes events as newline-delimited JSON using their
+// original lines.
+func MarshalEventsNDJSON(events []UIEvent) []byte {
+	var buf bytes.Buffer
+	for _, ev := range events {
+		buf.Write(ev.Raw)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
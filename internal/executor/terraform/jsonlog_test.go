@@ -0,0 +1,140 @@
+package terraform
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseUIEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		wantErr  bool
+		wantType string
+	}{
+		{
+			name:     "change summary",
+			line:     `{"@level":"info","@message":"Plan: 1 to add, 0 to change, 0 to destroy.","type":"change_summary","changes":{"add":1,"change":0,"import":0,"remove":0,"operation":"plan"}}`,
+			wantType: EventChangeSummary,
+		},
+		{
+			name:     "apply complete",
+			line:     `{"@level":"info","@message":"null_resource.a: Creation complete after 0s","type":"apply_complete","hook":{"resource":{"addr":"null_resource.a"},"action":"create"}}`,
+			wantType: EventApplyComplete,
+		},
+		{
+			name:    "plain text",
+			line:    `Initializing provider plugins...`,
+			wantErr: true,
+		},
+		{
+			name:    "json without type",
+			line:    `{"foo":"bar"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := ParseUIEvent([]byte(tt.line))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got event %+v", ev)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ev.Type != tt.wantType {
+				t.Errorf("Type = %q, want %q", ev.Type, tt.wantType)
+			}
+			if string(ev.Raw) != tt.line {
+				t.Errorf("Raw not preserved: %s", ev.Raw)
+			}
+		})
+	}
+}
+
+func TestRenderEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{
+			name: "version is hidden",
+			line: `{"@message":"Terraform 1.9.0","type":"version"}`,
+			want: nil,
+		},
+		{
+			name: "error diagnostic",
+			line: `{"@level":"error","@message":"Error: Missing required argument","type":"diagnostic","diagnostic":{"severity":"error","summary":"Missing required argument","detail":"The argument \"ami\" is required.","range":{"filename":"main.tf","start":{"line":3}}}}`,
+			want: []string{"Error: Missing required argument", "on main.tf line 3", `The argument "ami" is required.`},
+		},
+		{
+			name: "change summary keeps plan line",
+			line: `{"@message":"Plan: 2 to add, 1 to change, 0 to destroy.","type":"change_summary","changes":{"add":2,"change":1,"remove":0}}`,
+			want: []string{"Plan: 2 to add, 1 to change, 0 to destroy."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := ParseUIEvent([]byte(tt.line))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := RenderEvent(ev)
+			if tt.want == nil && got != "" {
+				t.Fatalf("expected empty render, got %q", got)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("render %q missing %q", got, w)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONLogWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewJSONLogWriter(&out)
+
+	stream := `{"@message":"Terraform 1.9.0","type":"version"}
+plain provider output
+{"@message":"null_resource.a: Creating...","type":"apply_start","hook":{"resource":{"addr":"null_resource.a"},"action":"create"}}
+{"@message":"Apply complete! Resources: 1 added, 0 changed, 0 destroyed.","type":"change_summary","changes":{"add":1,"change":0,"remove":0,"operation":"apply"}}`
+
+	// Split the write mid-line to exercise buffering.
+	w.Write([]byte(stream[:40]))
+	w.Write([]byte(stream[40:]))
+	w.Flush()
+
+	events := w.Events()
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+
+	text := out.String()
+	for _, want := range []string{"plain provider output", "null_resource.a: Creating...", "Apply complete!"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("rendered log missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Terraform 1.9.0") {
+		t.Errorf("version event should not be rendered:\n%s", text)
+	}
+
+	cs := LastChangeSummary(events)
+	if cs == nil || cs.Add != 1 || cs.Operation != "apply" {
+		t.Errorf("LastChangeSummary = %+v", cs)
+	}
+
+	ndjson := MarshalEventsNDJSON(events)
+	if n := bytes.Count(ndjson, []byte("\n")); n != 3 {
+		t.Errorf("NDJSON has %d lines, want 3", n)
+	}
+}
@@ -0,0 +1,75 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ilkerispir/terrakubed/internal/utils"
+)
+
+const (
+	dependencyLockFile = ".terraform.lock.hcl"
+	pluginCacheLock    = ".terrakube.lock"
+)
+
+// initArgs returns the terraform init arguments. Init runs with -reconfigure so
+// Terraform adopts the backend override (terrakube_override.tf) without
+// prompting for state migration, which would fail in non-interactive mode.
+//
+// With a shared plugin cache the providers are already installed by
+// providerInitArgs, which also wrote the dependency lock file, so -upgrade is
+// dropped: init only links the locked versions from the cache instead of
+// re-resolving them against the registry and writing to the cache unlocked.
+func (e *Executor) initArgs() []string {
+	if e.PluginCacheDir != "" {
+		return []string{"init", "-input=false", "-reconfigure"}
+	}
+	return []string{"init", "-input=false", "-upgrade", "-reconfigure"}
+}
+
+// providerInitArgs returns the arguments of the init that installs providers
+// into the shared plugin cache. It skips the backend, so the cache lock isn't
+// held while the state backend is configured. With a committed dependency
+// lock file -upgrade is dropped: the lock file pins the provider versions, so
+// the cached copies are reused.
+func (e *Executor) providerInitArgs() []string {
+	if e.hasLockFile {
+		return []string{"init", "-input=false", "-backend=false"}
+	}
+	return []string{"init", "-input=false", "-upgrade", "-backend=false"}
+}
+
+// init runs terraform init. Terraform's plugin cache is not safe for
+// concurrent writers, so with a shared cache the providers are first
+// installed by an init without backend under a file lock, which also covers
+// executors on other nodes mounting the same volume. The full init then runs
+// without the lock and only reads the cache.
+func (e *Executor) init() error {
+	if e.PluginCacheDir == "" {
+		return e.runTerraformDirect(e.initArgs()...)
+	}
+
+	// Checked before init, which writes a lock file of its own.
+	_, err := os.Stat(filepath.Join(e.WorkingDir, dependencyLockFile))
+	e.hasLockFile = err == nil
+
+	if err := e.installProviders(); err != nil {
+		return err
+	}
+	return e.runTerraformDirect(e.initArgs()...)
+}
+
+// installProviders runs the provider init while holding the cache lock.
+func (e *Executor) installProviders() error {
+	if err := os.MkdirAll(e.PluginCacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create plugin cache %s: %w", e.PluginCacheDir, err)
+	}
+	unlock, err := utils.LockFile(filepath.Join(e.PluginCacheDir, pluginCacheLock), true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return e.runTerraformDirect(e.providerInitArgs()...)
+}
@@ -0,0 +1,120 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ilkerispir/terrakubed/internal/model"
+	"github.com/ilkerispir/terrakubed/internal/utils"
+)
+
+func TestInitArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		cacheDir string
+		lockFile bool
+		want     string
+	}{
+		{"no cache", "", false, "init -input=false -upgrade -reconfigure"},
+		{"no cache with lock file", "", true, "init -input=false -upgrade -reconfigure"},
+		{"cache without lock file", "/cache", false, "init -input=false -reconfigure"},
+		{"cache with lock file", "/cache", true, "init -input=false -reconfigure"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Executor{PluginCacheDir: tt.cacheDir, hasLockFile: tt.lockFile}
+			if got := strings.Join(e.initArgs(), " "); got != tt.want {
+				t.Errorf("initArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderInitArgs(t *testing.T) {
+	e := &Executor{PluginCacheDir: "/cache"}
+	if got, want := strings.Join(e.providerInitArgs(), " "), "init -input=false -upgrade -backend=false"; got != want {
+		t.Errorf("providerInitArgs() = %q, want %q", got, want)
+	}
+	e.hasLockFile = true
+	if got, want := strings.Join(e.providerInitArgs(), " "), "init -input=false -backend=false"; got != want {
+		t.Errorf("providerInitArgs() with lock file = %q, want %q", got, want)
+	}
+}
+
+func TestPluginCacheEnv(t *testing.T) {
+	job := &model.TerraformJob{EnvironmentVariables: map[string]string{}}
+
+	e := &Executor{Job: job, PluginCacheDir: "/cache"}
+	env := e.buildEnvMap()
+	if env["TF_PLUGIN_CACHE_DIR"] != "/cache" {
+		t.Errorf("TF_PLUGIN_CACHE_DIR = %q, want /cache", env["TF_PLUGIN_CACHE_DIR"])
+	}
+	if env["TF_PLUGIN_CACHE_MAY_BREAK_DEPENDENCY_LOCK_FILE"] != "true" {
+		t.Error("cache without a lock file should allow Terraform to populate it")
+	}
+
+	e.hasLockFile = true
+	if _, ok := e.buildEnvMap()["TF_PLUGIN_CACHE_MAY_BREAK_DEPENDENCY_LOCK_FILE"]; ok {
+		t.Error("lock file present: cached providers must be verified against it")
+	}
+
+	job.EnvironmentVariables["TF_PLUGIN_CACHE_DIR"] = "/workspace-cache"
+	if got := e.buildEnvMap()["TF_PLUGIN_CACHE_DIR"]; got != "/workspace-cache" {
+		t.Errorf("workspace env should override the cache dir, got %q", got)
+	}
+}
+
+func TestInitDetectsLockFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, dependencyLockFile), []byte("# lock\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache := filepath.Join(t.TempDir(), "plugins")
+
+	// "true" stands in for terraform: init only has to succeed.
+	e := &Executor{Job: &model.TerraformJob{}, WorkingDir: dir, ExecPath: "true", PluginCacheDir: cache}
+	if err := e.init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if !e.hasLockFile {
+		t.Error("expected the dependency lock file to be detected")
+	}
+	if _, err := os.Stat(filepath.Join(cache, pluginCacheLock)); err != nil {
+		t.Errorf("expected cache dir and lock to be created: %v", err)
+	}
+}
+
+func TestInitLocksOnlyProviderInstall(t *testing.T) {
+	dir := t.TempDir()
+	cache := filepath.Join(t.TempDir(), "plugins")
+	calls := filepath.Join(t.TempDir(), "calls")
+
+	// The fake terraform records its arguments.
+	script := filepath.Join(t.TempDir(), "terraform")
+	body := "#!/bin/sh\necho \"$*\" >> " + calls + "\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	e := &Executor{Job: &model.TerraformJob{}, WorkingDir: dir, ExecPath: script, PluginCacheDir: cache}
+	if err := e.init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	out, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "init -input=false -upgrade -backend=false\ninit -input=false -reconfigure\n"
+	if string(out) != want {
+		t.Errorf("terraform calls = %q, want %q", out, want)
+	}
+
+	// The lock is released once the providers are installed.
+	unlock, err := utils.LockFile(filepath.Join(cache, pluginCacheLock), false)
+	if err != nil {
+		t.Fatalf("cache lock still held: %v", err)
+	}
+	unlock()
+}
@@ -38,7 +38,7 @@ func (w *Workspace) Setup() (string, error) {
 	}
 
 	gitSvc := git.NewService()
-	finalDir, err := gitSvc.CloneWorkspace(w.Job.Source, w.Job.Branch, w.Job.VcsType, w.Job.ConnectionType, w.Job.AccessToken, w.Job.Folder, w.Job.JobId)
+	finalDir, err := gitSvc.CloneWorkspace(w.Job.Source, w.Job.Branch, w.Job.CommitId, w.Job.VcsType, w.Job.ConnectionType, w.Job.AccessToken, w.Job.Folder, w.Job.OrganizationId, w.Job.JobId)
 	if err != nil {
 		return "", err
 	}
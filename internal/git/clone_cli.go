@@ -0,0 +1,124 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// cloneCLI clones with the git binary.
+func (s *Service) cloneCLI(c cloneSpec) error {
+	env, hosts, sshCleanup, err := s.setupSSHEnv(c)
+	if err != nil {
+		return err
+	}
+	defer sshCleanup()
+
+	run := func(args ...string) error {
+		cmd := exec.Command("git", args...)
+		cmd.Env = env
+		if output, err := cmd.CombinedOutput(); err != nil {
+			if hostErr := hostKeyError(string(output)); hostErr != nil {
+				return hostErr
+			}
+			return fmt.Errorf("git %s failed: %s: %w", strings.Join(redact(args), " "), strings.TrimSpace(string(output)), err)
+		}
+		return nil
+	}
+
+	if s.opts.Mirrors != nil {
+		err = s.opts.Mirrors.checkout(c, run)
+		s.opts.Mirrors.evict()
+		var missing *missingRefError
+		switch {
+		case err == nil:
+			return s.finishCLI(c, hosts, run)
+		case errors.As(err, &missing), errors.Is(err, ErrHostKeyMismatch), errors.Is(err, ErrUnknownHost):
+			return err
+		}
+		log.Printf("Warning: git mirror of %s failed, cloning directly: %v", c.source, err)
+		if err := resetDir(c.dir); err != nil {
+			return err
+		}
+	}
+
+	repoURL := setupCredentialURL(c.source, c.vcsType, c.connectionType, c.accessToken)
+
+	if c.commit == "" {
+		args := []string{"clone", "--depth", "1"}
+		if c.ref != "" {
+			args = append(args, "--branch", c.ref)
+		}
+		if err := run(append(args, repoURL, c.dir)...); err != nil {
+			return err
+		}
+	} else {
+		if err := run("init", "-q", c.dir); err != nil {
+			return err
+		}
+		if err := run("-C", c.dir, "remote", "add", "origin", repoURL); err != nil {
+			return err
+		}
+		// Fetching a single commit needs server support for wants by SHA;
+		// fall back to fetching the history when the server refuses
+		if err := run("-C", c.dir, "fetch", "-q", "--depth", "1", "origin", c.commit); err == nil {
+			if err := run("-C", c.dir, "checkout", "-q", "--detach", "FETCH_HEAD"); err != nil {
+				return err
+			}
+		} else {
+			if err := run("-C", c.dir, "fetch", "-q", "--tags", "origin"); err != nil {
+				return err
+			}
+			if err := run("-C", c.dir, "checkout", "-q", "--detach", c.commit); err != nil {
+				return fmt.Errorf("commit %s not found: %w", c.commit, err)
+			}
+		}
+	}
+
+	return s.finishCLI(c, hosts, run)
+}
+
+// finishCLI checks out submodules and LFS objects of a checkout, then pins
+// host keys seen for the first time.
+func (s *Service) finishCLI(c cloneSpec, hosts *knownHosts, run func(args ...string) error) error {
+	if s.opts.Submodules {
+		args := credentialRewrite(c.source, c.vcsType, c.connectionType, c.accessToken)
+		args = append(args, "-C", c.dir, "submodule", "update", "--init", "--recursive")
+		if err := run(args...); err != nil {
+			return err
+		}
+	}
+	if s.opts.LFS {
+		if err := run("-C", c.dir, "lfs", "pull"); err != nil {
+			return fmt.Errorf("git lfs (is git-lfs installed?): %w", err)
+		}
+	}
+	if hosts != nil {
+		hosts.pinNew()
+	}
+	return nil
+}
+
+// resetDir empties dir after a failed clone attempt.
+func resetDir(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.MkdirAll(dir, 0700)
+}
+
+// redact hides credentials embedded in URL arguments from error messages.
+func redact(args []string) []string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		if at := strings.Index(a, "@"); at > 0 && strings.Contains(a[:at], "://") {
+			scheme := a[:strings.Index(a, "://")+3]
+			a = scheme + "***" + a[at:]
+		}
+		out[i] = a
+	}
+	return out
+}
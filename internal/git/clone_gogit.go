@@ -0,0 +1,160 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// cloneGoGit clones in-process with go-git, so no git binary is needed.
+func (s *Service) cloneGoGit(c cloneSpec) error {
+	auth, hosts, cleanup, err := s.goGitAuth(c)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	var repo *gogit.Repository
+	if c.commit == "" {
+		opts := &gogit.CloneOptions{URL: c.source, Auth: auth, Depth: 1, SingleBranch: true}
+		if c.ref != "" {
+			opts.ReferenceName = plumbing.NewBranchReferenceName(c.ref)
+			if c.tag {
+				opts.ReferenceName = plumbing.NewTagReferenceName(c.ref)
+			}
+		}
+		if repo, err = gogit.PlainClone(c.dir, false, opts); err != nil {
+			return fmt.Errorf("git clone failed: %w", err)
+		}
+	} else if repo, err = fetchCommit(c, auth); err != nil {
+		return err
+	}
+
+	if s.opts.Submodules {
+		wt, err := repo.Worktree()
+		if err != nil {
+			return err
+		}
+		subs, err := wt.Submodules()
+		if err != nil {
+			return fmt.Errorf("failed to read submodules: %w", err)
+		}
+		if err := subs.Update(&gogit.SubmoduleUpdateOptions{
+			Init:              true,
+			RecurseSubmodules: gogit.DefaultSubmoduleRecursionDepth,
+			Auth:              auth,
+		}); err != nil {
+			return fmt.Errorf("git submodule update failed: %w", err)
+		}
+	}
+	if s.opts.LFS {
+		log.Printf("Warning: Git LFS is not supported by the in-process git backend; LFS files of %s are left as pointers", c.source)
+	}
+	if hosts != nil {
+		hosts.pinNew()
+	}
+	return nil
+}
+
+// fetchCommit initializes c.dir and checks out c.commit. A shallow fetch of
+// the commit is tried first; servers that don't allow wants by SHA get a
+// full fetch of branches and tags instead.
+func fetchCommit(c cloneSpec, auth transport.AuthMethod) (*gogit.Repository, error) {
+	repo, err := gogit.PlainInit(c.dir, false)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{c.source}})
+	if err != nil {
+		return nil, err
+	}
+
+	err = errors.New("not a full commit hash")
+	if plumbing.IsHash(c.commit) {
+		err = remote.Fetch(&gogit.FetchOptions{
+			Auth:     auth,
+			Depth:    1,
+			RefSpecs: []config.RefSpec{config.RefSpec(c.commit + ":refs/heads/pinned")},
+		})
+	}
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		err = remote.Fetch(&gogit.FetchOptions{
+			Auth:     auth,
+			RefSpecs: []config.RefSpec{"+refs/heads/*:refs/remotes/origin/*"},
+			Tags:     gogit.AllTags,
+		})
+		if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+			return nil, fmt.Errorf("git fetch failed: %w", err)
+		}
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(c.commit))
+	if err != nil {
+		return nil, fmt.Errorf("commit %s not found: %w", c.commit, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	if err := wt.Checkout(&gogit.CheckoutOptions{Hash: *hash, Force: true}); err != nil {
+		return nil, fmt.Errorf("git checkout %s failed: %w", c.commit, err)
+	}
+	return repo, nil
+}
+
+// goGitAuth maps the access token to go-git credentials, mirroring
+// setupCredentialURL and setupSSHEnv of the CLI backend.
+func (s *Service) goGitAuth(c cloneSpec) (transport.AuthMethod, *knownHosts, func(), error) {
+	cleanup := func() {}
+	if strings.HasPrefix(c.vcsType, "SSH") {
+		if c.accessToken == "" {
+			return nil, nil, cleanup, nil
+		}
+		keys, err := gitssh.NewPublicKeys("git", []byte(c.accessToken), "")
+		if err != nil {
+			return nil, nil, cleanup, fmt.Errorf("invalid SSH key: %w", err)
+		}
+		dir, err := os.MkdirTemp("", "terrakube-ssh")
+		if err != nil {
+			return nil, nil, cleanup, err
+		}
+		cleanup = func() { os.RemoveAll(dir) }
+		hosts, err := s.prepareKnownHosts(c.orgId, dir)
+		if err == nil {
+			keys.HostKeyCallback, err = hosts.callback()
+		}
+		if err != nil {
+			cleanup()
+			return nil, nil, func() {}, err
+		}
+		return keys, hosts, cleanup, nil
+	}
+	user, password, ok := credentials(c.vcsType, c.connectionType, c.accessToken)
+	if !ok || !strings.HasPrefix(c.source, "https://") {
+		return nil, nil, cleanup, nil
+	}
+	return &http.BasicAuth{Username: user, Password: password}, nil, cleanup, nil
+}
+
+// HeadCommit returns the commit checked out in dir or any of its parents,
+// or "" when dir is not inside a git repository.
+func HeadCommit(dir string) string {
+	repo, err := gogit.PlainOpenWithOptions(dir, &gogit.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return ""
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return ""
+	}
+	return head.Hash().String()
+}
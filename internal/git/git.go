@@ -2,113 +2,198 @@ package git
 
 import (
 	"fmt"
+	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 type GitService interface {
-	CloneRepository(source, version, vcsType, accessToken, tagPrefix, folder string) (string, error)
+	CloneRepository(orgId, source, version, vcsType, accessToken, tagPrefix, folder string) (string, error)
 }
 
-type Service struct{}
+// Options control how repositories are cloned.
+type Options struct {
+	// InProcess clones with go-git instead of the git binary.
+	InProcess bool
+	// Submodules initializes submodules recursively after checkout.
+	Submodules bool
+	// LFS downloads Git LFS objects after checkout (git binary with git-lfs only).
+	LFS bool
+	// Mirrors caches repositories as local bare mirrors (git binary only).
+	Mirrors *MirrorCache
+	// HostKeyChecking is HostKeysTOFU (default), HostKeysStrict or HostKeysOff.
+	HostKeyChecking string
+	// HostKeys stores the SSH host keys trusted by each organization.
+	HostKeys HostKeyStore
+}
+
+var (
+	optionsMu      sync.RWMutex
+	defaultOptions Options
+)
+
+// Configure sets the Options of every Service created afterwards. It is
+// called once at startup.
+func Configure(opts Options) {
+	optionsMu.Lock()
+	defer optionsMu.Unlock()
+	defaultOptions = opts
+}
+
+type Service struct {
+	opts Options
+}
 
 func NewService() *Service {
-	return &Service{}
+	optionsMu.RLock()
+	defer optionsMu.RUnlock()
+	return &Service{opts: defaultOptions}
 }
 
-// setupCredentialURL injects the appropriate credential format based on VCS type.
-func setupCredentialURL(source, vcsType, connectionType, accessToken string) string {
-	if accessToken == "" || vcsType == "PUBLIC" || strings.HasPrefix(vcsType, "SSH") {
-		return source
-	}
+// NewServiceWithOptions creates a Service that ignores the configured defaults.
+func NewServiceWithOptions(opts Options) *Service {
+	return &Service{opts: opts}
+}
 
-	if !strings.HasPrefix(source, "https://") {
-		return source
+// cloneSpec describes a single clone.
+type cloneSpec struct {
+	dir            string
+	source         string
+	ref            string // branch or tag; empty for the remote's default branch
+	tag            bool
+	commit         string // checked out instead of ref when set
+	vcsType        string
+	connectionType string
+	accessToken    string
+	orgId          string // selects the known SSH host keys
+}
+
+// credentials returns the HTTPS basic auth user and password for a token.
+func credentials(vcsType, connectionType, accessToken string) (string, string, bool) {
+	if accessToken == "" || vcsType == "PUBLIC" || strings.HasPrefix(vcsType, "SSH") {
+		return "", "", false
 	}
 
-	var user string
 	switch vcsType {
 	case "GITHUB":
 		if connectionType == "OAUTH" {
-			return strings.Replace(source, "https://", fmt.Sprintf("https://%s@", accessToken), 1)
+			return accessToken, "", true
 		}
-		user = "x-access-token"
+		return "x-access-token", accessToken, true
 	case "BITBUCKET":
-		user = "x-token-auth"
+		return "x-token-auth", accessToken, true
 	case "GITLAB":
-		user = "oauth2"
+		return "oauth2", accessToken, true
 	case "AZURE_DEVOPS":
-		user = "dummy"
+		return "dummy", accessToken, true
 	default:
-		user = "oauth2"
+		return "oauth2", accessToken, true
 	}
+}
 
-	return strings.Replace(source, "https://", fmt.Sprintf("https://%s:%s@", user, accessToken), 1)
+// setupCredentialURL injects the appropriate credential format based on VCS type.
+func setupCredentialURL(source, vcsType, connectionType, accessToken string) string {
+	if !strings.HasPrefix(source, "https://") {
+		return source
+	}
+	user, password, ok := credentials(vcsType, connectionType, accessToken)
+	if !ok {
+		return source
+	}
+	if password == "" {
+		return strings.Replace(source, "https://", fmt.Sprintf("https://%s@", user), 1)
+	}
+	return strings.Replace(source, "https://", fmt.Sprintf("https://%s:%s@", user, password), 1)
+}
+
+// credentialRewrite returns git config arguments that add the token to every
+// HTTPS URL on the source's host, so submodules on that host authenticate too.
+func credentialRewrite(source, vcsType, connectionType, accessToken string) []string {
+	u, err := url.Parse(source)
+	if err != nil || u.Scheme != "https" {
+		return nil
+	}
+	plain := "https://" + u.Host + "/"
+	authed := setupCredentialURL(plain, vcsType, connectionType, accessToken)
+	if authed == plain {
+		return nil
+	}
+	return []string{"-c", "url." + authed + ".insteadOf=" + plain}
 }
 
 // setupSSHEnv prepares SSH environment for git clone when using SSH keys.
-func setupSSHEnv(vcsType, accessToken, tempDir string) ([]string, func(), error) {
+// The key and known_hosts are written to their own temp dir, outside the
+// clone destination. The returned knownHosts is nil for non-SSH clones.
+func (s *Service) setupSSHEnv(c cloneSpec) ([]string, *knownHosts, func(), error) {
 	cleanup := func() {}
 	env := os.Environ()
 
-	if !strings.HasPrefix(vcsType, "SSH") || accessToken == "" {
-		return env, cleanup, nil
+	if !strings.HasPrefix(c.vcsType, "SSH") || c.accessToken == "" {
+		return env, nil, cleanup, nil
 	}
 
-	parts := strings.SplitN(vcsType, "~", 2)
+	parts := strings.SplitN(c.vcsType, "~", 2)
 	keyName := "id_rsa"
 	if len(parts) == 2 && parts[1] != "" {
 		keyName = parts[1]
 	}
 
-	sshDir := filepath.Join(tempDir, ".ssh")
-	if err := os.MkdirAll(sshDir, 0700); err != nil {
-		return nil, cleanup, fmt.Errorf("failed to create SSH dir: %w", err)
+	sshDir, err := os.MkdirTemp("", "terrakube-ssh")
+	if err != nil {
+		return nil, nil, cleanup, fmt.Errorf("failed to create SSH dir: %w", err)
 	}
+	cleanup = func() { os.RemoveAll(sshDir) }
 
 	keyPath := filepath.Join(sshDir, keyName)
-	if err := os.WriteFile(keyPath, []byte(accessToken), 0600); err != nil {
-		return nil, cleanup, fmt.Errorf("failed to write SSH key: %w", err)
+	if err := os.WriteFile(keyPath, []byte(c.accessToken), 0600); err != nil {
+		cleanup()
+		return nil, nil, func() {}, fmt.Errorf("failed to write SSH key: %w", err)
 	}
 
-	cleanup = func() { os.RemoveAll(sshDir) }
+	hosts, err := s.prepareKnownHosts(c.orgId, sshDir)
+	if err != nil {
+		cleanup()
+		return nil, nil, func() {}, err
+	}
 
-	sshCmd := fmt.Sprintf("ssh -i %s -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null", keyPath)
+	sshCmd := fmt.Sprintf("ssh -i %s %s", keyPath, hosts.sshOptions())
 	env = append(env, "GIT_SSH_COMMAND="+sshCmd)
 
-	return env, cleanup, nil
+	return env, hosts, cleanup, nil
 }
 
-func (s *Service) CloneRepository(source, version, vcsType, accessToken, tagPrefix, folder string) (string, error) {
-	tempDir, err := os.MkdirTemp("", "terrakube-registry")
-	if err != nil {
-		return "", fmt.Errorf("failed to create temp dir: %w", err)
+func (s *Service) clone(c cloneSpec) error {
+	if s.opts.InProcess {
+		return s.cloneGoGit(c)
 	}
+	return s.cloneCLI(c)
+}
 
-	repoURL := setupCredentialURL(source, vcsType, "", accessToken)
-
-	env, sshCleanup, err := setupSSHEnv(vcsType, accessToken, tempDir)
-	if err != nil {
-		return "", err
-	}
-	defer sshCleanup()
+func (s *Service) CloneRepository(orgId, source, version, vcsType, accessToken, tagPrefix, folder string) (string, error) {
+	accessToken = appToken(orgId, source, vcsType, "", accessToken)
 
 	// Try tag with "v" prefix first, then without
-	tag := tagPrefix + "v" + version
-	cloneCmd := exec.Command("git", "clone", "--depth", "1", "--branch", tag, repoURL, tempDir)
-	cloneCmd.Env = env
-
-	if _, err := cloneCmd.CombinedOutput(); err != nil {
-		tag = tagPrefix + version
-		os.RemoveAll(tempDir)
-		tempDir, _ = os.MkdirTemp("", "terrakube-registry")
-		cloneCmd = exec.Command("git", "clone", "--depth", "1", "--branch", tag, repoURL, tempDir)
-		cloneCmd.Env = env
-		if output, err := cloneCmd.CombinedOutput(); err != nil {
-			return "", fmt.Errorf("git clone failed for tag %s: %s: %w", tag, string(output), err)
+	var tempDir string
+	var err error
+	for _, tag := range []string{tagPrefix + "v" + version, tagPrefix + version} {
+		tempDir, err = os.MkdirTemp("", "terrakube-registry")
+		if err != nil {
+			return "", fmt.Errorf("failed to create temp dir: %w", err)
 		}
+		err = s.clone(cloneSpec{
+			dir: tempDir, source: source, ref: tag, tag: true,
+			vcsType: vcsType, accessToken: accessToken, orgId: orgId,
+		})
+		if err == nil {
+			break
+		}
+		os.RemoveAll(tempDir)
+		err = fmt.Errorf("git clone failed for tag %s: %w", tag, err)
+	}
+	if err != nil {
+		return "", err
 	}
 
 	if folder != "" {
@@ -118,32 +203,27 @@ func (s *Service) CloneRepository(source, version, vcsType, accessToken, tagPref
 	return tempDir, nil
 }
 
-func (s *Service) CloneWorkspace(source, branch, vcsType, connectionType, accessToken, folder string, jobId string) (string, error) {
+// CloneWorkspace clones a workspace repository. With a commitId the clone is
+// pinned to that commit, so every step of a job runs the same code.
+func (s *Service) CloneWorkspace(source, branch, commitId, vcsType, connectionType, accessToken, folder, orgId, jobId string) (string, error) {
 	tempDir, err := os.MkdirTemp("", fmt.Sprintf("terrakube-job-%s", jobId))
 	if err != nil {
 		return "", fmt.Errorf("failed to create temp dir: %w", err)
 	}
 
-	repoURL := setupCredentialURL(source, vcsType, connectionType, accessToken)
+	if token := appToken(orgId, source, vcsType, connectionType, accessToken); token != accessToken {
+		// Installation tokens authenticate as x-access-token, not as an OAuth user
+		accessToken, connectionType = token, "STANDALONE"
+	}
 
-	env, sshCleanup, err := setupSSHEnv(vcsType, accessToken, tempDir)
+	err = s.clone(cloneSpec{
+		dir: tempDir, source: source, ref: branch, commit: commitId,
+		vcsType: vcsType, connectionType: connectionType, accessToken: accessToken, orgId: orgId,
+	})
 	if err != nil {
+		os.RemoveAll(tempDir)
 		return "", err
 	}
-	defer sshCleanup()
-
-	cmdArgs := []string{"clone", "--depth", "1"}
-	if branch != "" {
-		cmdArgs = append(cmdArgs, "--branch", branch)
-	}
-	cmdArgs = append(cmdArgs, repoURL, tempDir)
-
-	cloneCmd := exec.Command("git", cmdArgs...)
-	cloneCmd.Env = env
-
-	if output, err := cloneCmd.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("git clone failed: %s: %w", string(output), err)
-	}
 
 	finalDir := tempDir
 	if folder != "" {
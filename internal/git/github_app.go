@@ -0,0 +1,251 @@
+package git
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// tokenSkew drops cached installation tokens this long before they expire,
+// so a clone never starts with a token that runs out halfway.
+const tokenSkew = 5 * time.Minute
+
+// errNoInstallation is returned when the organization has not registered an
+// installation of the app for the repository owner.
+var errNoInstallation = errors.New("github app is not installed for repository")
+
+// AppInstallation is a github_app_token row: an installation of a GitHub App
+// that an organization registered for a repository owner.
+type AppInstallation struct {
+	AppID          string
+	InstallationID string
+	Owner          string
+}
+
+// AppInstallationStore lists the GitHub App installations of an organization.
+type AppInstallationStore interface {
+	GitHubAppInstallations(orgId string) ([]AppInstallation, error)
+}
+
+// GitHubApp mints installation access tokens for a GitHub App. An
+// organization only gets tokens for the owners it registered an installation
+// for, and each token is restricted to the cloned repository. Tokens are
+// cached per installation and repository until shortly before they expire.
+type GitHubApp struct {
+	AppID         string
+	APIURL        string
+	installations AppInstallationStore
+	key           interface{}
+	client        *http.Client
+	now           func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]installationToken
+}
+
+type installationToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// NewGitHubApp parses the app's PEM private key. apiURL defaults to
+// https://api.github.com; set it for GitHub Enterprise Server.
+func NewGitHubApp(appID, privateKeyPEM, apiURL string, installations AppInstallationStore) (*GitHubApp, error) {
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("invalid github app private key: %w", err)
+	}
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+	return &GitHubApp{
+		AppID:         appID,
+		APIURL:        strings.TrimRight(apiURL, "/"),
+		installations: installations,
+		key:           key,
+		client:        &http.Client{Timeout: 30 * time.Second},
+		now:           time.Now,
+		tokens:        make(map[string]installationToken),
+	}, nil
+}
+
+var (
+	githubAppMu sync.RWMutex
+	githubApp   *GitHubApp
+)
+
+// UseGitHubApp makes clones of GitHub repositories use installation tokens
+// of app. It is set once at startup and shared by every Service, so all
+// jobs and module downloads of the process share one token cache.
+func UseGitHubApp(app *GitHubApp) {
+	githubAppMu.Lock()
+	defer githubAppMu.Unlock()
+	githubApp = app
+}
+
+func currentGitHubApp() *GitHubApp {
+	githubAppMu.RLock()
+	defer githubAppMu.RUnlock()
+	return githubApp
+}
+
+// appToken returns an installation token for source when a GitHub App is
+// configured and the organization registered its installation on the
+// repository's owner. Connections with their own token keep using it unless
+// they are GitHub App (STANDALONE) connections.
+func appToken(orgId, source, vcsType, connectionType, accessToken string) string {
+	app := currentGitHubApp()
+	if app == nil || vcsType != "GITHUB" || (accessToken != "" && connectionType != "STANDALONE") {
+		return accessToken
+	}
+	owner, repo, ok := githubRepo(source)
+	if !ok {
+		return accessToken
+	}
+	token, err := app.Token(orgId, owner, repo)
+	if err != nil {
+		if !errors.Is(err, errNoInstallation) {
+			log.Printf("GitHub App token for %s/%s: %v", owner, repo, err)
+		}
+		return accessToken
+	}
+	return token
+}
+
+// githubRepo extracts owner and repository from an HTTPS clone URL.
+func githubRepo(source string) (string, string, bool) {
+	u, err := url.Parse(source)
+	if err != nil || u.Scheme != "https" {
+		return "", "", false
+	}
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(u.Path, ".git"), "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// Token returns a cached or freshly minted token for owner/repo from the
+// installation the organization registered for owner.
+func (a *GitHubApp) Token(orgId, owner, repo string) (string, error) {
+	if a.installations == nil {
+		return "", errNoInstallation
+	}
+	registered, err := a.installations.GitHubAppInstallations(orgId)
+	if err != nil {
+		return "", fmt.Errorf("failed to list installations: %w", err)
+	}
+	var installationID string
+	for _, i := range registered {
+		if strings.EqualFold(i.Owner, owner) && (i.AppID == "" || i.AppID == a.AppID) && i.InstallationID != "" {
+			installationID = i.InstallationID
+			break
+		}
+	}
+	if installationID == "" {
+		return "", errNoInstallation
+	}
+
+	// The lock is held while minting so concurrent clones share one token
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := installationID + "/" + strings.ToLower(owner) + "/" + strings.ToLower(repo)
+	if t, ok := a.tokens[key]; ok && a.now().Add(tokenSkew).Before(t.expiresAt) {
+		return t.token, nil
+	}
+
+	appJWT, err := a.jwt()
+	if err != nil {
+		return "", err
+	}
+
+	// The registered installation must be the app's installation on owner,
+	// not one of another account
+	var installation struct {
+		Account struct {
+			Login string `json:"login"`
+		} `json:"account"`
+	}
+	path := "/app/installations/" + url.PathEscape(installationID)
+	status, err := a.do(http.MethodGet, path, appJWT, nil, &installation)
+	if status == http.StatusNotFound {
+		return "", errNoInstallation
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up installation: %w", err)
+	}
+	if !strings.EqualFold(installation.Account.Login, owner) {
+		return "", fmt.Errorf("installation %s belongs to %s, not %s", installationID, installation.Account.Login, owner)
+	}
+
+	var token struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	request := map[string][]string{"repositories": {repo}}
+	if _, err := a.do(http.MethodPost, path+"/access_tokens", appJWT, request, &token); err != nil {
+		return "", fmt.Errorf("failed to create installation token: %w", err)
+	}
+	a.tokens[key] = installationToken{token: token.Token, expiresAt: token.ExpiresAt}
+	return token.Token, nil
+}
+
+// jwt signs the short-lived RS256 app JWT. iat is backdated for clock drift;
+// GitHub rejects JWTs valid for more than ten minutes.
+func (a *GitHubApp) jwt() (string, error) {
+	now := a.now()
+	claims := jwt.RegisteredClaims{
+		Issuer:    a.AppID,
+		IssuedAt:  jwt.NewNumericDate(now.Add(-60 * time.Second)),
+		ExpiresAt: jwt.NewNumericDate(now.Add(9 * time.Minute)),
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(a.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign github app jwt: %w", err)
+	}
+	return signed, nil
+}
+
+// do sends an app API request. payload may be nil.
+func (a *GitHubApp) do(method, path, appJWT string, payload, out interface{}) (int, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, a.APIURL+path, body)
+	if err != nil {
+		return 0, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+appJWT)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return resp.StatusCode, fmt.Errorf("github returned %d: %s", resp.StatusCode, body)
+	}
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+}
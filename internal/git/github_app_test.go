@@ -0,0 +1,117 @@
+package git
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeInstallations serves the github_app_token rows of each organization.
+type fakeInstallations map[string][]AppInstallation
+
+func (f fakeInstallations) GitHubAppInstallations(orgId string) ([]AppInstallation, error) {
+	return f[orgId], nil
+}
+
+func TestGitHubAppToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	minted := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		appJWT := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		claims := jwt.RegisteredClaims{}
+		if _, err := jwt.ParseWithClaims(appJWT, &claims, func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil }); err != nil || claims.Issuer != "42" {
+			t.Errorf("bad app jwt: %v %+v", err, claims)
+		}
+		switch {
+		case r.URL.Path == "/app/installations/7":
+			w.Write([]byte(`{"id": 7, "account": {"login": "acme"}}`))
+		case r.URL.Path == "/app/installations/8":
+			w.Write([]byte(`{"id": 8, "account": {"login": "bigcorp"}}`))
+		case r.URL.Path == "/app/installations/7/access_tokens" && r.Method == http.MethodPost:
+			var body struct {
+				Repositories []string `json:"repositories"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Repositories) != 1 || body.Repositories[0] != "infra" {
+				t.Errorf("token not restricted to the repository: %+v %v", body, err)
+			}
+			minted++
+			fmt.Fprintf(w, `{"token":"ghs_%d","expires_at":%q}`, minted, time.Now().Add(time.Hour).Format(time.RFC3339))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	installations := fakeInstallations{
+		"org-acme":   {{AppID: "42", InstallationID: "7", Owner: "acme"}},
+		"org-mallet": {{AppID: "42", InstallationID: "8", Owner: "acme"}},
+	}
+	app, err := NewGitHubApp("42", string(keyPEM), srv.URL, installations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		token, err := app.Token("org-acme", "acme", "infra")
+		if err != nil || token != "ghs_1" {
+			t.Fatalf("Token = %q, %v; want ghs_1", token, err)
+		}
+	}
+	if minted != 1 {
+		t.Errorf("minted %d tokens, want 1 (cached)", minted)
+	}
+
+	// Expired tokens are minted again
+	app.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if token, _ := app.Token("org-acme", "acme", "infra"); token != "ghs_2" {
+		t.Errorf("Token after expiry = %q, want ghs_2", token)
+	}
+
+	// Organizations only get tokens for the owners they registered
+	if _, err := app.Token("org-acme", "other", "repo"); !errors.Is(err, errNoInstallation) {
+		t.Errorf("unregistered owner: err = %v, want errNoInstallation", err)
+	}
+	if _, err := app.Token("org-other", "acme", "infra"); !errors.Is(err, errNoInstallation) {
+		t.Errorf("other organization: err = %v, want errNoInstallation", err)
+	}
+	if _, err := app.Token("org-mallet", "acme", "infra"); err == nil {
+		t.Error("installation of another account was used")
+	}
+	if minted != 2 {
+		t.Errorf("minted %d tokens, want 2", minted)
+	}
+}
+
+func TestGitHubRepo(t *testing.T) {
+	tests := []struct {
+		source      string
+		owner, repo string
+		ok          bool
+	}{
+		{"https://github.com/acme/infra.git", "acme", "infra", true},
+		{"https://github.com/acme/infra", "acme", "infra", true},
+		{"git@github.com:acme/infra.git", "", "", false},
+		{"https://github.com/acme", "", "", false},
+	}
+	for _, tt := range tests {
+		owner, repo, ok := githubRepo(tt.source)
+		if owner != tt.owner || repo != tt.repo || ok != tt.ok {
+			t.Errorf("githubRepo(%q) = %q, %q, %v", tt.source, owner, repo, ok)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Host key checking modes for SSH clones.
+const (
+	// HostKeysTOFU trusts the first key seen for a host and pins it for the
+	// organization; later clones fail when the host presents another key.
+	HostKeysTOFU = "tofu"
+	// HostKeysStrict only accepts keys already pinned for the organization.
+	HostKeysStrict = "strict"
+	// HostKeysOff disables host key checking.
+	HostKeysOff = "off"
+)
+
+var (
+	// ErrHostKeyMismatch means the host presented a key other than the pinned one.
+	ErrHostKeyMismatch = errors.New("ssh host key mismatch")
+	// ErrUnknownHost means strict checking found no pinned key for the host.
+	ErrUnknownHost = errors.New("ssh host key unknown")
+)
+
+// HostKey is one known_hosts entry; Host is "name" or "[name]:port".
+type HostKey struct {
+	Host    string
+	KeyType string
+	Key     string // base64 wire format, as in known_hosts
+}
+
+func (k HostKey) line() string { return k.Host + " " + k.KeyType + " " + k.Key }
+
+// HostKeyStore loads and pins the SSH host keys trusted by an organization.
+type HostKeyStore interface {
+	HostKeys(orgId string) ([]HostKey, error)
+	PinHostKey(orgId string, key HostKey) error
+}
+
+// knownHosts is the known_hosts file of one clone, seeded with the
+// organization's keys. Keys accepted on first use are appended to it and
+// pinned once the clone succeeds.
+type knownHosts struct {
+	mode  string
+	path  string
+	orgId string
+	store HostKeyStore
+	seen  map[string]bool
+	mu    sync.Mutex
+}
+
+// prepareKnownHosts writes the organization's host keys into dir. Strict mode
+// fails closed when the keys cannot be loaded.
+func (s *Service) prepareKnownHosts(orgId, dir string) (*knownHosts, error) {
+	mode := s.opts.HostKeyChecking
+	if mode == "" {
+		mode = HostKeysTOFU
+	}
+	k := &knownHosts{mode: mode, path: filepath.Join(dir, "known_hosts"), orgId: orgId, store: s.opts.HostKeys, seen: map[string]bool{}}
+	if mode == HostKeysOff {
+		return k, nil
+	}
+
+	var keys []HostKey
+	if k.store != nil && orgId != "" {
+		var err error
+		if keys, err = k.store.HostKeys(orgId); err != nil {
+			if mode == HostKeysStrict {
+				return nil, fmt.Errorf("failed to load known hosts: %w", err)
+			}
+			log.Printf("Warning: failed to load known hosts of organization %s: %v", orgId, err)
+		}
+	}
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key.line() + "\n")
+		k.seen[key.line()] = true
+	}
+	if err := os.WriteFile(k.path, []byte(b.String()), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write known_hosts: %w", err)
+	}
+	return k, nil
+}
+
+// sshOptions are the ssh command line options for the git binary.
+func (k *knownHosts) sshOptions() string {
+	switch k.mode {
+	case HostKeysOff:
+		return "-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null"
+	case HostKeysStrict:
+		return fmt.Sprintf("-o StrictHostKeyChecking=yes -o UserKnownHostsFile=%s -o GlobalKnownHostsFile=/dev/null", k.path)
+	default:
+		return fmt.Sprintf("-o StrictHostKeyChecking=accept-new -o HashKnownHosts=no -o UserKnownHostsFile=%s -o GlobalKnownHostsFile=/dev/null", k.path)
+	}
+}
+
+// callback is the go-git equivalent of sshOptions.
+func (k *knownHosts) callback() (ssh.HostKeyCallback, error) {
+	if k.mode == HostKeysOff {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	check, err := knownhosts.New(k.path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid known_hosts: %w", err)
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := check(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+		host := knownhosts.Normalize(hostname)
+		if len(keyErr.Want) > 0 {
+			return fmt.Errorf("%w for %s: got %s %s", ErrHostKeyMismatch, host, key.Type(), ssh.FingerprintSHA256(key))
+		}
+		if k.mode == HostKeysStrict {
+			return fmt.Errorf("%w: %s", ErrUnknownHost, host)
+		}
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		f, err := os.OpenFile(k.path, os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = fmt.Fprintln(f, knownhosts.Line([]string{host}, key))
+		return err
+	}, nil
+}
+
+// pinNew saves keys accepted on first use for the organization.
+func (k *knownHosts) pinNew() {
+	if k.mode != HostKeysTOFU || k.store == nil || k.orgId == "" {
+		return
+	}
+	f, err := os.Open(k.path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.Fields(line)
+		if len(fields) < 3 || k.seen[strings.Join(fields[:3], " ")] {
+			continue
+		}
+		key := HostKey{Host: fields[0], KeyType: fields[1], Key: fields[2]}
+		if err := k.store.PinHostKey(k.orgId, key); err != nil {
+			log.Printf("Warning: failed to pin host key of %s: %v", key.Host, err)
+			continue
+		}
+		log.Printf("Pinned %s host key of %s for organization %s", key.KeyType, key.Host, k.orgId)
+	}
+}
+
+// hostKeyError turns ssh's host key failures in git output into clear errors.
+func hostKeyError(output string) error {
+	switch {
+	case strings.Contains(output, "REMOTE HOST IDENTIFICATION HAS CHANGED"):
+		return fmt.Errorf("%w: the server key differs from the key pinned for this organization; if it was rotated, update the organization's known hosts", ErrHostKeyMismatch)
+	case strings.Contains(output, "Host key verification failed"):
+		return fmt.Errorf("%w: add the server key to the organization's known hosts", ErrUnknownHost)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package git
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+type fakeHostKeyStore struct {
+	keys   []HostKey
+	pinned []HostKey
+}
+
+func (f *fakeHostKeyStore) HostKeys(string) ([]HostKey, error) { return f.keys, nil }
+func (f *fakeHostKeyStore) PinHostKey(_ string, key HostKey) error {
+	f.pinned = append(f.pinned, key)
+	return nil
+}
+
+func newHostKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestKnownHostsCallback(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 22}
+	serverKey, otherKey := newHostKey(t), newHostKey(t)
+
+	// First use pins the key
+	store := &fakeHostKeyStore{}
+	svc := NewServiceWithOptions(Options{HostKeys: store})
+	hosts, err := svc.prepareKnownHosts("org", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	check, err := hosts.callback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := check("git.example.com:22", addr, serverKey); err != nil {
+		t.Fatalf("tofu rejected unknown host: %v", err)
+	}
+	hosts.pinNew()
+	if len(store.pinned) != 1 || store.pinned[0].Host != "git.example.com" || store.pinned[0].KeyType != ssh.KeyAlgoED25519 {
+		t.Fatalf("pinned = %+v", store.pinned)
+	}
+
+	// Later clones accept the pinned key and nothing else
+	store.keys, store.pinned = store.pinned, nil
+	for _, mode := range []string{HostKeysTOFU, HostKeysStrict} {
+		svc := NewServiceWithOptions(Options{HostKeys: store, HostKeyChecking: mode})
+		hosts, err := svc.prepareKnownHosts("org", t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		check, _ := hosts.callback()
+		if err := check("git.example.com:22", addr, serverKey); err != nil {
+			t.Errorf("%s: pinned key rejected: %v", mode, err)
+		}
+		if err := check("git.example.com:22", addr, otherKey); !errors.Is(err, ErrHostKeyMismatch) {
+			t.Errorf("%s: changed key: err = %v, want ErrHostKeyMismatch", mode, err)
+		}
+		hosts.pinNew()
+		if len(store.pinned) != 0 {
+			t.Errorf("%s: re-pinned %+v", mode, store.pinned)
+		}
+	}
+
+	// Strict mode never trusts new hosts
+	svc = NewServiceWithOptions(Options{HostKeys: &fakeHostKeyStore{}, HostKeyChecking: HostKeysStrict})
+	hosts, _ = svc.prepareKnownHosts("org", t.TempDir())
+	check, _ = hosts.callback()
+	if err := check("git.example.com:22", addr, serverKey); !errors.Is(err, ErrUnknownHost) {
+		t.Errorf("strict unknown host: err = %v, want ErrUnknownHost", err)
+	}
+}
+
+func TestHostKeyError(t *testing.T) {
+	tests := []struct {
+		output string
+		want   error
+	}{
+		{"@@@ WARNING: REMOTE HOST IDENTIFICATION HAS CHANGED! @@@\nHost key verification failed.", ErrHostKeyMismatch},
+		{"No ED25519 host key is known for example.com and you have requested strict checking.\nHost key verification failed.", ErrUnknownHost},
+		{"fatal: repository not found", nil},
+	}
+	for _, tt := range tests {
+		if err := hostKeyError(tt.output); !errors.Is(err, tt.want) || (tt.want == nil) != (err == nil) {
+			t.Errorf("hostKeyError(%q) = %v, want %v", tt.output, err, tt.want)
+		}
+	}
+}
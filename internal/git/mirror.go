@@ -0,0 +1,229 @@
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ilkerispir/terrakubed/internal/utils"
+)
+
+// MirrorCache keeps bare mirrors of remote repositories on disk. Clones fetch
+// into the mirror and check out from it locally, so repositories shared by
+// many workspaces or module versions are downloaded once and then only
+// updated. Mirrors are locked per repository and evicted least recently used
+// first once the cache grows past MaxBytes.
+//
+// A mirror is shared by every organization cloning the repository, so each
+// clone contacts the remote with its own credentials before it is served from
+// the mirror.
+type MirrorCache struct {
+	Dir      string
+	MaxBytes int64
+}
+
+// NewMirrorCache creates the cache directory. maxBytes <= 0 disables eviction.
+func NewMirrorCache(dir string, maxBytes int64) (*MirrorCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create git mirror cache: %w", err)
+	}
+	return &MirrorCache{Dir: dir, MaxBytes: maxBytes}, nil
+}
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// mirrorKey identifies a repository independently of credentials, letter
+// case of the host and a trailing ".git".
+func mirrorKey(source string) string {
+	normalized := strings.TrimSuffix(strings.TrimSuffix(source, "/"), ".git")
+	name := normalized
+	if u, err := url.Parse(normalized); err == nil && u.Host != "" {
+		u.User = nil
+		u.Host = strings.ToLower(u.Host)
+		normalized = u.String()
+		name = u.Path
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	base := unsafeName.ReplaceAllString(filepath.Base(name), "_")
+	return hex.EncodeToString(sum[:8]) + "-" + base
+}
+
+func (m *MirrorCache) path(key string) string { return filepath.Join(m.Dir, key+".git") }
+
+// checkout clones c from its mirror into c.dir, creating or updating the
+// mirror first. run executes git with the credentials of the clone.
+func (m *MirrorCache) checkout(c cloneSpec, run func(args ...string) error) error {
+	key := mirrorKey(c.source)
+	mirror := m.path(key)
+
+	unlock, err := utils.LockFile(filepath.Join(m.Dir, key+".lock"), true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Credentials are passed per command and never stored in the mirror config
+	auth := credentialRewrite(c.source, c.vcsType, c.connectionType, c.accessToken)
+	git := func(args ...string) error { return run(append(append([]string{}, auth...), args...)...) }
+
+	if _, err := os.Stat(mirror); os.IsNotExist(err) {
+		if err := initMirror(mirror, c.source, run); err != nil {
+			os.RemoveAll(mirror)
+			return err
+		}
+		if err := git("-C", mirror, "fetch", "-q", "--prune", "origin"); err != nil {
+			os.RemoveAll(mirror)
+			return err
+		}
+	} else if m.hasTarget(mirror, c, run) {
+		// Nothing to fetch, but the caller must still be able to read the
+		// repository: another organization's clone may have filled the mirror
+		if err := git("-C", mirror, "ls-remote", "-q", "origin", "HEAD"); err != nil {
+			return fmt.Errorf("failed to access %s: %w", c.source, err)
+		}
+	} else if err := git("-C", mirror, "fetch", "-q", "--prune", "origin"); err != nil {
+		return err
+	}
+	// Commits outside branches and tags, e.g. pull request heads from forks
+	if c.commit != "" && run("-C", mirror, "cat-file", "-e", c.commit+"^{commit}") != nil {
+		if err := git("-C", mirror, "fetch", "-q", "origin", c.commit); err != nil {
+			return &missingRefError{fmt.Errorf("commit %s not found: %w", c.commit, err)}
+		}
+	}
+	now := time.Now()
+	os.Chtimes(mirror, now, now)
+
+	// A local clone hardlinks the mirror's objects instead of copying them
+	if err := run("clone", "-q", "--no-checkout", mirror, c.dir); err != nil {
+		return err
+	}
+	repoURL := setupCredentialURL(c.source, c.vcsType, c.connectionType, c.accessToken)
+	if err := run("-C", c.dir, "remote", "set-url", "origin", repoURL); err != nil {
+		return err
+	}
+	if err := run("-C", c.dir, "checkout", "-q", "--detach", checkoutTarget(c)); err != nil {
+		return &missingRefError{err}
+	}
+	return nil
+}
+
+// missingRefError means the mirror is up to date but lacks the branch, tag
+// or commit, so cloning from the remote directly would not help either.
+type missingRefError struct{ err error }
+
+func (e *missingRefError) Error() string { return e.err.Error() }
+func (e *missingRefError) Unwrap() error { return e.err }
+
+func initMirror(mirror, source string, run func(args ...string) error) error {
+	// Only branches and tags: provider refs such as GitHub's refs/pull/* would
+	// multiply the size of the mirror
+	for _, args := range [][]string{
+		{"init", "-q", "--bare", mirror},
+		{"-C", mirror, "remote", "add", "origin", source},
+		{"-C", mirror, "config", "remote.origin.fetch", "+refs/heads/*:refs/heads/*"},
+		{"-C", mirror, "config", "--add", "remote.origin.fetch", "+refs/tags/*:refs/tags/*"},
+	} {
+		if err := run(args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasTarget reports whether the mirror can serve c without fetching: pinned
+// commits and tags don't move, branches always need a fetch.
+func (m *MirrorCache) hasTarget(mirror string, c cloneSpec, run func(args ...string) error) bool {
+	switch {
+	case c.commit != "":
+		return run("-C", mirror, "cat-file", "-e", c.commit+"^{commit}") == nil
+	case c.tag:
+		return run("-C", mirror, "rev-parse", "-q", "--verify", "refs/tags/"+c.ref) == nil
+	default:
+		return false
+	}
+}
+
+// checkoutTarget is the revision to check out in a clone of the mirror.
+func checkoutTarget(c cloneSpec) string {
+	switch {
+	case c.commit != "":
+		return c.commit
+	case c.tag:
+		return "refs/tags/" + c.ref
+	case c.ref != "":
+		return "refs/remotes/origin/" + c.ref
+	default:
+		return "HEAD"
+	}
+}
+
+type mirrorEntry struct {
+	key     string
+	size    int64
+	lastUse time.Time
+}
+
+// evict removes least recently used mirrors until the cache fits MaxBytes.
+// Mirrors in use by another clone are skipped.
+func (m *MirrorCache) evict() {
+	if m.MaxBytes <= 0 {
+		return
+	}
+	dirs, err := filepath.Glob(filepath.Join(m.Dir, "*.git"))
+	if err != nil {
+		return
+	}
+	var entries []mirrorEntry
+	var total int64
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil {
+			continue
+		}
+		size := dirSize(dir)
+		total += size
+		entries = append(entries, mirrorEntry{
+			key:     strings.TrimSuffix(filepath.Base(dir), ".git"),
+			size:    size,
+			lastUse: info.ModTime(),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].lastUse.Before(entries[j].lastUse) })
+
+	for _, e := range entries {
+		if total <= m.MaxBytes {
+			return
+		}
+		unlock, err := utils.LockFile(filepath.Join(m.Dir, e.key+".lock"), false)
+		if err != nil {
+			continue
+		}
+		if err := os.RemoveAll(m.path(e.key)); err == nil {
+			total -= e.size
+			log.Printf("Evicted git mirror %s (%d bytes)", e.key, e.size)
+		}
+		unlock()
+	}
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
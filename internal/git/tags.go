@@ -0,0 +1,110 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// Tag is a tag of a remote repository.
+type Tag struct {
+	Name   string
+	Commit string // the tagged commit, peeled for annotated tags
+}
+
+// ListTags lists the tags of a remote repository without cloning it.
+func (s *Service) ListTags(orgId, source, vcsType, accessToken string) ([]Tag, error) {
+	c := cloneSpec{
+		source: source, vcsType: vcsType, orgId: orgId,
+		accessToken: appToken(orgId, source, vcsType, "", accessToken),
+	}
+	if s.opts.InProcess {
+		return s.listTagsGoGit(c)
+	}
+	return s.listTagsCLI(c)
+}
+
+func (s *Service) listTagsCLI(c cloneSpec) ([]Tag, error) {
+	env, hosts, cleanup, err := s.setupSSHEnv(c)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	args := []string{"ls-remote", "--tags", setupCredentialURL(c.source, c.vcsType, c.connectionType, c.accessToken)}
+	cmd := exec.Command("git", args...)
+	cmd.Env = env
+	output, err := cmd.Output()
+	if err != nil {
+		stderr := ""
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			stderr = string(exitErr.Stderr)
+			if hostErr := hostKeyError(stderr); hostErr != nil {
+				return nil, hostErr
+			}
+		}
+		return nil, fmt.Errorf("git %s failed: %s: %w", strings.Join(redact(args), " "), strings.TrimSpace(stderr), err)
+	}
+	if hosts != nil {
+		hosts.pinNew()
+	}
+
+	var refs [][2]string
+	for _, line := range strings.Split(string(output), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			refs = append(refs, [2]string{fields[1], fields[0]})
+		}
+	}
+	return tagsFromRefs(refs), nil
+}
+
+func (s *Service) listTagsGoGit(c cloneSpec) ([]Tag, error) {
+	auth, hosts, cleanup, err := s.goGitAuth(c)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	remote := gogit.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: "origin", URLs: []string{c.source}})
+	list, err := remote.List(&gogit.ListOptions{Auth: auth, PeelingOption: gogit.AppendPeeled})
+	if err != nil {
+		return nil, fmt.Errorf("git ls-remote failed: %w", err)
+	}
+	if hosts != nil {
+		hosts.pinNew()
+	}
+
+	var refs [][2]string
+	for _, ref := range list {
+		refs = append(refs, [2]string{ref.Name().String(), ref.Hash().String()})
+	}
+	return tagsFromRefs(refs), nil
+}
+
+// tagsFromRefs picks the tags out of (ref, hash) pairs as listed by
+// ls-remote. The peeled "^{}" entry of an annotated tag replaces the hash of
+// the tag object with the commit's.
+func tagsFromRefs(refs [][2]string) []Tag {
+	var tags []Tag
+	index := map[string]int{}
+	for _, ref := range refs {
+		name, ok := strings.CutPrefix(ref[0], "refs/tags/")
+		if !ok {
+			continue
+		}
+		name, peeled := strings.CutSuffix(name, "^{}")
+		if i, seen := index[name]; seen {
+			if peeled {
+				tags[i].Commit = ref[1]
+			}
+			continue
+		}
+		index[name] = len(tags)
+		tags = append(tags, Tag{Name: name, Commit: ref[1]})
+	}
+	return tags
+}
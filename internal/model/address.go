@@ -0,0 +1,14 @@
+package model
+
+// Address types accepted by the API's address table.
+const (
+	AddressTypeResource = "resource"
+	AddressTypeData     = "data"
+)
+
+// ResourceAddress is a resource or data source touched by a job,
+// e.g. {Name: "module.vpc.aws_subnet.private[0]", Type: "resource"}.
+type ResourceAddress struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
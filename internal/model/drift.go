@@ -0,0 +1,10 @@
+package model
+
+// DriftStatus is the drift status of a workspace, written by terraformDrift
+// jobs and stored by the API.
+type DriftStatus string
+
+const (
+	DriftStatusInSync  DriftStatus = "inSync"
+	DriftStatusDrifted DriftStatus = "drifted"
+)
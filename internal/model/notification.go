@@ -0,0 +1,51 @@
+package model
+
+import "strings"
+
+// Notification destination types.
+const (
+	NotificationSlack   = "slack"
+	NotificationTeams   = "teams"
+	NotificationWebhook = "webhook"
+	NotificationEmail   = "email"
+)
+
+// Notification is a destination configured on an organization (WorkspaceId
+// empty) or on a single workspace, subscribed to a set of job events.
+type Notification struct {
+	Id              string   `json:"id"`
+	Name            string   `json:"name"`
+	DestinationType string   `json:"destinationType"`
+	Url             string   `json:"url"`
+	Token           string   `json:"token"`
+	EmailAddresses  []string `json:"emailAddresses"`
+	Events          []string `json:"events"`
+	Enabled         bool     `json:"enabled"`
+	WorkspaceId     string   `json:"workspaceId"`
+}
+
+// Subscribed reports whether the destination wants the given event. An empty
+// event list subscribes to everything.
+func (n Notification) Subscribed(event string) bool {
+	if len(n.Events) == 0 {
+		return true
+	}
+	for _, e := range n.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitList parses the comma-separated lists stored by the API
+// (events, email addresses), dropping blanks.
+func SplitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
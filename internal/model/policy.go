@@ -0,0 +1,25 @@
+package model
+
+// Policy enforcement levels, in increasing order of strictness.
+const (
+	EnforcementAdvisory      = "advisory"
+	EnforcementSoftMandatory = "soft-mandatory"
+	EnforcementHardMandatory = "hard-mandatory"
+)
+
+// PolicySet is a group of Rego policies evaluated against every plan of an
+// organization. Soft-mandatory failures can be overridden by OverrideTeam.
+type PolicySet struct {
+	Id           string   `json:"id"`
+	Name         string   `json:"name"`
+	OverrideTeam string   `json:"overrideTeam"`
+	Policies     []Policy `json:"policies"`
+}
+
+// Policy is a single Rego module. Its `deny` rule yields violation messages.
+type Policy struct {
+	Id               string `json:"id"`
+	Name             string `json:"name"`
+	Rego             string `json:"rego"`
+	EnforcementLevel string `json:"enforcementLevel"`
+}
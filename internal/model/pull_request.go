@@ -0,0 +1,13 @@
+package model
+
+// PullRequest identifies the pull/merge request a speculative plan job was
+// created for.
+type PullRequest struct {
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+	// Branch is the head branch the plan runs on.
+	Branch string `json:"branch,omitempty"`
+	// VcsApiUrl is the REST API of the workspace's VCS connection; empty
+	// selects the provider's default.
+	VcsApiUrl string `json:"vcsApiUrl,omitempty"`
+}
@@ -0,0 +1,188 @@
+package registry
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// registryCache caches API lookups of the registry. Values are the encoded
+// responses, so a backend shared by every replica can hold them. Publishing
+// deletes the keys of the versions it changes.
+type registryCache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(keys ...string)
+}
+
+// Cache keys of module and provider lookups. Segments are joined with "/",
+// which can't appear in a route parameter, so different modules never share
+// a key.
+func moduleVersionsKey(org, name, provider string) string {
+	return fmt.Sprintf("versions/%s/%s/%s", org, name, provider)
+}
+
+func moduleDownloadKey(org, name, provider, version string) string {
+	return fmt.Sprintf("download/%s/%s/%s/%s", org, name, provider, version)
+}
+
+func providerVersionsKey(org, provider string) string {
+	return fmt.Sprintf("provider-versions/%s/%s", org, provider)
+}
+
+func providerDownloadKey(org, provider, version, osName, arch string) string {
+	return fmt.Sprintf("provider-download/%s/%s/%s/%s/%s", org, provider, version, osName, arch)
+}
+
+// lruEntry is a cached value with an expiration time.
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// lruCache is a thread-safe in-memory cache with TTL that holds at most size
+// entries, evicting the least recently used.
+type lruCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+	size    int
+	ttl     time.Duration
+}
+
+func newLRUCache(size int, ttl time.Duration) *lruCache {
+	return &lruCache{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		size:    size,
+		ttl:     ttl,
+	}
+}
+
+func (c *lruCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+func (c *lruCache) Set(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &lruEntry{key: key, value: value, expiresAt: time.Now().Add(c.ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *lruCache) Delete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if element, ok := c.entries[key]; ok {
+			c.remove(element)
+		}
+	}
+}
+
+func (c *lruCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*lruEntry).key)
+}
+
+// redisCache shares cached lookups, and their invalidation, between registry
+// replicas. Redis errors are treated as cache misses.
+type redisCache struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// redisTimeout bounds each cache operation, so an unreachable Redis slows
+// requests down by at most this much.
+const redisTimeout = time.Second
+
+func newRedisCache(client *redis.Client, ttl time.Duration) *redisCache {
+	return &redisCache{client: client, prefix: "terrakube:registry:", ttl: ttl}
+}
+
+func (c *redisCache) Get(key string) ([]byte, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Registry cache: get %s: %v", key, err)
+		}
+		return nil, false
+	}
+	return value, true
+}
+
+func (c *redisCache) Set(key string, value []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := c.client.Set(ctx, c.prefix+key, value, c.ttl).Err(); err != nil {
+		log.Printf("Registry cache: set %s: %v", key, err)
+	}
+}
+
+func (c *redisCache) Delete(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := c.client.Del(ctx, prefixed...).Err(); err != nil {
+		log.Printf("Registry cache: delete %v: %v", keys, err)
+	}
+}
+
+// newRegistryCache returns the cache selected by REGISTRY_CACHE: "redis"
+// shares entries between replicas and falls back to memory when Redis is not
+// reachable.
+func newRegistryCache(backend, redisAddress, redisPassword string, size int, ttl time.Duration) registryCache {
+	if backend == "redis" {
+		if redisAddress == "" {
+			log.Printf("Warning: REGISTRY_CACHE=redis without a Redis address, caching in memory")
+			return newLRUCache(size, ttl)
+		}
+		client := redis.NewClient(&redis.Options{Addr: redisAddress, Password: redisPassword})
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			log.Printf("Warning: Redis not reachable at %s (%v), caching in memory", redisAddress, err)
+			client.Close()
+			return newLRUCache(size, ttl)
+		}
+		log.Printf("Registry cache: Redis at %s (TTL %s)", redisAddress, ttl)
+		return newRedisCache(client, ttl)
+	}
+	log.Printf("Registry cache: in memory, %d entries (TTL %s)", size, ttl)
+	return newLRUCache(size, ttl)
+}
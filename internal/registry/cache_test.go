@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		ops     func(c *lruCache)
+		present []string
+		absent  []string
+	}{
+		{"evicts least recently used", time.Minute, func(c *lruCache) {
+			c.Set("a", []byte("1"))
+			c.Set("b", []byte("2"))
+			c.Get("a")
+			c.Set("c", []byte("3"))
+		}, []string{"a", "c"}, []string{"b"}},
+		{"overwrite does not grow", time.Minute, func(c *lruCache) {
+			c.Set("a", []byte("1"))
+			c.Set("a", []byte("2"))
+			c.Set("b", []byte("3"))
+		}, []string{"a", "b"}, nil},
+		{"delete", time.Minute, func(c *lruCache) {
+			c.Set("a", []byte("1"))
+			c.Set("b", []byte("2"))
+			c.Delete("a", "b", "missing")
+		}, nil, []string{"a", "b"}},
+		{"expired", -time.Second, func(c *lruCache) {
+			c.Set("a", []byte("1"))
+		}, nil, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newLRUCache(2, tt.ttl)
+			tt.ops(c)
+			for _, key := range tt.present {
+				if _, ok := c.Get(key); !ok {
+					t.Errorf("%s missing", key)
+				}
+			}
+			for _, key := range tt.absent {
+				if _, ok := c.Get(key); ok {
+					t.Errorf("%s still cached", key)
+				}
+			}
+			if len(c.entries) != c.order.Len() || len(c.entries) > 2 {
+				t.Errorf("%d entries, %d in order", len(c.entries), c.order.Len())
+			}
+		})
+	}
+
+	c := newLRUCache(2, time.Minute)
+	c.Set("a", []byte("1"))
+	c.Set("a", []byte("2"))
+	if got, _ := c.Get("a"); string(got) != "2" {
+		t.Errorf("a = %q, want the latest value", got)
+	}
+}
+
+func TestCacheKeysAreUnambiguous(t *testing.T) {
+	pairs := [][2]string{
+		{moduleVersionsKey("a-b", "c", "aws"), moduleVersionsKey("a", "b-c", "aws")},
+		{moduleDownloadKey("acme", "vpc-eks", "aws", "1.0.0"), moduleDownloadKey("acme", "vpc", "eks-aws", "1.0.0")},
+		{providerVersionsKey("a-b", "c"), providerVersionsKey("a", "b-c")},
+		{providerDownloadKey("acme", "aws", "1.0.0", "linux-amd64", "arm64"), providerDownloadKey("acme", "aws", "1.0.0", "linux", "amd64-arm64")},
+	}
+	for _, p := range pairs {
+		if p[0] == p[1] {
+			t.Errorf("different lookups share the key %q", p[0])
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package registry
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ilkerispir/terrakubed/internal/client"
+)
+
+// downloadSink is the part of the API client that stores download events.
+type downloadSink interface {
+	RecordDownloads(events []client.DownloadEvent) error
+}
+
+// downloadRecorder reports downloads to the API in the background, so
+// serving a download never waits on it. Events are sent in batches every
+// flushInterval or once batchSize are queued. When the queue is full, or the
+// API rejects a batch, the events are dropped: statistics are best effort.
+type downloadRecorder struct {
+	sink          downloadSink
+	events        chan client.DownloadEvent
+	batchSize     int
+	flushInterval time.Duration
+	dropped       atomic.Int64
+}
+
+func newDownloadRecorder(sink downloadSink, queueSize int) *downloadRecorder {
+	return &downloadRecorder{
+		sink:          sink,
+		events:        make(chan client.DownloadEvent, queueSize),
+		batchSize:     100,
+		flushInterval: 10 * time.Second,
+	}
+}
+
+// Record queues a download without blocking.
+func (r *downloadRecorder) Record(event client.DownloadEvent) {
+	select {
+	case r.events <- event:
+	default:
+		if r.dropped.Add(1)%1000 == 1 {
+			log.Printf("Warning: download statistics queue is full, %d events dropped", r.dropped.Load())
+		}
+	}
+}
+
+// recordRequest queues a download served for the request. A nil recorder
+// records nothing.
+func (r *downloadRecorder) recordRequest(c *gin.Context, event client.DownloadEvent) {
+	if r == nil {
+		return
+	}
+	event.ClientIP = c.ClientIP()
+	event.UserAgent = c.Request.UserAgent()
+	event.Time = time.Now().UTC()
+	r.Record(event)
+}
+
+// Start sends queued downloads until ctx is done, then sends what is left.
+func (r *downloadRecorder) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]client.DownloadEvent, 0, r.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := r.sink.RecordDownloads(batch); err != nil {
+			log.Printf("Download statistics: %v", err)
+		}
+		batch = make([]client.DownloadEvent, 0, r.batchSize)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-r.events:
+					batch = append(batch, event)
+					if len(batch) == r.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		case event := <-r.events:
+			batch = append(batch, event)
+			if len(batch) == r.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ilkerispir/terrakubed/internal/client"
+)
+
+// fakeDownloadSink records the batches it receives.
+type fakeDownloadSink struct {
+	mu      sync.Mutex
+	batches [][]client.DownloadEvent
+}
+
+func (f *fakeDownloadSink) RecordDownloads(events []client.DownloadEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, events)
+	return nil
+}
+
+func TestDownloadRecorder(t *testing.T) {
+	tests := []struct {
+		name        string
+		queueSize   int
+		record      int
+		wantBatches []int
+	}{
+		{"flushed on shutdown", 10, 1, []int{1}},
+		{"batched", 10, 5, []int{2, 2, 1}},
+		{"full queue drops events", 4, 6, []int{2, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &fakeDownloadSink{}
+			r := newDownloadRecorder(sink, tt.queueSize)
+			r.batchSize = 2
+			r.flushInterval = time.Hour
+
+			// Queued before Start, so a full queue drops
+			for i := 0; i < tt.record; i++ {
+				r.Record(client.DownloadEvent{Type: "module", Version: "1.0.0"})
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			r.Start(ctx)
+
+			var got []int
+			for _, batch := range sink.batches {
+				got = append(got, len(batch))
+			}
+			if len(got) != len(tt.wantBatches) {
+				t.Fatalf("batches = %v, want %v", got, tt.wantBatches)
+			}
+			for i := range got {
+				if got[i] != tt.wantBatches[i] {
+					t.Fatalf("batches = %v, want %v", got, tt.wantBatches)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadRecorderRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := newDownloadRecorder(&fakeDownloadSink{}, 1)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.RemoteAddr = "10.0.0.7:1234"
+	c.Request.Header.Set("User-Agent", "Terraform/1.9.0")
+
+	r.recordRequest(c, client.DownloadEvent{Type: "provider", Name: "acme"})
+	event := <-r.events
+	if event.ClientIP != "10.0.0.7" || event.UserAgent != "Terraform/1.9.0" || event.Time.IsZero() {
+		t.Errorf("event = %+v", event)
+	}
+
+	var disabled *downloadRecorder
+	disabled.recordRequest(c, client.DownloadEvent{}) // must not panic
+}
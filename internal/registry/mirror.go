@@ -0,0 +1,512 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/gin-gonic/gin"
+	"github.com/ilkerispir/terrakubed/internal/storage"
+	"golang.org/x/sync/singleflight"
+)
+
+// providerMirror serves the Provider Network Mirror Protocol
+// (https://developer.hashicorp.com/terraform/internals/provider-network-mirror-protocol):
+//
+//	GET /terraform/mirror/v1/{hostname}/{namespace}/{type}/index.json       → available versions
+//	GET /terraform/mirror/v1/{hostname}/{namespace}/{type}/{version}.json   → package per platform
+//	GET /terraform/mirror/v1/{hostname}/{namespace}/{type}/{version}/{file} → provider package
+//
+// Providers are proxied from allow-listed upstream registries. Version documents
+// and packages are copied into registry storage on first use, so executors
+// without internet access keep installing them. The version index is refreshed
+// from upstream while it is reachable and served from storage otherwise.
+type providerMirror struct {
+	storage   storage.StorageService
+	upstreams map[string]bool
+	client    *http.Client
+
+	discovery *lruCache // hostname → providers.v1 base URL
+	indexes   *lruCache // storage key → index.json
+	fetches   singleflight.Group
+}
+
+// errMirrorNotFound is returned for providers, versions or packages the
+// upstream registry does not have.
+var errMirrorNotFound = errors.New("not found")
+
+// pathSegment matches namespaces, types, versions and package file names;
+// anything else never reaches upstream URLs or storage keys.
+var pathSegment = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+-]*$`)
+
+func newProviderMirror(store storage.StorageService, upstreams string) *providerMirror {
+	m := &providerMirror{
+		storage:   store,
+		upstreams: make(map[string]bool),
+		client:    &http.Client{Timeout: 10 * time.Minute},
+		discovery: newLRUCache(100, time.Hour),
+		indexes:   newLRUCache(1000, 10*time.Minute),
+	}
+	for _, host := range strings.Split(upstreams, ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			m.upstreams[host] = true
+		}
+	}
+	return m
+}
+
+// register adds the mirror routes to g.
+func (m *providerMirror) register(g gin.IRoutes) {
+	g.GET("/terraform/mirror/v1/:hostname/:namespace/:type/:version", m.handleMetadata)
+	g.GET("/terraform/mirror/v1/:hostname/:namespace/:type/:version/:file", m.handlePackage)
+}
+
+// mirrorProvider is a provider address: hostname/namespace/type.
+type mirrorProvider struct {
+	host, namespace, typ string
+}
+
+func (p mirrorProvider) String() string {
+	return p.host + "/" + p.namespace + "/" + p.typ
+}
+
+// key returns the storage key of a mirrored file of the provider.
+func (p mirrorProvider) key(parts ...string) string {
+	return path.Join(append([]string{"registry/mirror", p.host, p.namespace, p.typ}, parts...)...)
+}
+
+func (m *providerMirror) provider(c *gin.Context) (mirrorProvider, bool) {
+	p := mirrorProvider{
+		host:      strings.ToLower(c.Param("hostname")),
+		namespace: strings.ToLower(c.Param("namespace")),
+		typ:       strings.ToLower(c.Param("type")),
+	}
+	return p, m.upstreams[p.host] && pathSegment.MatchString(p.namespace) && pathSegment.MatchString(p.typ)
+}
+
+func (m *providerMirror) handleMetadata(c *gin.Context) {
+	p, ok := m.provider(c)
+	name := c.Param("version")
+	version := strings.TrimSuffix(name, ".json")
+	if !ok || version == name || !pathSegment.MatchString(version) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+
+	var body []byte
+	var err error
+	if name == "index.json" {
+		body, err = m.index(p)
+	} else {
+		body, err = m.version(p, version)
+	}
+	if err != nil {
+		mirrorError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", body)
+}
+
+func (m *providerMirror) handlePackage(c *gin.Context) {
+	p, ok := m.provider(c)
+	version, file := c.Param("version"), c.Param("file")
+	if !ok || !pathSegment.MatchString(version) || !pathSegment.MatchString(file) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+
+	reader, err := m.archive(p, version, file)
+	if err != nil {
+		mirrorError(c, err)
+		return
+	}
+	defer reader.Close()
+	c.DataFromReader(http.StatusOK, -1, "application/zip", reader, nil)
+}
+
+func mirrorError(c *gin.Context, err error) {
+	if errors.Is(err, errMirrorNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	log.Printf("Provider mirror: %v", err)
+	c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to mirror provider"})
+}
+
+// mirrorIndex is index.json: the versions as keys of empty objects.
+type mirrorIndex struct {
+	Versions map[string]struct{} `json:"versions"`
+}
+
+// mirrorVersion is {version}.json: one package per os_arch.
+type mirrorVersion struct {
+	Archives map[string]mirrorArchive `json:"archives"`
+}
+
+type mirrorArchive struct {
+	URL    string   `json:"url"`
+	Hashes []string `json:"hashes"`
+}
+
+func (m *providerMirror) index(p mirrorProvider) ([]byte, error) {
+	key := p.key("index.json")
+	if cached, ok := m.indexes.Get(key); ok {
+		return cached, nil
+	}
+
+	versions, err := m.upstreamVersions(p)
+	if err != nil {
+		if errors.Is(err, errMirrorNotFound) {
+			return nil, err
+		}
+		body, storedErr := m.stored(key)
+		if storedErr != nil {
+			return nil, err
+		}
+		log.Printf("Provider mirror: %s upstream unavailable, serving stored index: %v", p, err)
+		return body, nil
+	}
+
+	doc := mirrorIndex{Versions: make(map[string]struct{}, len(versions))}
+	for _, v := range versions {
+		doc.Versions[v.Version] = struct{}{}
+	}
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.storage.UploadFile(key, bytes.NewReader(body)); err != nil {
+		log.Printf("Provider mirror: failed to store %s: %v", key, err)
+	}
+	m.indexes.Set(key, body)
+	return body, nil
+}
+
+// version returns the {version}.json document. Released versions do not
+// change, so a stored document is served without asking upstream.
+func (m *providerMirror) version(p mirrorProvider, version string) ([]byte, error) {
+	key := p.key(version + ".json")
+	if body, err := m.stored(key); err == nil {
+		return body, nil
+	}
+	body, err, _ := m.fetches.Do(key, func() (interface{}, error) {
+		return m.fetchVersion(p, version)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return body.([]byte), nil
+}
+
+func (m *providerMirror) fetchVersion(p mirrorProvider, version string) ([]byte, error) {
+	versions, err := m.upstreamVersions(p)
+	if err != nil {
+		return nil, err
+	}
+	var platforms []upstreamPlatform
+	found := false
+	for _, v := range versions {
+		if v.Version == version {
+			platforms, found = v.Platforms, true
+			break
+		}
+	}
+	if !found {
+		return nil, errMirrorNotFound
+	}
+
+	doc := mirrorVersion{Archives: make(map[string]mirrorArchive, len(platforms))}
+	packages := make(map[string]*upstreamPackage, len(platforms))
+	for _, platform := range platforms {
+		pkg, err := m.upstreamPackage(p, version, platform)
+		if err != nil {
+			return nil, err
+		}
+		if !pathSegment.MatchString(pkg.Filename) {
+			return nil, fmt.Errorf("%s %s: invalid package file name %q", p, version, pkg.Filename)
+		}
+		// Relative to {version}.json, i.e. {version}/{file}.
+		doc.Archives[platform.OS+"_"+platform.Arch] = mirrorArchive{
+			URL:    version + "/" + pkg.Filename,
+			Hashes: []string{"zh:" + pkg.Shasum},
+		}
+		packages[pkg.Filename] = pkg
+	}
+
+	// Package details first: the version document marks the version as mirrored.
+	details, err := json.Marshal(packages)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.storage.UploadFile(p.key(version, "packages.json"), bytes.NewReader(details)); err != nil {
+		return nil, fmt.Errorf("failed to store %s %s packages: %w", p, version, err)
+	}
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.storage.UploadFile(p.key(version+".json"), bytes.NewReader(body)); err != nil {
+		return nil, fmt.Errorf("failed to store %s %s: %w", p, version, err)
+	}
+	return body, nil
+}
+
+// archive returns a provider package, copying it into storage first if needed.
+func (m *providerMirror) archive(p mirrorProvider, version, file string) (io.ReadCloser, error) {
+	key := p.key(version, file)
+	if reader, err := m.storage.DownloadFile(key); err == nil {
+		return reader, nil
+	}
+	if _, err, _ := m.fetches.Do(key, func() (interface{}, error) {
+		return nil, m.fetchArchive(p, version, file)
+	}); err != nil {
+		return nil, err
+	}
+	return m.storage.DownloadFile(key)
+}
+
+func (m *providerMirror) fetchArchive(p mirrorProvider, version, file string) error {
+	if _, err := m.version(p, version); err != nil {
+		return err
+	}
+	details, err := m.stored(p.key(version, "packages.json"))
+	if err != nil {
+		return fmt.Errorf("failed to read %s %s packages: %w", p, version, err)
+	}
+	var packages map[string]*upstreamPackage
+	if err := json.Unmarshal(details, &packages); err != nil {
+		return fmt.Errorf("failed to parse %s %s packages: %w", p, version, err)
+	}
+	pkg, ok := packages[file]
+	if !ok {
+		return errMirrorNotFound
+	}
+
+	if err := m.verifyShasums(pkg); err != nil {
+		return fmt.Errorf("%s %s: %w", p, version, err)
+	}
+
+	tmp, err := os.CreateTemp("", "provider-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	resp, err := m.get(pkg.DownloadURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), resp.Body); err != nil {
+		return fmt.Errorf("failed to download %s: %w", pkg.DownloadURL, err)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != pkg.Shasum {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", file, sum, pkg.Shasum)
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	key := p.key(version, file)
+	if err := m.storage.UploadFile(key, tmp); err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	log.Printf("Provider mirror: stored %s", key)
+	return nil
+}
+
+// verifyShasums checks the package checksum against the release's SHA256SUMS
+// document and that document against the signing keys, as terraform init does.
+func (m *providerMirror) verifyShasums(pkg *upstreamPackage) error {
+	sums, err := m.fetch(pkg.ShasumsURL)
+	if err != nil {
+		return err
+	}
+	signature, err := m.fetch(pkg.ShasumsSignatureURL)
+	if err != nil {
+		return err
+	}
+	if err := verifySignature(sums, signature, pkg.SigningKeys.GPGPublicKeys); err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(sums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == pkg.Filename {
+			if fields[0] != pkg.Shasum {
+				return fmt.Errorf("checksum of %s does not match SHA256SUMS", pkg.Filename)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not listed in SHA256SUMS", pkg.Filename)
+}
+
+// verifySignature checks a detached signature over signed against any of the
+// ASCII-armored public keys.
+func verifySignature(signed, signature []byte, keys []upstreamKey) error {
+	var keyring openpgp.EntityList
+	for _, key := range keys {
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key.ASCIIArmor))
+		if err != nil {
+			return fmt.Errorf("invalid signing key %s: %w", key.KeyID, err)
+		}
+		keyring = append(keyring, entities...)
+	}
+	if len(keyring) == 0 {
+		return errors.New("no signing keys")
+	}
+	if _, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(signed), bytes.NewReader(signature), nil); err != nil {
+		return fmt.Errorf("invalid SHA256SUMS signature: %w", err)
+	}
+	return nil
+}
+
+// stored reads a whole file from registry storage.
+func (m *providerMirror) stored(key string) ([]byte, error) {
+	reader, err := m.storage.DownloadFile(key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// --- upstream registry (provider registry protocol) ---
+
+type upstreamVersion struct {
+	Version   string             `json:"version"`
+	Platforms []upstreamPlatform `json:"platforms"`
+}
+
+type upstreamPlatform struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+}
+
+type upstreamKey struct {
+	KeyID      string `json:"key_id"`
+	ASCIIArmor string `json:"ascii_armor"`
+}
+
+type upstreamPackage struct {
+	Filename            string `json:"filename"`
+	DownloadURL         string `json:"download_url"`
+	Shasum              string `json:"shasum"`
+	ShasumsURL          string `json:"shasums_url"`
+	ShasumsSignatureURL string `json:"shasums_signature_url"`
+	SigningKeys         struct {
+		GPGPublicKeys []upstreamKey `json:"gpg_public_keys"`
+	} `json:"signing_keys"`
+}
+
+// providersURL resolves the upstream's providers.v1 service.
+func (m *providerMirror) providersURL(host string) (*url.URL, error) {
+	if cached, ok := m.discovery.Get(host); ok {
+		return url.Parse(string(cached))
+	}
+	wellKnown := &url.URL{Scheme: "https", Host: host, Path: "/.well-known/terraform.json"}
+	var services struct {
+		Providers string `json:"providers.v1"`
+	}
+	if err := m.getJSON(wellKnown.String(), &services); err != nil {
+		return nil, fmt.Errorf("service discovery for %s: %w", host, err)
+	}
+	if services.Providers == "" {
+		return nil, fmt.Errorf("%s does not offer providers.v1", host)
+	}
+	base, err := wellKnown.Parse(services.Providers)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	m.discovery.Set(host, []byte(base.String()))
+	return base, nil
+}
+
+func (m *providerMirror) upstreamVersions(p mirrorProvider) ([]upstreamVersion, error) {
+	base, err := m.providersURL(p.host)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		Versions []upstreamVersion `json:"versions"`
+	}
+	if err := m.getJSON(base.JoinPath(p.namespace, p.typ, "versions").String(), &out); err != nil {
+		return nil, err
+	}
+	return out.Versions, nil
+}
+
+func (m *providerMirror) upstreamPackage(p mirrorProvider, version string, platform upstreamPlatform) (*upstreamPackage, error) {
+	base, err := m.providersURL(p.host)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := base.JoinPath(p.namespace, p.typ, version, "download", platform.OS, platform.Arch)
+	var pkg upstreamPackage
+	if err := m.getJSON(endpoint.String(), &pkg); err != nil {
+		return nil, err
+	}
+	// The URLs may be relative to the download endpoint.
+	for _, u := range []*string{&pkg.DownloadURL, &pkg.ShasumsURL, &pkg.ShasumsSignatureURL} {
+		resolved, err := endpoint.Parse(*u)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL %q in %s: %w", *u, endpoint, err)
+		}
+		*u = resolved.String()
+	}
+	return &pkg, nil
+}
+
+func (m *providerMirror) get(u string) (*http.Response, error) {
+	resp, err := m.client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, errMirrorNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: status %d", u, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+func (m *providerMirror) fetch(u string) ([]byte, error) {
+	resp, err := m.get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func (m *providerMirror) getJSON(u string, out interface{}) error {
+	body, err := m.fetch(u)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", u, err)
+	}
+	return nil
+}
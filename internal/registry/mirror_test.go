@@ -0,0 +1,248 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/gin-gonic/gin"
+	"github.com/ilkerispir/terrakubed/internal/storage"
+)
+
+// memStorage is an in-memory storage.StorageService.
+type memStorage struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func (s *memStorage) SearchModule(org, orgId, module, provider, version, source, vcsType, accessToken, tagPrefix, folder string) (string, error) {
+	return "", fmt.Errorf("not supported")
+}
+
+func (s *memStorage) DownloadModule(org, module, provider, version string) (io.ReadCloser, error) {
+	return s.DownloadFile(storage.ModuleKey(org, module, provider, version))
+}
+
+func (s *memStorage) UploadFile(path string, content io.Reader) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[path] = data
+	return nil
+}
+
+func (s *memStorage) DownloadFile(path string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.files[path]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", path)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// fakeUpstream is a provider registry serving hashicorp/null 1.0.0 for linux_amd64.
+type fakeUpstream struct {
+	*httptest.Server
+	pkg       []byte // served package; tests may tamper with it
+	sums      []byte
+	signature []byte
+	publicKey string
+	down      bool
+}
+
+func newFakeUpstream(t *testing.T) *fakeUpstream {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Test Signer", "", "signer@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pub bytes.Buffer
+	w, err := armor.Encode(&pub, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	u := &fakeUpstream{pkg: []byte("provider package"), publicKey: pub.String()}
+	sum := sha256.Sum256(u.pkg)
+	u.sums = []byte(hex.EncodeToString(sum[:]) + "  terraform-provider-null_1.0.0_linux_amd64.zip\n")
+	var sig bytes.Buffer
+	if err := openpgp.DetachSign(&sig, entity, bytes.NewReader(u.sums), nil); err != nil {
+		t.Fatal(err)
+	}
+	u.signature = sig.Bytes()
+
+	u.Server = httptest.NewTLSServer(http.HandlerFunc(u.serve))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func (u *fakeUpstream) host() string {
+	return strings.TrimPrefix(u.URL, "https://")
+}
+
+func (u *fakeUpstream) serve(w http.ResponseWriter, r *http.Request) {
+	if u.down {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	// The registry advertises the checksum of the genuine package.
+	sum := sha256.Sum256([]byte("provider package"))
+	switch r.URL.Path {
+	case "/.well-known/terraform.json":
+		fmt.Fprint(w, `{"providers.v1": "/v1/providers/"}`)
+	case "/v1/providers/hashicorp/null/versions":
+		fmt.Fprint(w, `{"versions": [{"version": "1.0.0", "platforms": [{"os": "linux", "arch": "amd64"}]}]}`)
+	case "/v1/providers/hashicorp/null/1.0.0/download/linux/amd64":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"filename":              "terraform-provider-null_1.0.0_linux_amd64.zip",
+			"download_url":          "/files/null.zip",
+			"shasum":                hex.EncodeToString(sum[:]),
+			"shasums_url":           "/files/SHA256SUMS",
+			"shasums_signature_url": "/files/SHA256SUMS.sig",
+			"signing_keys": map[string]interface{}{
+				"gpg_public_keys": []map[string]string{{"key_id": "TEST", "ascii_armor": u.publicKey}},
+			},
+		})
+	case "/files/null.zip":
+		w.Write(u.pkg)
+	case "/files/SHA256SUMS":
+		w.Write(u.sums)
+	case "/files/SHA256SUMS.sig":
+		w.Write(u.signature)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestMirror(u *fakeUpstream, store *memStorage) (*gin.Engine, *memStorage) {
+	gin.SetMode(gin.TestMode)
+	if store == nil {
+		store = &memStorage{files: map[string][]byte{}}
+	}
+	m := newProviderMirror(store, "registry.terraform.io, "+u.host())
+	m.client = u.Client()
+	r := gin.New()
+	m.register(r)
+	return r, store
+}
+
+func mirrorGet(r http.Handler, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestProviderMirror(t *testing.T) {
+	u := newFakeUpstream(t)
+	r, store := newTestMirror(u, nil)
+	base := "/terraform/mirror/v1/" + u.host() + "/hashicorp/null/"
+
+	rec := mirrorGet(r, base+"index.json")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"versions":{"1.0.0":{}}}` {
+		t.Fatalf("index.json = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = mirrorGet(r, base+"1.0.0.json")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("1.0.0.json = %d %s", rec.Code, rec.Body.String())
+	}
+	var doc mirrorVersion
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(u.pkg)
+	archive := doc.Archives["linux_amd64"]
+	if archive.URL != "1.0.0/terraform-provider-null_1.0.0_linux_amd64.zip" {
+		t.Errorf("archive url = %q", archive.URL)
+	}
+	if len(archive.Hashes) != 1 || archive.Hashes[0] != "zh:"+hex.EncodeToString(sum[:]) {
+		t.Errorf("archive hashes = %v", archive.Hashes)
+	}
+
+	rec = mirrorGet(r, base+archive.URL)
+	if rec.Code != http.StatusOK || rec.Body.String() != "provider package" {
+		t.Fatalf("package = %d %q", rec.Code, rec.Body.String())
+	}
+	key := "registry/mirror/" + u.host() + "/hashicorp/null/1.0.0/terraform-provider-null_1.0.0_linux_amd64.zip"
+	if _, ok := store.files[key]; !ok {
+		t.Errorf("package not stored at %s", key)
+	}
+
+	// Air-gapped: everything mirrored so far is served from storage.
+	u.down = true
+	r2, _ := newTestMirror(u, store)
+	for _, p := range []string{"index.json", "1.0.0.json", archive.URL} {
+		if rec := mirrorGet(r2, base+p); rec.Code != http.StatusOK {
+			t.Errorf("%s with upstream down = %d %s", p, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestProviderMirrorRejectsTamperedPackage(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(u *fakeUpstream)
+	}{
+		{"package", func(u *fakeUpstream) { u.pkg = []byte("malicious package") }},
+		{"SHA256SUMS", func(u *fakeUpstream) { u.sums = bytes.Replace(u.sums, []byte("null"), []byte("nul1"), 1) }},
+		{"signature", func(u *fakeUpstream) { u.signature = []byte("not a signature") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newFakeUpstream(t)
+			tt.tamper(u)
+			r, store := newTestMirror(u, nil)
+
+			rec := mirrorGet(r, "/terraform/mirror/v1/"+u.host()+"/hashicorp/null/1.0.0/terraform-provider-null_1.0.0_linux_amd64.zip")
+			if rec.Code != http.StatusBadGateway {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusBadGateway)
+			}
+			for key := range store.files {
+				if strings.HasSuffix(key, ".zip") {
+					t.Errorf("tampered package stored at %s", key)
+				}
+			}
+		})
+	}
+}
+
+func TestProviderMirrorNotFound(t *testing.T) {
+	u := newFakeUpstream(t)
+	r, _ := newTestMirror(u, nil)
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"host not allowed", "/terraform/mirror/v1/evil.example.com/hashicorp/null/index.json"},
+		{"unknown provider", "/terraform/mirror/v1/" + u.host() + "/hashicorp/missing/index.json"},
+		{"unknown version", "/terraform/mirror/v1/" + u.host() + "/hashicorp/null/9.9.9.json"},
+		{"not a document", "/terraform/mirror/v1/" + u.host() + "/hashicorp/null/1.0.0"},
+		{"unknown package", "/terraform/mirror/v1/" + u.host() + "/hashicorp/null/1.0.0/other.zip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := mirrorGet(r, tt.path); rec.Code != http.StatusNotFound {
+				t.Errorf("status = %d, want 404 (%s)", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
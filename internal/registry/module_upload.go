@@ -0,0 +1,320 @@
+package registry
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	goversion "github.com/hashicorp/go-version"
+	"github.com/ilkerispir/terrakubed/internal/client"
+	"github.com/ilkerispir/terrakubed/internal/storage"
+)
+
+// moduleAPI is the part of the API client used to publish module versions.
+type moduleAPI interface {
+	GetModule(organization, module, provider string) (*client.ModuleDetails, string, error)
+	ModuleVersionExists(moduleId, version string) (bool, error)
+	CreateModuleVersion(moduleId, version, commit string) error
+}
+
+// moduleUploader publishes module versions from uploaded archives, for
+// pipelines that do not tag a git repository:
+//
+//	POST /terraform/modules/v1/{org}/{name}/{provider}/{version}/upload  → zip or tar.gz as the request body
+//
+// The archive is validated and normalized to the module.zip a tag clone
+// produces, stored under the same key and registered as a module version, so
+// the regular download endpoint serves it right away. Its documentation is
+// generated at the same time.
+type moduleUploader struct {
+	storage storage.StorageService
+	api     moduleAPI
+	cache   registryCache
+	docs    *moduleDocs
+	maxSize int64 // applies to the upload and to its extracted content
+}
+
+var (
+	errInvalidModule  = errors.New("invalid module archive")
+	errModuleTooLarge = errors.New("module archive too large")
+)
+
+func newModuleUploader(store storage.StorageService, api moduleAPI, cache registryCache, docs *moduleDocs, maxSize int64) *moduleUploader {
+	return &moduleUploader{storage: store, api: api, cache: cache, docs: docs, maxSize: maxSize}
+}
+
+// register adds the upload route, behind authorize, to protected.
+func (u *moduleUploader) register(protected gin.IRoutes, authorize gin.HandlerFunc) {
+	protected.POST("/terraform/modules/v1/:org/:name/:provider/:version/upload", authorize, u.handleUpload)
+}
+
+func (u *moduleUploader) handleUpload(c *gin.Context) {
+	org, name, provider, version := c.Param("org"), c.Param("name"), c.Param("provider"), c.Param("version")
+	for _, segment := range []string{org, name, provider, version} {
+		if !pathSegment.MatchString(segment) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid path segment %q", segment)})
+			return
+		}
+	}
+	if _, err := goversion.NewSemver(version); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid version %q", version)})
+		return
+	}
+
+	module, _, err := u.api.GetModule(org, name, provider)
+	if err != nil {
+		log.Printf("Module upload: %v", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Module not found"})
+		return
+	}
+	exists, err := u.api.ModuleVersionExists(module.ID, version)
+	if err != nil {
+		log.Printf("Module upload: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check module versions"})
+		return
+	}
+	if exists {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("version %s already exists", version)})
+		return
+	}
+
+	upload, err := io.ReadAll(io.LimitReader(c.Request.Body, u.maxSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read upload"})
+		return
+	}
+	if int64(len(upload)) > u.maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("module archives are limited to %d bytes", u.maxSize)})
+		return
+	}
+	archive, err := normalizeModuleArchive(upload, u.maxSize)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errModuleTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := storage.ModuleKey(org, name, provider, version)
+	if err := u.storage.UploadFile(key, bytes.NewReader(archive)); err != nil {
+		log.Printf("Module upload: failed to store %s: %v", key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store module"})
+		return
+	}
+	if err := u.api.CreateModuleVersion(module.ID, version, ""); err != nil {
+		log.Printf("Module upload: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register module version"})
+		return
+	}
+
+	if _, err := u.docs.generate(org, name, provider, version, archive); err != nil {
+		log.Printf("Module upload: failed to document %s/%s/%s %s: %v", org, name, provider, version, err)
+	}
+
+	// Version lists are cached by the versions and download endpoints.
+	u.cache.Delete(moduleVersionsKey(org, name, provider), moduleDownloadKey(org, name, provider, version))
+
+	log.Printf("Published module %s/%s/%s %s from an uploaded archive", org, name, provider, version)
+	c.JSON(http.StatusCreated, gin.H{"version": version})
+}
+
+// moduleFile is a regular file extracted from an uploaded archive.
+type moduleFile struct {
+	name string
+	mode fs.FileMode
+	data []byte
+}
+
+// normalizeModuleArchive validates a zip or tar.gz module archive and returns
+// it as a zip rooted at the module. A single top-level directory, as produced
+// by most release tooling, is stripped. Entries escaping the archive, links
+// and more than limit bytes of content are rejected, and the module root must
+// contain Terraform files.
+func normalizeModuleArchive(upload []byte, limit int64) ([]byte, error) {
+	var files []moduleFile
+	var err error
+	switch {
+	case bytes.HasPrefix(upload, []byte("PK\x03\x04")):
+		files, err = readZipModule(upload, limit)
+	case bytes.HasPrefix(upload, []byte{0x1f, 0x8b}):
+		files, err = readTarGzModule(upload, limit)
+	default:
+		return nil, fmt.Errorf("%w: expected a zip or tar.gz archive", errInvalidModule)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files = stripTopLevelDir(files)
+	hasTerraform := false
+	for _, f := range files {
+		if !strings.Contains(f.name, "/") && (strings.HasSuffix(f.name, ".tf") || strings.HasSuffix(f.name, ".tf.json")) {
+			hasTerraform = true
+			break
+		}
+	}
+	if !hasTerraform {
+		return nil, fmt.Errorf("%w: no .tf files at the module root", errInvalidModule)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	var out bytes.Buffer
+	w := zip.NewWriter(&out)
+	for _, f := range files {
+		header := &zip.FileHeader{Name: f.name, Method: zip.Deflate}
+		header.SetMode(f.mode)
+		fw, err := w.CreateHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// moduleEntryName validates an archive entry name and returns it cleaned.
+func moduleEntryName(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") || strings.Contains(name, ":") {
+		return "", fmt.Errorf("%w: absolute path %q", errInvalidModule, name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("%w: path %q escapes the module", errInvalidModule, name)
+		}
+	}
+	name = path.Clean(name)
+	if name == "." {
+		return "", fmt.Errorf("%w: empty path", errInvalidModule)
+	}
+	return name, nil
+}
+
+// moduleReadLimit tracks the extracted size against the limit.
+type moduleReadLimit struct {
+	remaining int64
+}
+
+func (l *moduleReadLimit) read(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, l.remaining+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidModule, err)
+	}
+	l.remaining -= int64(len(data))
+	if l.remaining < 0 {
+		return nil, errModuleTooLarge
+	}
+	return data, nil
+}
+
+func readZipModule(upload []byte, limit int64) ([]moduleFile, error) {
+	r, err := zip.NewReader(bytes.NewReader(upload), int64(len(upload)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidModule, err)
+	}
+	budget := &moduleReadLimit{remaining: limit}
+	var files []moduleFile
+	for _, f := range r.File {
+		mode := f.Mode()
+		if mode.IsDir() {
+			continue
+		}
+		name, err := moduleEntryName(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !mode.IsRegular() {
+			return nil, fmt.Errorf("%w: %q is not a regular file", errInvalidModule, f.Name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidModule, err)
+		}
+		data, err := budget.read(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, moduleFile{name: name, mode: mode.Perm(), data: data})
+	}
+	return files, nil
+}
+
+func readTarGzModule(upload []byte, limit int64) ([]moduleFile, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(upload))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidModule, err)
+	}
+	defer gz.Close()
+
+	budget := &moduleReadLimit{remaining: limit}
+	var files []moduleFile
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidModule, err)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir, tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeReg:
+		default:
+			return nil, fmt.Errorf("%w: %q is not a regular file", errInvalidModule, header.Name)
+		}
+		name, err := moduleEntryName(header.Name)
+		if err != nil {
+			return nil, err
+		}
+		data, err := budget.read(tr)
+		if err != nil {
+			return nil, err
+		}
+		mode := fs.FileMode(header.Mode).Perm()
+		if mode == 0 {
+			mode = 0644
+		}
+		files = append(files, moduleFile{name: name, mode: mode, data: data})
+	}
+}
+
+// stripTopLevelDir removes a directory that contains every file.
+func stripTopLevelDir(files []moduleFile) []moduleFile {
+	if len(files) == 0 {
+		return files
+	}
+	top, _, ok := strings.Cut(files[0].name, "/")
+	if !ok {
+		return files
+	}
+	for _, f := range files {
+		if !strings.HasPrefix(f.name, top+"/") {
+			return files
+		}
+	}
+	for i := range files {
+		files[i].name = strings.TrimPrefix(files[i].name, top+"/")
+	}
+	return files
+}
@@ -0,0 +1,216 @@
+package registry
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ilkerispir/terrakubed/internal/client"
+)
+
+type archiveEntry struct {
+	name     string
+	body     string
+	typeflag byte // tar only; 0 means a regular file
+}
+
+func tarGz(t *testing.T, entries ...archiveEntry) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.body)), Typeflag: e.typeflag}
+		switch e.typeflag {
+		case 0:
+			h.Typeflag = tar.TypeReg
+		case tar.TypeSymlink:
+			h.Size, h.Linkname = 0, "/etc/passwd"
+		case tar.TypeDir:
+			h.Size = 0
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(e.body))
+	}
+	tw.Close()
+	gz.Close()
+	return b.Bytes()
+}
+
+func zipArchive(t *testing.T, entries ...archiveEntry) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for _, e := range entries {
+		f, err := w.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(e.body))
+	}
+	w.Close()
+	return b.Bytes()
+}
+
+// zipNames lists the files of a zip archive.
+func zipNames(t *testing.T, data []byte) []string {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestNormalizeModuleArchive(t *testing.T) {
+	tests := []struct {
+		name    string
+		archive func(t *testing.T) []byte
+		want    string // comma-separated file names
+		wantErr error
+	}{
+		{"zip", func(t *testing.T) []byte {
+			return zipArchive(t, archiveEntry{name: "main.tf"}, archiveEntry{name: "modules/db/main.tf"})
+		}, "main.tf,modules/db/main.tf", nil},
+		{"tar.gz with top-level directory", func(t *testing.T) []byte {
+			return tarGz(t,
+				archiveEntry{name: "./", typeflag: tar.TypeDir},
+				archiveEntry{name: "./vpc-1.0.0/main.tf"},
+				archiveEntry{name: "vpc-1.0.0/README.md"})
+		}, "README.md,main.tf", nil},
+		{"tf.json module", func(t *testing.T) []byte {
+			return zipArchive(t, archiveEntry{name: "main.tf.json", body: "{}"})
+		}, "main.tf.json", nil},
+		{"path traversal", func(t *testing.T) []byte {
+			return zipArchive(t, archiveEntry{name: "main.tf"}, archiveEntry{name: "../../etc/cron.d/x"})
+		}, "", errInvalidModule},
+		{"absolute path", func(t *testing.T) []byte {
+			return tarGz(t, archiveEntry{name: "main.tf"}, archiveEntry{name: "/etc/passwd"})
+		}, "", errInvalidModule},
+		{"symlink", func(t *testing.T) []byte {
+			return tarGz(t, archiveEntry{name: "main.tf"}, archiveEntry{name: "link", typeflag: tar.TypeSymlink})
+		}, "", errInvalidModule},
+		{"no terraform files", func(t *testing.T) []byte {
+			return zipArchive(t, archiveEntry{name: "README.md"}, archiveEntry{name: "modules/db/main.tf"})
+		}, "", errInvalidModule},
+		{"not an archive", func(t *testing.T) []byte {
+			return []byte("resource \"null_resource\" \"x\" {}")
+		}, "", errInvalidModule},
+		{"extracted content over limit", func(t *testing.T) []byte {
+			return tarGz(t, archiveEntry{name: "main.tf", body: strings.Repeat("#", 2048)})
+		}, "", errModuleTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeModuleArchive(tt.archive(t), 1024)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if names := strings.Join(zipNames(t, got), ","); names != tt.want {
+				t.Errorf("files = %s, want %s", names, tt.want)
+			}
+		})
+	}
+}
+
+// fakeModuleAPI knows one module, vpc/aws in org acme.
+type fakeModuleAPI struct {
+	versions []string
+}
+
+func (f *fakeModuleAPI) GetModule(organization, module, provider string) (*client.ModuleDetails, string, error) {
+	if organization != "acme" || module != "vpc" || provider != "aws" {
+		return nil, "", fmt.Errorf("module not found")
+	}
+	return &client.ModuleDetails{ID: "module-1"}, "org-1", nil
+}
+
+func (f *fakeModuleAPI) ModuleVersionExists(moduleId, version string) (bool, error) {
+	for _, v := range f.versions {
+		if v == version {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeModuleAPI) CreateModuleVersion(moduleId, version, commit string) error {
+	f.versions = append(f.versions, version)
+	return nil
+}
+
+func TestModuleUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	api := &fakeModuleAPI{versions: []string{"1.0.0"}}
+	store := &memStorage{files: map[string][]byte{}}
+	cache := newLRUCache(10, time.Minute)
+	cache.Set(moduleVersionsKey("acme", "vpc", "aws"), []byte("stale"))
+	r := gin.New()
+	newModuleUploader(store, api, cache, newModuleDocs(store, nil), 1<<20).register(r, func(*gin.Context) {})
+
+	module := zipArchive(t, archiveEntry{name: "main.tf", body: "variable \"cidr\" {}"})
+	tests := []struct {
+		name string
+		path string
+		body []byte
+		want int
+	}{
+		{"new version", "/terraform/modules/v1/acme/vpc/aws/1.1.0/upload", module, http.StatusCreated},
+		{"existing version", "/terraform/modules/v1/acme/vpc/aws/1.0.0/upload", module, http.StatusConflict},
+		{"unknown module", "/terraform/modules/v1/acme/eks/aws/1.0.0/upload", module, http.StatusNotFound},
+		{"invalid version", "/terraform/modules/v1/acme/vpc/aws/main/upload", module, http.StatusBadRequest},
+		{"invalid archive", "/terraform/modules/v1/acme/vpc/aws/1.2.0/upload", []byte("main.tf"), http.StatusBadRequest},
+		{"too large", "/terraform/modules/v1/acme/vpc/aws/1.3.0/upload", make([]byte, 1<<20+1), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := upload(r, tt.path, tt.body); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	if strings.Join(api.versions, ",") != "1.0.0,1.1.0" {
+		t.Errorf("registered versions = %v", api.versions)
+	}
+	reader, err := store.DownloadFile("registry/acme/vpc/aws/1.1.0/module.zip")
+	if err != nil {
+		t.Fatalf("uploaded module not stored: %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	if names := zipNames(t, data); len(names) != 1 || names[0] != "main.tf" {
+		t.Errorf("stored module files = %v", names)
+	}
+	if _, ok := cache.Get(moduleVersionsKey("acme", "vpc", "aws")); ok {
+		t.Error("cached version list was not invalidated")
+	}
+	if _, ok := store.files["registry/acme/vpc/aws/1.1.0/"+moduleDocFile]; !ok {
+		t.Error("uploaded module was not documented")
+	}
+	if len(store.files) != 2 {
+		t.Errorf("rejected uploads were stored: %d files", len(store.files))
+	}
+}
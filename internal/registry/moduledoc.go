@@ -0,0 +1,332 @@
+package registry
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/ilkerispir/terrakubed/internal/storage"
+)
+
+// moduleDoc is the documentation of a module version, in the shape of the
+// public registry's module details: the root module, its submodules under
+// modules/ and its examples under examples/.
+type moduleDoc struct {
+	Root       moduleDocPart   `json:"root"`
+	Submodules []moduleDocPart `json:"submodules"`
+	Examples   []moduleDocPart `json:"examples"`
+}
+
+type moduleDocPart struct {
+	Path                 string                 `json:"path"`
+	Name                 string                 `json:"name"`
+	Readme               string                 `json:"readme"`
+	Empty                bool                   `json:"empty"`
+	RequiredCore         []string               `json:"required_core"`
+	Inputs               []moduleDocInput       `json:"inputs"`
+	Outputs              []moduleDocOutput      `json:"outputs"`
+	Dependencies         []moduleDocDependency  `json:"dependencies"`
+	ProviderDependencies []moduleDocProviderDep `json:"provider_dependencies"`
+	Resources            []moduleDocResource    `json:"resources"`
+	Diagnostics          []string               `json:"diagnostics,omitempty"`
+}
+
+type moduleDocInput struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Description string      `json:"description"`
+	Default     interface{} `json:"default"`
+	Required    bool        `json:"required"`
+	Sensitive   bool        `json:"sensitive"`
+}
+
+type moduleDocOutput struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Sensitive   bool   `json:"sensitive"`
+}
+
+// moduleDocDependency is a module call.
+type moduleDocDependency struct {
+	Name    string `json:"name"`
+	Source  string `json:"source"`
+	Version string `json:"version"`
+}
+
+type moduleDocProviderDep struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Source    string `json:"source"`
+	Version   string `json:"version"`
+}
+
+type moduleDocResource struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Mode     string `json:"mode"` // managed or data
+	Provider string `json:"provider"`
+}
+
+// moduleDocs serves module documentation:
+//
+//	GET /terraform/docs/v1/{org}/{name}/{provider}/{version}  → moduleDoc
+//
+// Docs are generated from the stored module.zip when a version is uploaded or
+// first requested, and kept next to it in storage.
+type moduleDocs struct {
+	storage storage.StorageService
+	// fetch makes sure the module version is in storage, cloning its tag if
+	// it has not been downloaded yet.
+	fetch func(org, name, provider, version string) error
+}
+
+// moduleDocFile is stored next to module.zip. The suffix is bumped when the
+// format changes so stale docs are regenerated.
+const moduleDocFile = "doc.v1.json"
+
+func newModuleDocs(store storage.StorageService, fetch func(org, name, provider, version string) error) *moduleDocs {
+	return &moduleDocs{storage: store, fetch: fetch}
+}
+
+func (d *moduleDocs) register(protected gin.IRoutes) {
+	protected.GET("/terraform/docs/v1/:org/:name/:provider/:version", d.handleDoc)
+}
+
+func moduleDocKey(org, name, provider, version string) string {
+	return path.Join(path.Dir(storage.ModuleKey(org, name, provider, version)), moduleDocFile)
+}
+
+func (d *moduleDocs) handleDoc(c *gin.Context) {
+	org, name, provider, version := c.Param("org"), c.Param("name"), c.Param("provider"), c.Param("version")
+	for _, segment := range []string{org, name, provider, version} {
+		if !pathSegment.MatchString(segment) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid path segment %q", segment)})
+			return
+		}
+	}
+
+	if reader, err := d.storage.DownloadFile(moduleDocKey(org, name, provider, version)); err == nil {
+		defer reader.Close()
+		c.DataFromReader(http.StatusOK, -1, "application/json", reader, nil)
+		return
+	}
+
+	reader, err := d.storage.DownloadModule(org, name, provider, version)
+	if err != nil {
+		if err := d.fetch(org, name, provider, version); err != nil {
+			log.Printf("Module docs: %v", err)
+			c.JSON(http.StatusNotFound, gin.H{"error": "Module version not found"})
+			return
+		}
+		if reader, err = d.storage.DownloadModule(org, name, provider, version); err != nil {
+			log.Printf("Module docs: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read module zip"})
+			return
+		}
+	}
+	archive, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read module zip"})
+		return
+	}
+
+	document, err := d.generate(org, name, provider, version, archive)
+	if err != nil {
+		log.Printf("Module docs %s/%s/%s %s: %v", org, name, provider, version, err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/json", document)
+}
+
+// generate builds the documentation of a module archive and stores it.
+// Failing to store it only costs a regeneration on the next request.
+func (d *moduleDocs) generate(org, name, provider, version string, archive []byte) ([]byte, error) {
+	doc, err := buildModuleDoc(archive)
+	if err != nil {
+		return nil, err
+	}
+	document, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	key := moduleDocKey(org, name, provider, version)
+	if err := d.storage.UploadFile(key, bytes.NewReader(document)); err != nil {
+		log.Printf("Module docs: failed to store %s: %v", key, err)
+	}
+	return document, nil
+}
+
+// buildModuleDoc parses the Terraform configuration of a module.zip.
+func buildModuleDoc(archive []byte) (*moduleDoc, error) {
+	r, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, fmt.Errorf("module archive is not a zip: %w", err)
+	}
+	fsys := tfconfig.WrapFS(r)
+
+	doc := &moduleDoc{Root: inspectModuleDir(r, fsys, ".")}
+	doc.Root.Path, doc.Root.Name = "", ""
+	for _, group := range []struct {
+		dir   string
+		parts *[]moduleDocPart
+	}{{"modules", &doc.Submodules}, {"examples", &doc.Examples}} {
+		*group.parts = []moduleDocPart{}
+		entries, err := fs.ReadDir(r, group.dir)
+		if err != nil {
+			continue // no such directory
+		}
+		for _, entry := range entries {
+			dir := path.Join(group.dir, entry.Name())
+			if entry.IsDir() && tfconfig.IsModuleDirOnFilesystem(fsys, dir) {
+				*group.parts = append(*group.parts, inspectModuleDir(r, fsys, dir))
+			}
+		}
+	}
+	return doc, nil
+}
+
+// inspectModuleDir documents the module in dir. Parse errors are reported in
+// the part's diagnostics rather than failing the whole document.
+func inspectModuleDir(r fs.FS, fsys tfconfig.FS, dir string) moduleDocPart {
+	module, _ := tfconfig.LoadModuleFromFilesystem(fsys, dir)
+	part := moduleDocPart{
+		Path:                 dir,
+		Name:                 path.Base(dir),
+		Readme:               readmeInDir(r, dir),
+		RequiredCore:         append([]string{}, module.RequiredCore...),
+		Inputs:               []moduleDocInput{},
+		Outputs:              []moduleDocOutput{},
+		Dependencies:         []moduleDocDependency{},
+		ProviderDependencies: []moduleDocProviderDep{},
+		Resources:            []moduleDocResource{},
+	}
+
+	variables := make([]*tfconfig.Variable, 0, len(module.Variables))
+	for _, v := range module.Variables {
+		variables = append(variables, v)
+	}
+	sort.Slice(variables, func(i, j int) bool { return posLess(variables[i].Pos, variables[j].Pos) })
+	for _, v := range variables {
+		part.Inputs = append(part.Inputs, moduleDocInput{
+			Name: v.Name, Type: v.Type, Description: v.Description,
+			Default: v.Default, Required: v.Required, Sensitive: v.Sensitive,
+		})
+	}
+
+	outputs := make([]*tfconfig.Output, 0, len(module.Outputs))
+	for _, o := range module.Outputs {
+		outputs = append(outputs, o)
+	}
+	sort.Slice(outputs, func(i, j int) bool { return posLess(outputs[i].Pos, outputs[j].Pos) })
+	for _, o := range outputs {
+		part.Outputs = append(part.Outputs, moduleDocOutput{Name: o.Name, Description: o.Description, Sensitive: o.Sensitive})
+	}
+
+	calls := make([]*tfconfig.ModuleCall, 0, len(module.ModuleCalls))
+	for _, m := range module.ModuleCalls {
+		calls = append(calls, m)
+	}
+	sort.Slice(calls, func(i, j int) bool { return posLess(calls[i].Pos, calls[j].Pos) })
+	for _, m := range calls {
+		part.Dependencies = append(part.Dependencies, moduleDocDependency{Name: m.Name, Source: m.Source, Version: m.Version})
+	}
+
+	for name, req := range module.RequiredProviders {
+		part.ProviderDependencies = append(part.ProviderDependencies, providerDependency(name, req))
+	}
+	sort.Slice(part.ProviderDependencies, func(i, j int) bool {
+		return part.ProviderDependencies[i].Name < part.ProviderDependencies[j].Name
+	})
+
+	resources := make([]*tfconfig.Resource, 0, len(module.ManagedResources)+len(module.DataResources))
+	for _, res := range module.ManagedResources {
+		resources = append(resources, res)
+	}
+	for _, res := range module.DataResources {
+		resources = append(resources, res)
+	}
+	sort.Slice(resources, func(i, j int) bool { return posLess(resources[i].Pos, resources[j].Pos) })
+	for _, res := range resources {
+		mode := "managed"
+		if res.Mode == tfconfig.DataResourceMode {
+			mode = "data"
+		}
+		part.Resources = append(part.Resources, moduleDocResource{Name: res.Name, Type: res.Type, Mode: mode, Provider: res.Provider.Name})
+	}
+
+	for _, diag := range module.Diagnostics {
+		if diag.Severity == tfconfig.DiagError {
+			part.Diagnostics = append(part.Diagnostics, diagnosticText(diag))
+		}
+	}
+	part.Empty = len(part.Inputs) == 0 && len(part.Outputs) == 0 && len(part.Resources) == 0 && len(part.Dependencies) == 0
+	return part
+}
+
+// providerDependency describes a required_providers entry. Providers without
+// a source default to the hashicorp namespace, as in terraform.
+func providerDependency(name string, req *tfconfig.ProviderRequirement) moduleDocProviderDep {
+	source := req.Source
+	if source == "" {
+		source = "hashicorp/" + name
+	}
+	namespace := "hashicorp"
+	if parts := strings.Split(source, "/"); len(parts) >= 2 {
+		namespace = parts[len(parts)-2]
+	}
+	return moduleDocProviderDep{
+		Name:      name,
+		Namespace: namespace,
+		Source:    source,
+		Version:   strings.Join(req.VersionConstraints, ", "),
+	}
+}
+
+// posLess orders definitions as they appear in the module's files.
+func posLess(a, b tfconfig.SourcePos) bool {
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	return a.Line < b.Line
+}
+
+func diagnosticText(diag tfconfig.Diagnostic) string {
+	text := diag.Summary
+	if diag.Detail != "" {
+		text += ": " + diag.Detail
+	}
+	if diag.Pos != nil {
+		text = fmt.Sprintf("%s:%d: %s", diag.Pos.Filename, diag.Pos.Line, text)
+	}
+	return text
+}
+
+// readmeInDir returns the README.md of a module directory, matched without
+// regard to case.
+func readmeInDir(r fs.FS, dir string) string {
+	entries, err := fs.ReadDir(r, dir)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(entry.Name(), "README.md") {
+			content, err := fs.ReadFile(r, path.Join(dir, entry.Name()))
+			if err == nil {
+				return string(content)
+			}
+		}
+	}
+	return ""
+}
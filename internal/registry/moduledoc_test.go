@@ -0,0 +1,166 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ilkerispir/terrakubed/internal/storage"
+)
+
+const testModuleMain = `
+terraform {
+  required_version = ">= 1.3"
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = ">= 5.0"
+    }
+    random = "~> 3.0"
+  }
+}
+
+variable "name" {
+  type        = string
+  description = "Name of the VPC."
+}
+
+variable "cidr" {
+  type    = string
+  default = "10.0.0.0/16"
+}
+
+resource "aws_vpc" "this" {
+  cidr_block = var.cidr
+}
+
+data "aws_region" "current" {}
+
+module "subnets" {
+  source  = "./modules/subnets"
+}
+
+output "vpc_id" {
+  description = "ID of the VPC."
+  value       = aws_vpc.this.id
+}
+`
+
+func testModuleZip(t *testing.T) []byte {
+	t.Helper()
+	return zipArchive(t,
+		archiveEntry{name: "README.md", body: "# VPC"},
+		archiveEntry{name: "main.tf", body: testModuleMain},
+		archiveEntry{name: "modules/subnets/main.tf", body: `variable "vpc_id" {}`},
+		archiveEntry{name: "modules/subnets/readme.md", body: "# Subnets"},
+		archiveEntry{name: "modules/scripts/init.sh", body: "#!/bin/sh"},
+		archiveEntry{name: "examples/complete/main.tf", body: `module "vpc" {
+  source  = "acme/vpc/aws"
+  version = "1.0.0"
+}`},
+	)
+}
+
+func TestBuildModuleDoc(t *testing.T) {
+	doc, err := buildModuleDoc(testModuleZip(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := doc.Root
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"readme", root.Readme, "# VPC"},
+		{"required core", root.RequiredCore, []string{">= 1.3"}},
+		{"inputs", root.Inputs, []moduleDocInput{
+			{Name: "name", Type: "string", Description: "Name of the VPC.", Required: true},
+			{Name: "cidr", Type: "string", Default: "10.0.0.0/16"},
+		}},
+		{"outputs", root.Outputs, []moduleDocOutput{{Name: "vpc_id", Description: "ID of the VPC."}}},
+		{"providers", root.ProviderDependencies, []moduleDocProviderDep{
+			{Name: "aws", Namespace: "hashicorp", Source: "hashicorp/aws", Version: ">= 5.0"},
+			{Name: "random", Namespace: "hashicorp", Source: "hashicorp/random", Version: "~> 3.0"},
+		}},
+		{"resources", root.Resources, []moduleDocResource{
+			{Name: "this", Type: "aws_vpc", Mode: "managed", Provider: "aws"},
+			{Name: "current", Type: "aws_region", Mode: "data", Provider: "aws"},
+		}},
+		{"dependencies", root.Dependencies, []moduleDocDependency{{Name: "subnets", Source: "./modules/subnets"}}},
+		{"submodules", len(doc.Submodules), 1},
+		{"submodule path", doc.Submodules[0].Path, "modules/subnets"},
+		{"submodule readme", doc.Submodules[0].Readme, "# Subnets"},
+		{"submodule inputs", len(doc.Submodules[0].Inputs), 1},
+		{"examples", len(doc.Examples), 1},
+		{"example dependency", doc.Examples[0].Dependencies, []moduleDocDependency{{Name: "vpc", Source: "acme/vpc/aws", Version: "1.0.0"}}},
+		{"example is empty", doc.Examples[0].Empty, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %#v, want %#v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildModuleDocDiagnostics(t *testing.T) {
+	doc, err := buildModuleDoc(zipArchive(t, archiveEntry{name: "main.tf", body: `variable "x" {`}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Root.Diagnostics) == 0 {
+		t.Error("parse error was not reported")
+	}
+}
+
+func TestModuleDocEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &memStorage{files: map[string][]byte{}}
+	fetched := 0
+	fetch := func(org, name, provider, version string) error {
+		fetched++
+		if name != "vpc" {
+			return errModuleNotFound
+		}
+		return store.UploadFile(storage.ModuleKey(org, name, provider, version), bytes.NewReader(testModuleZip(t)))
+	}
+	r := gin.New()
+	newModuleDocs(store, fetch).register(r)
+
+	tests := []struct {
+		name        string
+		path        string
+		want        int
+		wantFetched int
+	}{
+		{"cloned on first request", "/terraform/docs/v1/acme/vpc/aws/1.0.0", http.StatusOK, 1},
+		{"served from storage", "/terraform/docs/v1/acme/vpc/aws/1.0.0", http.StatusOK, 1},
+		{"unknown module", "/terraform/docs/v1/acme/eks/aws/1.0.0", http.StatusNotFound, 2},
+		{"invalid segment", "/terraform/docs/v1/acme/vpc/aws/..", http.StatusBadRequest, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+			if fetched != tt.wantFetched {
+				t.Errorf("fetched %d times, want %d", fetched, tt.wantFetched)
+			}
+			if rec.Code == http.StatusOK {
+				var doc moduleDoc
+				if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil || len(doc.Root.Inputs) != 2 {
+					t.Errorf("unexpected doc %s (%v)", rec.Body.String(), err)
+				}
+			}
+		})
+	}
+}
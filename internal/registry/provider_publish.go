@@ -0,0 +1,340 @@
+package registry
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/gin-gonic/gin"
+	goversion "github.com/hashicorp/go-version"
+	"github.com/ilkerispir/terrakubed/internal/client"
+	"github.com/ilkerispir/terrakubed/internal/storage"
+)
+
+// providerAPI is the part of the API client used to publish providers.
+type providerAPI interface {
+	OrganizationID(name string) (string, error)
+	SigningKey(orgId string) (string, error)
+	PublishProvider(orgId, provider, version string, protocols []string, impl client.ProviderImplementation) error
+}
+
+// providerPublisher publishes private provider releases:
+//
+//	POST /terraform/providers/v1/{org}/{provider}/{version}/upload/{os}/{arch}  → release zip as the request body
+//	GET  /terraform/providers/v1/download/{org}/{provider}/{version}/{file}    → zip, SHA256SUMS or SHA256SUMS.sig
+//
+// Each upload regenerates the version's SHA256SUMS and signs it with the
+// organization's GPG key, so the download endpoint can hand terraform the
+// package, checksums, signature and public key it verifies on init.
+type providerPublisher struct {
+	storage  storage.StorageService
+	api      providerAPI
+	cache    registryCache
+	hostname string // public registry URL
+	maxSize  int64  // of an uploaded package
+
+	// Packages are stored and SHA256SUMS is read, updated and rewritten per
+	// upload. Uploads for the same version are serialized within this replica.
+	locks sync.Map // storage prefix → *sync.Mutex
+}
+
+func newProviderPublisher(store storage.StorageService, api providerAPI, cache registryCache, hostname string, maxSize int64) *providerPublisher {
+	return &providerPublisher{storage: store, api: api, cache: cache, hostname: strings.TrimSuffix(hostname, "/"), maxSize: maxSize}
+}
+
+// register adds the upload route, behind authorize, to protected and the
+// download route to public.
+func (p *providerPublisher) register(protected, public gin.IRoutes, authorize gin.HandlerFunc) {
+	protected.POST("/terraform/providers/v1/:org/:provider/:version/upload/:os/:arch", authorize, p.handleUpload)
+	public.GET("/terraform/providers/v1/download/:org/:provider/:version/:file", p.handleDownload)
+}
+
+// providerPrefix is the storage prefix of a provider version.
+func providerPrefix(org, provider, version string) string {
+	return path.Join("registry/providers", org, provider, version)
+}
+
+func (p *providerPublisher) handleUpload(c *gin.Context) {
+	org, provider, version := c.Param("org"), c.Param("provider"), c.Param("version")
+	osName, arch := c.Param("os"), c.Param("arch")
+	for _, segment := range []string{org, provider, version, osName, arch} {
+		if !pathSegment.MatchString(segment) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid path segment %q", segment)})
+			return
+		}
+	}
+	if _, err := goversion.NewSemver(version); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid version %q", version)})
+		return
+	}
+	protocols := strings.Split(c.DefaultQuery("protocols", "5.0"), ",")
+
+	orgId, err := p.api.OrganizationID(org)
+	if err != nil {
+		log.Printf("Provider upload: %v", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		return
+	}
+	armored, err := p.api.SigningKey(orgId)
+	if err != nil {
+		if errors.Is(err, client.ErrNoSigningKey) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Add a GPG key to the organization before publishing providers"})
+			return
+		}
+		log.Printf("Provider upload: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read the organization's GPG key"})
+		return
+	}
+	signer, err := readSigningKey(armored)
+	if err != nil {
+		log.Printf("Provider upload: organization %s: %v", org, err)
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "The organization's GPG key cannot sign: " + err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", provider, version, osName, arch)
+	prefix := providerPrefix(org, provider, version)
+	shasum, err := p.publishPackage(c.Request.Body, prefix, filename, signer)
+	if err != nil {
+		log.Printf("Provider upload %s: %v", filename, err)
+		switch {
+		case errors.Is(err, errPackageExists):
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s is already published", filename)})
+		case errors.Is(err, errPackageTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("provider packages are limited to %d bytes", p.maxSize)})
+		case errors.Is(err, errInvalidPackage):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to publish provider package"})
+		}
+		return
+	}
+
+	publicKey, err := armoredPublicKey(signer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	downloadBase := fmt.Sprintf("%s/terraform/providers/v1/download/%s/%s/%s/", p.hostname, org, provider, version)
+	impl := client.ProviderImplementation{
+		OS:                  osName,
+		Arch:                arch,
+		Filename:            filename,
+		DownloadURL:         downloadBase + filename,
+		ShasumsURL:          downloadBase + shasumsFile,
+		ShasumsSignatureURL: downloadBase + shasumsFile + ".sig",
+		Shasum:              shasum,
+		KeyID:               keyID(signer),
+		AsciiArmor:          publicKey,
+		Source:              org,
+	}
+	if err := p.api.PublishProvider(orgId, provider, version, protocols, impl); err != nil {
+		log.Printf("Provider upload %s: %v", filename, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register provider version"})
+		return
+	}
+
+	p.cache.Delete(providerVersionsKey(org, provider), providerDownloadKey(org, provider, version, osName, arch))
+
+	log.Printf("Published provider %s/%s %s for %s_%s", org, provider, version, osName, arch)
+	c.JSON(http.StatusCreated, gin.H{
+		"filename":     impl.Filename,
+		"shasum":       impl.Shasum,
+		"key_id":       impl.KeyID,
+		"download_url": impl.DownloadURL,
+	})
+}
+
+func (p *providerPublisher) handleDownload(c *gin.Context) {
+	org, provider, version, file := c.Param("org"), c.Param("provider"), c.Param("version"), c.Param("file")
+	for _, segment := range []string{org, provider, version, file} {
+		if !pathSegment.MatchString(segment) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+			return
+		}
+	}
+	reader, err := p.storage.DownloadFile(path.Join(providerPrefix(org, provider, version), file))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	defer reader.Close()
+
+	contentType := "application/zip"
+	if !strings.HasSuffix(file, ".zip") {
+		contentType = "application/octet-stream"
+	}
+	c.DataFromReader(http.StatusOK, -1, contentType, reader, nil)
+}
+
+const shasumsFile = "SHA256SUMS"
+
+var (
+	errInvalidPackage  = errors.New("upload is not a zip archive")
+	errPackageTooLarge = errors.New("provider package too large")
+	errPackageExists   = errors.New("provider package already published")
+)
+
+// publishPackage stores a new package of the version under prefix and adds it
+// to the version's signed SHA256SUMS. Published packages are immutable:
+// terraform pins their checksums in lock files, so a second upload of the
+// same os/arch is rejected instead of replacing it.
+func (p *providerPublisher) publishPackage(body io.Reader, prefix, filename string, signer *openpgp.Entity) (string, error) {
+	lock, _ := p.locks.LoadOrStore(prefix, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	sums, err := p.readShasums(prefix)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := sums[filename]; ok {
+		return "", errPackageExists
+	}
+	if reader, err := p.storage.DownloadFile(path.Join(prefix, filename)); err == nil {
+		reader.Close()
+		return "", errPackageExists
+	}
+
+	shasum, err := p.storePackage(body, path.Join(prefix, filename))
+	if err != nil {
+		return "", err
+	}
+	sums[filename] = shasum
+	if err := p.writeShasums(prefix, sums, signer); err != nil {
+		return "", err
+	}
+	return shasum, nil
+}
+
+// storePackage checks that body is a zip archive of at most maxSize bytes,
+// stores it at key and returns its SHA-256.
+func (p *providerPublisher) storePackage(body io.Reader, key string) (string, error) {
+	tmp, err := os.CreateTemp("", "provider-*.zip")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(body, p.maxSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read upload: %w", err)
+	}
+	if size > p.maxSize {
+		return "", errPackageTooLarge
+	}
+	if _, err := zip.NewReader(tmp, size); err != nil {
+		return "", fmt.Errorf("%w: %v", errInvalidPackage, err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	if err := p.storage.UploadFile(key, tmp); err != nil {
+		return "", fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readShasums returns the checksums in the version's SHA256SUMS, if any.
+func (p *providerPublisher) readShasums(prefix string) (map[string]string, error) {
+	reader, err := p.storage.DownloadFile(path.Join(prefix, shasumsFile))
+	if err != nil {
+		return map[string]string{}, nil
+	}
+	defer reader.Close()
+	existing, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", shasumsFile, err)
+	}
+	return parseShasums(existing), nil
+}
+
+// writeShasums rewrites the version's SHA256SUMS and its detached signature.
+func (p *providerPublisher) writeShasums(prefix string, sums map[string]string, signer *openpgp.Entity) error {
+	document := formatShasums(sums)
+
+	var signature bytes.Buffer
+	if err := openpgp.DetachSign(&signature, signer, bytes.NewReader(document), nil); err != nil {
+		return fmt.Errorf("failed to sign %s: %w", shasumsFile, err)
+	}
+	if err := p.storage.UploadFile(path.Join(prefix, shasumsFile), bytes.NewReader(document)); err != nil {
+		return err
+	}
+	return p.storage.UploadFile(path.Join(prefix, shasumsFile+".sig"), &signature)
+}
+
+// parseShasums reads a SHA256SUMS document ("<sha256>  <file>" per line).
+func parseShasums(document []byte) map[string]string {
+	sums := map[string]string{}
+	for _, line := range strings.Split(string(document), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			sums[fields[1]] = fields[0]
+		}
+	}
+	return sums
+}
+
+func formatShasums(sums map[string]string) []byte {
+	files := make([]string, 0, len(sums))
+	for file := range sums {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	var b bytes.Buffer
+	for _, file := range files {
+		fmt.Fprintf(&b, "%s  %s\n", sums[file], file)
+	}
+	return b.Bytes()
+}
+
+// readSigningKey parses an ASCII-armored private key that can sign without a
+// passphrase.
+func readSigningKey(armored string) (*openpgp.Entity, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		return nil, fmt.Errorf("invalid GPG key: %w", err)
+	}
+	if len(entities) == 0 || entities[0].PrivateKey == nil {
+		return nil, errors.New("GPG key has no private key")
+	}
+	if entities[0].PrivateKey.Encrypted {
+		return nil, errors.New("passphrase-protected GPG keys are not supported")
+	}
+	return entities[0], nil
+}
+
+// armoredPublicKey returns the public part of the key, as terraform expects
+// it in signing_keys.gpg_public_keys.
+func armoredPublicKey(entity *openpgp.Entity) (string, error) {
+	var b bytes.Buffer
+	w, err := armor.Encode(&b, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := entity.Serialize(w); err != nil {
+		return "", fmt.Errorf("failed to export public key: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func keyID(entity *openpgp.Entity) string {
+	return fmt.Sprintf("%016X", entity.PrimaryKey.KeyId)
+}
@@ -0,0 +1,214 @@
+package registry
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/gin-gonic/gin"
+	"github.com/ilkerispir/terrakubed/internal/client"
+)
+
+// fakeProviderAPI records published implementations.
+type fakeProviderAPI struct {
+	signingKey string
+	published  []client.ProviderImplementation
+	protocols  []string
+}
+
+func (f *fakeProviderAPI) OrganizationID(name string) (string, error) {
+	return "org-" + name, nil
+}
+
+func (f *fakeProviderAPI) SigningKey(orgId string) (string, error) {
+	if f.signingKey == "" {
+		return "", client.ErrNoSigningKey
+	}
+	return f.signingKey, nil
+}
+
+func (f *fakeProviderAPI) PublishProvider(orgId, provider, version string, protocols []string, impl client.ProviderImplementation) error {
+	f.published = append(f.published, impl)
+	f.protocols = protocols
+	return nil
+}
+
+func armoredPrivateKey(t *testing.T) string {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Acme Providers", "", "providers@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	w, err := armor.Encode(&b, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.SerializePrivate(w, nil); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	return b.String()
+}
+
+func providerZip(t *testing.T, content string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	f, err := w.Create("terraform-provider-acme_v1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(content))
+	w.Close()
+	return b.Bytes()
+}
+
+func newTestPublisher(api providerAPI) (*gin.Engine, *memStorage, *lruCache) {
+	gin.SetMode(gin.TestMode)
+	store := &memStorage{files: map[string][]byte{}}
+	cache := newLRUCache(10, time.Minute)
+	r := gin.New()
+	// The registry's provider routes share the path prefix.
+	r.GET("/terraform/providers/v1/:org/:provider/versions", func(c *gin.Context) {})
+	allow := func(*gin.Context) {}
+	newProviderPublisher(store, api, cache, "https://registry.example.com/", 1<<20).register(r, r, allow)
+	return r, store, cache
+}
+
+func upload(r http.Handler, path string, body []byte) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+	return rec
+}
+
+func TestProviderPublish(t *testing.T) {
+	api := &fakeProviderAPI{signingKey: armoredPrivateKey(t)}
+	r, store, cache := newTestPublisher(api)
+	cache.Set(providerVersionsKey("acme", "acme"), []byte("stale"))
+
+	for _, platform := range []string{"linux/amd64", "darwin/arm64"} {
+		rec := upload(r, "/terraform/providers/v1/acme/acme/1.2.0/upload/"+platform+"?protocols=5.0,6.0", providerZip(t, platform))
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("upload %s = %d %s", platform, rec.Code, rec.Body.String())
+		}
+	}
+
+	if len(api.published) != 2 {
+		t.Fatalf("published %d implementations, want 2", len(api.published))
+	}
+	if _, ok := cache.Get(providerVersionsKey("acme", "acme")); ok {
+		t.Error("cached provider versions were not invalidated")
+	}
+	if strings.Join(api.protocols, ",") != "5.0,6.0" {
+		t.Errorf("protocols = %v", api.protocols)
+	}
+	impl := api.published[1]
+	base := "https://registry.example.com/terraform/providers/v1/download/acme/acme/1.2.0/"
+	if impl.Filename != "terraform-provider-acme_1.2.0_darwin_arm64.zip" ||
+		impl.DownloadURL != base+impl.Filename ||
+		impl.ShasumsURL != base+"SHA256SUMS" ||
+		impl.ShasumsSignatureURL != base+"SHA256SUMS.sig" {
+		t.Errorf("unexpected implementation URLs: %+v", impl)
+	}
+	if len(impl.KeyID) != 16 || !strings.Contains(impl.AsciiArmor, "PUBLIC KEY") {
+		t.Errorf("unexpected signing key: %s %q", impl.KeyID, impl.AsciiArmor)
+	}
+
+	// SHA256SUMS lists both packages and verifies against the published public key.
+	download := func(file string) []byte {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/terraform/providers/v1/download/acme/acme/1.2.0/"+file, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("download %s = %d", file, rec.Code)
+		}
+		return rec.Body.Bytes()
+	}
+	sums := download("SHA256SUMS")
+	parsed := parseShasums(sums)
+	for _, p := range api.published {
+		if parsed[p.Filename] != p.Shasum {
+			t.Errorf("SHA256SUMS entry for %s = %q, want %q", p.Filename, parsed[p.Filename], p.Shasum)
+		}
+	}
+	if err := verifySignature(sums, download("SHA256SUMS.sig"), []upstreamKey{{KeyID: impl.KeyID, ASCIIArmor: impl.AsciiArmor}}); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+	if got := download(impl.Filename); !bytes.Equal(got, store.files["registry/providers/acme/acme/1.2.0/"+impl.Filename]) {
+		t.Error("downloaded package differs from the stored one")
+	}
+}
+
+func TestProviderPublishRejected(t *testing.T) {
+	tests := []struct {
+		name       string
+		signingKey string
+		path       string
+		body       []byte
+		want       int
+	}{
+		{"no signing key", "", "/terraform/providers/v1/acme/acme/1.2.0/upload/linux/amd64", nil, http.StatusPreconditionFailed},
+		{"invalid signing key", "not a key", "/terraform/providers/v1/acme/acme/1.2.0/upload/linux/amd64", nil, http.StatusPreconditionFailed},
+		{"invalid version", "key", "/terraform/providers/v1/acme/acme/latest/upload/linux/amd64", nil, http.StatusBadRequest},
+		{"not a zip", "key", "/terraform/providers/v1/acme/acme/1.2.0/upload/linux/amd64", []byte("binary"), http.StatusBadRequest},
+		{"too large", "key", "/terraform/providers/v1/acme/acme/1.2.0/upload/linux/amd64", make([]byte, 1<<20+1), http.StatusRequestEntityTooLarge},
+	}
+	validKey := armoredPrivateKey(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := tt.signingKey
+			if key == "key" {
+				key = validKey
+			}
+			api := &fakeProviderAPI{signingKey: key}
+			r, store, _ := newTestPublisher(api)
+
+			rec := upload(r, tt.path, tt.body)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+			if len(api.published) != 0 || len(store.files) != 0 {
+				t.Errorf("rejected upload was published or stored")
+			}
+		})
+	}
+}
+
+func TestProviderPublishExisting(t *testing.T) {
+	api := &fakeProviderAPI{signingKey: armoredPrivateKey(t)}
+	r, store, _ := newTestPublisher(api)
+	const path = "/terraform/providers/v1/acme/acme/1.2.0/upload/linux/amd64"
+
+	if rec := upload(r, path, providerZip(t, "first")); rec.Code != http.StatusCreated {
+		t.Fatalf("first upload = %d %s", rec.Code, rec.Body.String())
+	}
+	stored := store.files["registry/providers/acme/acme/1.2.0/terraform-provider-acme_1.2.0_linux_amd64.zip"]
+	sums := store.files["registry/providers/acme/acme/1.2.0/SHA256SUMS"]
+
+	if rec := upload(r, path, providerZip(t, "second")); rec.Code != http.StatusConflict {
+		t.Errorf("second upload = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if len(api.published) != 1 {
+		t.Errorf("published %d implementations, want 1", len(api.published))
+	}
+	if !bytes.Equal(store.files["registry/providers/acme/acme/1.2.0/terraform-provider-acme_1.2.0_linux_amd64.zip"], stored) ||
+		!bytes.Equal(store.files["registry/providers/acme/acme/1.2.0/SHA256SUMS"], sums) {
+		t.Error("second upload replaced the published package")
+	}
+}
+
+func TestFormatShasums(t *testing.T) {
+	got := string(formatShasums(map[string]string{"b.zip": "22", "a.zip": "11"}))
+	if want := "11  a.zip\n22  b.zip\n"; got != want {
+		t.Errorf("formatShasums = %q, want %q", got, want)
+	}
+	if parsed := parseShasums([]byte(got)); parsed["b.zip"] != "22" || len(parsed) != 2 {
+		t.Errorf("parseShasums = %v", parsed)
+	}
+}
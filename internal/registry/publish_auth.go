@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ilkerispir/terrakubed/internal/auth"
+	"github.com/ilkerispir/terrakubed/internal/client"
+	"github.com/ilkerispir/terrakubed/internal/config"
+)
+
+// teamAPI is the part of the API client used to authorize publishing.
+type teamAPI interface {
+	OrganizationID(name string) (string, error)
+	Teams(orgId string) ([]client.Team, error)
+}
+
+// publishAuth authorizes writes into the organization named by the route's
+// :org parameter. Unlike jwtAuthMiddleware it always requires a token, also in
+// LOCAL mode, and accepts it when it is an internal token, belongs to the
+// owner group, or belongs to a team of the organization with the required
+// permission.
+type publishAuth struct {
+	api        teamAPI
+	ownerGroup string
+	validate   func(token string) (jwt.MapClaims, error)
+}
+
+func newPublishAuth(cfg *config.Config, api teamAPI) *publishAuth {
+	return &publishAuth{
+		api:        api,
+		ownerGroup: cfg.OwnerGroup,
+		validate: func(token string) (jwt.MapClaims, error) {
+			return auth.ValidateTokenWithIssuer(token, cfg.InternalSecret, cfg.PatSecret, cfg.IssuerUri)
+		},
+	}
+}
+
+// canManageModules and canManageProviders are the team permissions required
+// to publish modules and providers.
+func canManageModules(t client.Team) bool   { return t.ManageModule }
+func canManageProviders(t client.Team) bool { return t.ManageProvider }
+
+// require returns a middleware that lets the request through only when the
+// caller has permission in the organization of the route.
+func (a *publishAuth) require(permission func(client.Team) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org := c.Param("org")
+		if !pathSegment.MatchString(org) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid path segment %q", org)})
+			return
+		}
+
+		authHeader := c.GetHeader("Authorization")
+		token := strings.TrimPrefix(authHeader, "Bearer ")
+		if token == "" || token == authHeader {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Bearer token required"})
+			return
+		}
+		claims, err := a.validate(token)
+		if err != nil {
+			log.Printf("Token validation failed: %v", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
+			return
+		}
+
+		if iss, _ := claims["iss"].(string); iss == "TerrakubeInternal" {
+			c.Next()
+			return
+		}
+		groups := tokenGroups(claims)
+		if a.ownerGroup != "" && groups[a.ownerGroup] {
+			c.Next()
+			return
+		}
+
+		orgId, err := a.api.OrganizationID(org)
+		if err != nil {
+			log.Printf("Publish authorization: %v", err)
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+			return
+		}
+		teams, err := a.api.Teams(orgId)
+		if err != nil {
+			log.Printf("Publish authorization: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			return
+		}
+		for _, team := range teams {
+			if groups[team.Name] && permission(team) {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("no permission to publish in organization %s", org)})
+	}
+}
+
+// tokenGroups returns the groups claim of a token as a set.
+func tokenGroups(claims jwt.MapClaims) map[string]bool {
+	groups := map[string]bool{}
+	switch g := claims["groups"].(type) {
+	case []interface{}:
+		for _, v := range g {
+			if s, ok := v.(string); ok {
+				groups[s] = true
+			}
+		}
+	case []string:
+		for _, s := range g {
+			groups[s] = true
+		}
+	case string:
+		groups[g] = true
+	}
+	return groups
+}
@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ilkerispir/terrakubed/internal/client"
+)
+
+// fakeTeamAPI serves the teams of the "acme" organization.
+type fakeTeamAPI struct {
+	teams []client.Team
+}
+
+func (f *fakeTeamAPI) OrganizationID(name string) (string, error) {
+	if name != "acme" {
+		return "", errors.New("organization not found")
+	}
+	return "org-acme", nil
+}
+
+func (f *fakeTeamAPI) Teams(orgId string) ([]client.Team, error) {
+	return f.teams, nil
+}
+
+func TestPublishAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tokens := map[string]jwt.MapClaims{
+		"internal":  {"iss": "TerrakubeInternal"},
+		"owner":     {"iss": "Terrakube", "groups": []interface{}{"TERRAKUBE_ADMIN"}},
+		"publisher": {"iss": "Terrakube", "groups": []interface{}{"acme-publishers"}},
+		"developer": {"iss": "Terrakube", "groups": []interface{}{"acme-developers"}},
+		"outsider":  {"iss": "Terrakube", "groups": []interface{}{"other-publishers"}},
+	}
+	auth := &publishAuth{
+		api: &fakeTeamAPI{teams: []client.Team{
+			{Name: "acme-publishers", ManageProvider: true},
+			{Name: "acme-developers", ManageModule: true},
+		}},
+		ownerGroup: "TERRAKUBE_ADMIN",
+		validate: func(token string) (jwt.MapClaims, error) {
+			if claims, ok := tokens[token]; ok {
+				return claims, nil
+			}
+			return nil, errors.New("invalid signature")
+		},
+	}
+	r := gin.New()
+	created := func(c *gin.Context) { c.Status(http.StatusCreated) }
+	r.POST("/:org/upload", auth.require(canManageProviders), created)
+	r.POST("/:org/modules/upload", auth.require(canManageModules), created)
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{"no token", "acme", "", http.StatusUnauthorized},
+		{"not a bearer token", "acme", "publisher", http.StatusUnauthorized},
+		{"invalid token", "acme", "Bearer forged", http.StatusUnauthorized},
+		{"internal token", "acme", "Bearer internal", http.StatusCreated},
+		{"owner group", "acme", "Bearer owner", http.StatusCreated},
+		{"team with permission", "acme", "Bearer publisher", http.StatusCreated},
+		{"team without permission", "acme", "Bearer developer", http.StatusForbidden},
+		{"not in a team", "acme", "Bearer outsider", http.StatusForbidden},
+		{"unknown organization", "other", "Bearer publisher", http.StatusNotFound},
+		{"invalid organization", "acme%20org", "Bearer internal", http.StatusBadRequest},
+		{"module team", "acme/modules", "Bearer developer", http.StatusCreated},
+		{"provider team publishing modules", "acme/modules", "Bearer publisher", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/"+tt.path+"/upload", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
@@ -5,12 +5,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -18,48 +20,10 @@ import (
 	"github.com/ilkerispir/terrakubed/internal/auth"
 	"github.com/ilkerispir/terrakubed/internal/client"
 	"github.com/ilkerispir/terrakubed/internal/config"
+	"github.com/ilkerispir/terrakubed/internal/git"
 	"github.com/ilkerispir/terrakubed/internal/storage"
 )
 
-// cacheEntry stores a cached value with an expiration time.
-type cacheEntry struct {
-	value     interface{}
-	expiresAt time.Time
-}
-
-// simpleCache is a thread-safe in-memory cache with TTL.
-type simpleCache struct {
-	mu      sync.RWMutex
-	entries map[string]cacheEntry
-	ttl     time.Duration
-}
-
-func newCache(ttl time.Duration) *simpleCache {
-	return &simpleCache{
-		entries: make(map[string]cacheEntry),
-		ttl:     ttl,
-	}
-}
-
-func (c *simpleCache) Get(key string) (interface{}, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	entry, ok := c.entries[key]
-	if !ok || time.Now().After(entry.expiresAt) {
-		return nil, false
-	}
-	return entry.value, true
-}
-
-func (c *simpleCache) Set(key string, value interface{}) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.entries[key] = cacheEntry{
-		value:     value,
-		expiresAt: time.Now().Add(c.ttl),
-	}
-}
-
 // jwtAuthMiddleware validates JWT tokens for protected endpoints.
 func jwtAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -131,6 +95,8 @@ func Start(cfg *config.Config) {
 		response := gin.H{
 			"modules.v1":   "/terraform/modules/v1/",
 			"providers.v1": "/terraform/providers/v1/",
+			// Informational: Terraform takes the mirror URL from network_mirror in the CLI config.
+			"mirror.v1": "/terraform/mirror/v1/",
 		}
 
 		if cfg.AppClientId != "" && cfg.IssuerUri != "" {
@@ -186,22 +152,43 @@ func Start(cfg *config.Config) {
 		log.Fatalf("Failed to initialize storage service (%s): %v", cfg.RegistryStorageType, err)
 	}
 
-	// Cache for module lookups (10 minute TTL)
-	moduleCache := newCache(10 * time.Minute)
+	// Cache for module and provider lookups
+	moduleCache := newRegistryCache(cfg.RegistryCache, cfg.RedisAddress, cfg.RedisPassword,
+		registryCacheSize(cfg.RegistryCacheSize), registryCacheTTL(cfg.RegistryCacheTTL))
+
+	// Signed module download URLs; the key defaults to the internal secret
+	signingKey := cfg.ModuleDownloadSigningKey
+	if signingKey == "" {
+		signingKey = cfg.InternalSecret
+	}
+	signer := newURLSigner(signingKey, moduleDownloadURLTTL(cfg.ModuleDownloadUrlTtl))
+	if signer == nil {
+		log.Printf("Warning: no module download signing key, module.zip downloads are public")
+	}
+
+	// Download statistics, reported to the API in the background
+	var downloads *downloadRecorder
+	if cfg.RegistryDownloadStats {
+		downloads = newDownloadRecorder(apiClient, 10000)
+		go downloads.Start(context.Background())
+	}
 
 	// Protected endpoints group
 	protected := r.Group("/")
 	protected.Use(jwtAuthMiddleware(cfg))
 
+	// Uploads additionally require permission in the target organization
+	publishAuth := newPublishAuth(cfg, apiClient)
+
 	// List Module Versions (protected)
 	protected.GET("/terraform/modules/v1/:org/:name/:provider/versions", func(c *gin.Context) {
 		org := c.Param("org")
 		name := c.Param("name")
 		provider := c.Param("provider")
 
-		cacheKey := fmt.Sprintf("versions-%s-%s-%s", org, name, provider)
+		cacheKey := moduleVersionsKey(org, name, provider)
 		if cached, ok := moduleCache.Get(cacheKey); ok {
-			c.JSON(http.StatusOK, cached)
+			c.Data(http.StatusOK, "application/json; charset=utf-8", cached)
 			return
 		}
 
@@ -225,10 +212,24 @@ func Start(cfg *config.Config) {
 			},
 		}
 
-		moduleCache.Set(cacheKey, response)
+		if body, err := json.Marshal(response); err == nil {
+			moduleCache.Set(cacheKey, body)
+		}
 		c.JSON(http.StatusOK, response)
 	})
 
+	// fetchModule clones a module version's tag into storage, unless it is
+	// already there, and returns its download URL.
+	fetchModule := func(org, name, provider, version string) (string, error) {
+		moduleDetails, orgId, err := apiClient.GetModule(org, name, provider)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", errModuleNotFound, err)
+		}
+
+		vcsType, accessToken := moduleCredentials(apiClient, orgId, moduleDetails)
+		return storageService.SearchModule(org, orgId, name, provider, version, moduleDetails.Source, vcsType, accessToken, moduleDetails.TagPrefix, moduleDetails.Folder)
+	}
+
 	// Download Module Version (protected)
 	protected.GET("/terraform/modules/v1/:org/:name/:provider/:version/download", func(c *gin.Context) {
 		org := c.Param("org")
@@ -236,51 +237,27 @@ func Start(cfg *config.Config) {
 		provider := c.Param("provider")
 		version := c.Param("version")
 
-		cacheKey := fmt.Sprintf("download-%s-%s-%s-%s", org, name, provider, version)
+		cacheKey := moduleDownloadKey(org, name, provider, version)
 		if cached, ok := moduleCache.Get(cacheKey); ok {
-			c.Header("X-Terraform-Get", cached.(string))
+			c.Header("X-Terraform-Get", signer.sign(string(cached)))
 			c.Status(http.StatusNoContent)
 			return
 		}
 
-		moduleDetails, orgId, err := apiClient.GetModule(org, name, provider)
-		if err != nil {
+		path, err := fetchModule(org, name, provider, version)
+		if errors.Is(err, errModuleNotFound) {
 			log.Printf("Error fetching module details: %v", err)
 			c.JSON(http.StatusNotFound, gin.H{"error": "Module not found"})
 			return
 		}
-
-		source := moduleDetails.Source
-		folder := moduleDetails.Folder
-		tagPrefix := moduleDetails.TagPrefix
-		vcsType := "PUBLIC"
-		accessToken := ""
-
-		if moduleDetails.Vcs != nil && len(moduleDetails.Vcs.Edges) > 0 {
-			vcsNode := moduleDetails.Vcs.Edges[0].Node
-			vcsType = vcsNode.VcsType
-
-			token, err := apiClient.GetVcsToken(orgId, vcsNode.ID)
-			if err == nil {
-				accessToken = token
-			} else {
-				log.Printf("Warning: Failed to fetch VCS token for VCS ID %s: %v", vcsNode.ID, err)
-			}
-		} else if moduleDetails.Ssh != nil && len(moduleDetails.Ssh.Edges) > 0 {
-			sshNode := moduleDetails.Ssh.Edges[0].Node
-			vcsType = "SSH~" + sshNode.SshType
-			accessToken = sshNode.PrivateKey
-		}
-
-		path, err := storageService.SearchModule(org, name, provider, version, source, vcsType, accessToken, tagPrefix, folder)
 		if err != nil {
 			log.Printf("Error searching/processing module: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process module download"})
 			return
 		}
 
-		moduleCache.Set(cacheKey, path)
-		c.Header("X-Terraform-Get", path)
+		moduleCache.Set(cacheKey, []byte(path))
+		c.Header("X-Terraform-Get", signer.sign(path))
 		c.Status(http.StatusNoContent)
 	})
 
@@ -295,7 +272,7 @@ func Start(cfg *config.Config) {
 		if err != nil {
 			// Module not yet in storage, return URL pointing to module zip
 			path := fmt.Sprintf("%s/terraform/modules/v1/download/%s/%s/%s/%s/module.zip", cfg.AzBuilderRegistry, org, name, provider, version)
-			c.Header("X-Terraform-Get", path)
+			c.Header("X-Terraform-Get", signer.sign(path))
 			c.Status(http.StatusNoContent)
 			return
 		}
@@ -312,7 +289,7 @@ func Start(cfg *config.Config) {
 
 		c.JSON(http.StatusOK, gin.H{
 			"content": base64.StdEncoding.EncodeToString([]byte(readmeContent)),
-			"url":     downloadURL,
+			"url":     signer.sign(downloadURL),
 		})
 	})
 
@@ -323,6 +300,11 @@ func Start(cfg *config.Config) {
 		provider := c.Param("provider")
 		version := c.Param("version")
 
+		if err := signer.verify(c.Request.URL.Path, c.Request.URL.Query()); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+
 		reader, err := storageService.DownloadModule(org, name, provider, version)
 		if err != nil {
 			log.Printf("Error downloading module zip: %v", err)
@@ -338,6 +320,7 @@ func Start(cfg *config.Config) {
 			"X-Terraform-Get": "",
 		}
 
+		downloads.recordRequest(c, client.DownloadEvent{Type: "module", Organization: org, Name: name, Provider: provider, Version: version})
 		c.DataFromReader(http.StatusOK, -1, "application/zip", reader, extraHeaders)
 	})
 
@@ -346,6 +329,12 @@ func Start(cfg *config.Config) {
 		org := c.Param("org")
 		provider := c.Param("provider")
 
+		cacheKey := providerVersionsKey(org, provider)
+		if cached, ok := moduleCache.Get(cacheKey); ok {
+			c.Data(http.StatusOK, "application/json; charset=utf-8", cached)
+			return
+		}
+
 		versions, err := apiClient.GetProviderVersions(org, provider)
 		if err != nil {
 			log.Printf("Error fetching provider versions: %v", err)
@@ -353,9 +342,13 @@ func Start(cfg *config.Config) {
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
+		response := gin.H{
 			"versions": versions,
-		})
+		}
+		if body, err := json.Marshal(response); err == nil {
+			moduleCache.Set(cacheKey, body)
+		}
+		c.JSON(http.StatusOK, response)
 	})
 
 	protected.GET("/terraform/providers/v1/:org/:provider/:version/download/:os/:arch", func(c *gin.Context) {
@@ -365,6 +358,14 @@ func Start(cfg *config.Config) {
 		osParam := c.Param("os")
 		arch := c.Param("arch")
 
+		download := client.DownloadEvent{Type: "provider", Organization: org, Name: provider, Version: version, OS: osParam, Arch: arch}
+		cacheKey := providerDownloadKey(org, provider, version, osParam, arch)
+		if cached, ok := moduleCache.Get(cacheKey); ok {
+			downloads.recordRequest(c, download)
+			c.Data(http.StatusOK, "application/json; charset=utf-8", cached)
+			return
+		}
+
 		fileData, err := apiClient.GetProviderFile(org, provider, version, osParam, arch)
 		if err != nil {
 			log.Printf("Error fetching provider file info: %v", err)
@@ -372,15 +373,115 @@ func Start(cfg *config.Config) {
 			return
 		}
 
+		if body, err := json.Marshal(fileData); err == nil {
+			moduleCache.Set(cacheKey, body)
+		}
+		downloads.recordRequest(c, download)
 		c.JSON(http.StatusOK, fileData)
 	})
 
+	// Module uploads (protected)
+	docs := newModuleDocs(storageService, func(org, name, provider, version string) error {
+		_, err := fetchModule(org, name, provider, version)
+		return err
+	})
+	newModuleUploader(storageService, apiClient, moduleCache, docs, moduleUploadMaxSize(cfg)).
+		register(protected, publishAuth.require(canManageModules))
+
+	// Module documentation (protected)
+	docs.register(protected)
+
+	// Module version discovery from VCS tags (protected)
+	var eagerFetch func(org, name, provider, version string) error
+	if cfg.ModuleTagSyncEager {
+		eagerFetch = func(org, name, provider, version string) error {
+			_, err := fetchModule(org, name, provider, version)
+			return err
+		}
+	}
+	tags := newTagSync(apiClient, git.NewService(), moduleCache, tagSyncInterval(cfg.ModuleTagSyncInterval), eagerFetch)
+	tags.register(protected, publishAuth.require(canManageModules))
+	go tags.Start(context.Background())
+
+	// Private provider uploads (protected) and package downloads (public)
+	newProviderPublisher(storageService, apiClient, moduleCache, cfg.AzBuilderRegistry, providerUploadMaxSize(cfg)).
+		register(protected, r, publishAuth.require(canManageProviders))
+
+	// Provider network mirror (protected)
+	newProviderMirror(storageService, cfg.ProviderMirrorUpstreams).register(protected)
+
 	log.Printf("Starting Registry Service on port %s", cfg.Port)
 	if err := r.Run(fmt.Sprintf(":%s", cfg.Port)); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
 
+type vcsTokenSource interface {
+	GetVcsToken(orgId, vcsId string) (string, error)
+}
+
+// moduleCredentials returns the VCS type and token or SSH key that clone the
+// module's repository.
+func moduleCredentials(tokens vcsTokenSource, orgId string, module *client.ModuleDetails) (string, string) {
+	if module.Vcs != nil && len(module.Vcs.Edges) > 0 {
+		vcsNode := module.Vcs.Edges[0].Node
+		token, err := tokens.GetVcsToken(orgId, vcsNode.ID)
+		if err != nil {
+			log.Printf("Warning: Failed to fetch VCS token for VCS ID %s: %v", vcsNode.ID, err)
+		}
+		return vcsNode.VcsType, token
+	}
+	if module.Ssh != nil && len(module.Ssh.Edges) > 0 {
+		sshNode := module.Ssh.Edges[0].Node
+		return "SSH~" + sshNode.SshType, sshNode.PrivateKey
+	}
+	return "PUBLIC", ""
+}
+
+// errModuleNotFound is returned when the API does not know a module.
+var errModuleNotFound = errors.New("module not found")
+
+// registryCacheSize returns the number of entries the in-memory cache holds
+// (default 10000).
+func registryCacheSize(value string) int {
+	if size, err := strconv.Atoi(value); err == nil && size > 0 {
+		return size
+	}
+	return 10000
+}
+
+// registryCacheTTL returns how long lookups are cached (default 10 minutes).
+func registryCacheTTL(value string) time.Duration {
+	if ttl, err := time.ParseDuration(value); err == nil && ttl > 0 {
+		return ttl
+	}
+	return 10 * time.Minute
+}
+
+// moduleDownloadURLTTL returns how long signed download URLs are valid
+// (default 10 minutes).
+func moduleDownloadURLTTL(value string) time.Duration {
+	if ttl, err := time.ParseDuration(value); err == nil && ttl > 0 {
+		return ttl
+	}
+	return 10 * time.Minute
+}
+
+// moduleUploadMaxSize returns the module upload limit in bytes (default 50 MB).
+func moduleUploadMaxSize(cfg *config.Config) int64 {
+	if mb, err := strconv.ParseInt(cfg.ModuleUploadMaxSizeMb, 10, 64); err == nil && mb > 0 {
+		return mb << 20
+	}
+	return 50 << 20
+}
+
+func providerUploadMaxSize(cfg *config.Config) int64 {
+	if mb, err := strconv.ParseInt(cfg.ProviderUploadMaxSizeMb, 10, 64); err == nil && mb > 0 {
+		return mb << 20
+	}
+	return 500 << 20
+}
+
 // extractReadmeFromZip looks for a README.md file inside a ZIP archive.
 func extractReadmeFromZip(data []byte) string {
 	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// urlSigner signs module download URLs, so the public module.zip route only
+// serves clients the protected download endpoint sent there. A signature
+// covers the URL path from the download route on and an expiry, and is checked
+// with the same key by every replica.
+type urlSigner struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+var (
+	errUnsignedURL = errors.New("download URL is not signed")
+	errExpiredURL  = errors.New("download URL has expired")
+	errInvalidURL  = errors.New("download URL signature is invalid")
+)
+
+// newURLSigner returns a signer for the key, or nil when the key is empty.
+// Keys shared with other services are derived, so a signature cannot be
+// reused as anything else.
+func newURLSigner(key string, ttl time.Duration) *urlSigner {
+	if key == "" {
+		return nil
+	}
+	derived := hmac.New(sha256.New, []byte(key))
+	derived.Write([]byte("terrakube module download"))
+	return &urlSigner{key: derived.Sum(nil), ttl: ttl, now: time.Now}
+}
+
+// moduleDownloadRoute is where the module.zip route starts. A registry URL
+// with a path, such as https://example.com/registry, puts a prefix in front of
+// it in signed URLs that proxies strip before requests reach the route.
+const moduleDownloadRoute = "/terraform/modules/v1/download/"
+
+// routePath returns path from the download route on, so signed and requested
+// paths compare equal whatever prefix the registry URL has.
+func routePath(path string) string {
+	if i := strings.LastIndex(path, moduleDownloadRoute); i >= 0 {
+		return path[i:]
+	}
+	return path
+}
+
+func (s *urlSigner) signature(path string, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(routePath(path) + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sign adds expires and signature parameters to rawURL. A nil signer, or a
+// URL that does not parse, returns it unchanged.
+func (s *urlSigner) sign(rawURL string) string {
+	if s == nil {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	expires := s.now().Add(s.ttl).Unix()
+	query := u.Query()
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.signature(u.Path, expires))
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// verify checks the signature of a request for path. A nil signer accepts
+// every request.
+func (s *urlSigner) verify(path string, query url.Values) error {
+	if s == nil {
+		return nil
+	}
+	signature, expiresParam := query.Get("signature"), query.Get("expires")
+	if signature == "" || expiresParam == "" {
+		return errUnsignedURL
+	}
+	expires, err := strconv.ParseInt(expiresParam, 10, 64)
+	if err != nil {
+		return errInvalidURL
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.signature(path, expires))) {
+		return errInvalidURL
+	}
+	if s.now().Unix() > expires {
+		return errExpiredURL
+	}
+	return nil
+}
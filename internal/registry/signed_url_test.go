@@ -0,0 +1,88 @@
+package registry
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLSigner(t *testing.T) {
+	const path = "/terraform/modules/v1/download/org/vpc/aws/1.0.0/module.zip"
+	signedAt := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name    string
+		signer  *urlSigner
+		path    string
+		after   time.Duration
+		tamper  func(url.Values)
+		wantErr error
+	}{
+		{"valid", newURLSigner("secret", time.Minute), path, 0, nil, nil},
+		{"valid until expiry", newURLSigner("secret", time.Minute), path, time.Minute, nil, nil},
+		{"expired", newURLSigner("secret", time.Minute), path, time.Minute + time.Second, nil, errExpiredURL},
+		{"other path", newURLSigner("secret", time.Minute), strings.Replace(path, "1.0.0", "2.0.0", 1), 0, nil, errInvalidURL},
+		{"other key", newURLSigner("other", time.Minute), path, 0, nil, errInvalidURL},
+		{"extended expiry", newURLSigner("secret", time.Minute), path, 0, func(q url.Values) { q.Set("expires", "9999999999") }, errInvalidURL},
+		{"bad expiry", newURLSigner("secret", time.Minute), path, 0, func(q url.Values) { q.Set("expires", "soon") }, errInvalidURL},
+		{"unsigned", newURLSigner("secret", time.Minute), path, 0, func(q url.Values) { q.Del("signature") }, errUnsignedURL},
+		{"no key accepts all", nil, path, 0, func(q url.Values) { q.Del("signature") }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := newURLSigner("secret", time.Minute)
+			signer.now = func() time.Time { return signedAt }
+			signed, err := url.Parse(signer.sign("https://registry.example.com" + path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			query := signed.Query()
+			if tt.tamper != nil {
+				tt.tamper(query)
+			}
+			if tt.signer != nil {
+				tt.signer.now = func() time.Time { return signedAt.Add(tt.after) }
+			}
+			if err := tt.signer.verify(tt.path, query); err != tt.wantErr {
+				t.Errorf("verify() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestURLSignerPrefixedURL(t *testing.T) {
+	const path = "/terraform/modules/v1/download/org/vpc/aws/1.0.0/module.zip"
+	signer := newURLSigner("secret", time.Minute)
+	signed, err := url.Parse(signer.sign("https://example.com/registry" + path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A proxy strips /registry before the request reaches the route.
+	if err := signer.verify(path, signed.Query()); err != nil {
+		t.Errorf("verify() of prefixed URL = %v", err)
+	}
+	if err := signer.verify(strings.Replace(path, "vpc", "eks", 1), signed.Query()); err != errInvalidURL {
+		t.Errorf("verify() of other module = %v, want %v", err, errInvalidURL)
+	}
+}
+
+func TestURLSignerSign(t *testing.T) {
+	var unsigned *urlSigner
+	if got := unsigned.sign("/module.zip"); got != "/module.zip" {
+		t.Errorf("nil signer sign() = %q, want the URL unchanged", got)
+	}
+
+	signer := newURLSigner("secret", time.Minute)
+	signed, err := url.Parse(signer.sign("https://registry.example.com/module.zip?archive=zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := signed.Query()
+	if query.Get("archive") != "zip" {
+		t.Errorf("sign() dropped query parameters: %s", signed)
+	}
+	if err := signer.verify(signed.Path, query); err != nil {
+		t.Errorf("verify() of signed URL = %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	goversion "github.com/hashicorp/go-version"
+	"github.com/ilkerispir/terrakubed/internal/client"
+	"github.com/ilkerispir/terrakubed/internal/git"
+)
+
+// tagSyncAPI is the part of the API client used to discover module versions.
+type tagSyncAPI interface {
+	vcsTokenSource
+	ListModules() ([]client.RegistryModule, error)
+	GetModule(organization, module, provider string) (*client.ModuleDetails, string, error)
+	GetModuleVersions(organization, module, provider string) ([]string, error)
+	CreateModuleVersion(moduleId, version, commit string) error
+}
+
+type tagLister interface {
+	ListTags(orgId, source, vcsType, accessToken string) ([]git.Tag, error)
+}
+
+// tagSync registers module versions for the tags of module repositories, so
+// new tags are listed without adding versions by hand:
+//
+//	POST /terraform/modules/v1/{org}/{name}/{provider}/sync  → synchronizes one module now
+//
+// Every module is synchronized each interval when one is configured. Tags are
+// matched after the module's tag prefix and an optional "v", and must be
+// semantic versions. Missing versions are created oldest first, so the
+// module's latest version ends on the newest release; pre-releases are
+// registered but never become the latest version.
+type tagSync struct {
+	api      tagSyncAPI
+	git      tagLister
+	cache    registryCache
+	interval time.Duration
+	// fetch, when set, clones new versions into storage right away instead of
+	// on their first download.
+	fetch func(org, name, provider, version string) error
+}
+
+func newTagSync(api tagSyncAPI, lister tagLister, cache registryCache, interval time.Duration, fetch func(org, name, provider, version string) error) *tagSync {
+	return &tagSync{api: api, git: lister, cache: cache, interval: interval, fetch: fetch}
+}
+
+func (s *tagSync) register(protected gin.IRoutes, authorize gin.HandlerFunc) {
+	protected.POST("/terraform/modules/v1/:org/:name/:provider/sync", authorize, s.handleSync)
+}
+
+// Start synchronizes every module each interval until ctx is done. It does
+// nothing without an interval.
+func (s *tagSync) Start(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+	log.Printf("Module tag sync starting (interval: %s)", s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.syncAll()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Module tag sync stopped")
+			return
+		case <-ticker.C:
+			s.syncAll()
+		}
+	}
+}
+
+func (s *tagSync) syncAll() {
+	modules, err := s.api.ListModules()
+	if err != nil {
+		log.Printf("Module tag sync: failed to list modules: %v", err)
+		return
+	}
+	for _, module := range modules {
+		if module.Source == "" {
+			continue // uploaded modules have no repository
+		}
+		if _, err := s.syncModule(module); err != nil {
+			log.Printf("Module tag sync %s/%s/%s: %v", module.Organization, module.Name, module.Provider, err)
+		}
+	}
+}
+
+func (s *tagSync) handleSync(c *gin.Context) {
+	org, name, provider := c.Param("org"), c.Param("name"), c.Param("provider")
+	for _, segment := range []string{org, name, provider} {
+		if !pathSegment.MatchString(segment) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid path segment %q", segment)})
+			return
+		}
+	}
+	details, orgId, err := s.api.GetModule(org, name, provider)
+	if err != nil {
+		log.Printf("Module tag sync: %v", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Module not found"})
+		return
+	}
+	if details.Source == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Module has no repository"})
+		return
+	}
+	created, err := s.syncModule(client.RegistryModule{
+		ModuleDetails: *details, Name: name, Provider: provider,
+		Organization: org, OrganizationID: orgId,
+	})
+	if err != nil {
+		log.Printf("Module tag sync %s/%s/%s: %v", org, name, provider, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"created": created})
+}
+
+// syncModule creates the versions tagged in the module's repository that the
+// API does not have yet, and returns them.
+func (s *tagSync) syncModule(module client.RegistryModule) ([]string, error) {
+	vcsType, accessToken := moduleCredentials(s.api, module.OrganizationID, &module.ModuleDetails)
+	tags, err := s.git.ListTags(module.OrganizationID, module.Source, vcsType, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	existing, err := s.api.GetModuleVersions(module.Organization, module.Name, module.Provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read versions: %w", err)
+	}
+
+	created := []string{}
+	for _, tagged := range missingTagVersions(tags, module.TagPrefix, existing) {
+		if err := s.api.CreateModuleVersion(module.ID, tagged.version, tagged.commit); err != nil {
+			return created, err
+		}
+		created = append(created, tagged.version)
+		log.Printf("Module tag sync: registered %s/%s/%s %s", module.Organization, module.Name, module.Provider, tagged.version)
+	}
+	if len(created) == 0 {
+		return created, nil
+	}
+	s.cache.Delete(moduleVersionsKey(module.Organization, module.Name, module.Provider))
+
+	if s.fetch != nil {
+		for _, version := range created {
+			if err := s.fetch(module.Organization, module.Name, module.Provider, version); err != nil {
+				log.Printf("Module tag sync: failed to store %s/%s/%s %s: %v", module.Organization, module.Name, module.Provider, version, err)
+			}
+		}
+	}
+	return created, nil
+}
+
+// semverTag matches a tag, after the module's prefix, that names a release.
+var semverTag = regexp.MustCompile(`^v?(\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?)$`)
+
+type taggedVersion struct {
+	version string // as registered; the registry clones tag prefix + ("v" +) version
+	commit  string
+	parsed  *goversion.Version
+}
+
+// missingTagVersions returns the semver tags with the prefix whose version is
+// not in existing, oldest first. A version tagged both with and without "v"
+// is returned once.
+func missingTagVersions(tags []git.Tag, tagPrefix string, existing []string) []taggedVersion {
+	seen := map[string]bool{}
+	for _, version := range existing {
+		if v, err := goversion.NewVersion(version); err == nil {
+			seen[v.String()] = true
+		}
+	}
+
+	var missing []taggedVersion
+	for _, tag := range tags {
+		rest, ok := strings.CutPrefix(tag.Name, tagPrefix)
+		if !ok {
+			continue
+		}
+		match := semverTag.FindStringSubmatch(rest)
+		if match == nil {
+			continue
+		}
+		v, err := goversion.NewVersion(match[1])
+		if err != nil || seen[v.String()] {
+			continue
+		}
+		seen[v.String()] = true
+		missing = append(missing, taggedVersion{version: match[1], commit: tag.Commit, parsed: v})
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i].parsed.LessThan(missing[j].parsed) })
+	return missing
+}
+
+// tagSyncInterval parses MODULE_TAG_SYNC_INTERVAL; sync is off when it is
+// empty or invalid.
+func tagSyncInterval(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		log.Printf("Warning: invalid module tag sync interval %q, periodic sync is disabled", value)
+		return 0
+	}
+	return interval
+}
@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ilkerispir/terrakubed/internal/client"
+	"github.com/ilkerispir/terrakubed/internal/git"
+)
+
+func TestMissingTagVersions(t *testing.T) {
+	tags := []git.Tag{
+		{Name: "v1.10.0", Commit: "c3"},
+		{Name: "v1.2.0", Commit: "c2"},
+		{Name: "1.2.0", Commit: "c2"},
+		{Name: "v2.0.0-rc.1", Commit: "c4"},
+		{Name: "v1.0.0", Commit: "c1"},
+		{Name: "latest", Commit: "c3"},
+		{Name: "v1.3", Commit: "c3"},
+		{Name: "vpc/v0.9.0", Commit: "c0"},
+	}
+	tests := []struct {
+		name      string
+		tagPrefix string
+		existing  []string
+		want      []string
+	}{
+		{"semver order", "", nil, []string{"1.0.0", "1.2.0", "1.10.0", "2.0.0-rc.1"}},
+		{"existing versions", "", []string{"1.0.0", "v1.10.0"}, []string{"1.2.0", "2.0.0-rc.1"}},
+		{"tag prefix", "vpc/", nil, []string{"0.9.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, v := range missingTagVersions(tags, tt.tagPrefix, tt.existing) {
+				got = append(got, v.version)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("versions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeTagSyncAPI knows the vcs-backed module acme/vpc/aws.
+type fakeTagSyncAPI struct {
+	versions []string
+	commits  []string
+}
+
+func (f *fakeTagSyncAPI) GetVcsToken(orgId, vcsId string) (string, error) { return "token", nil }
+
+func (f *fakeTagSyncAPI) ListModules() ([]client.RegistryModule, error) {
+	details, orgId, _ := f.GetModule("acme", "vpc", "aws")
+	return []client.RegistryModule{
+		{ModuleDetails: *details, Name: "vpc", Provider: "aws", Organization: "acme", OrganizationID: orgId},
+		{ModuleDetails: client.ModuleDetails{ID: "module-2"}, Name: "uploaded", Provider: "aws", Organization: "acme"},
+	}, nil
+}
+
+func (f *fakeTagSyncAPI) GetModule(organization, module, provider string) (*client.ModuleDetails, string, error) {
+	if module != "vpc" {
+		return nil, "", errModuleNotFound
+	}
+	return &client.ModuleDetails{ID: "module-1", Source: "https://git.example.com/vpc.git"}, "org-1", nil
+}
+
+func (f *fakeTagSyncAPI) GetModuleVersions(organization, module, provider string) ([]string, error) {
+	return f.versions, nil
+}
+
+func (f *fakeTagSyncAPI) CreateModuleVersion(moduleId, version, commit string) error {
+	f.versions = append(f.versions, version)
+	f.commits = append(f.commits, commit)
+	return nil
+}
+
+type fakeTagLister []git.Tag
+
+func (f fakeTagLister) ListTags(orgId, source, vcsType, accessToken string) ([]git.Tag, error) {
+	return f, nil
+}
+
+func TestTagSync(t *testing.T) {
+	api := &fakeTagSyncAPI{versions: []string{"1.0.0"}}
+	lister := fakeTagLister{{Name: "v1.1.0", Commit: "c2"}, {Name: "v1.0.0", Commit: "c1"}, {Name: "v1.0.1", Commit: "c3"}}
+	cache := newLRUCache(10, time.Minute)
+	cache.Set(moduleVersionsKey("acme", "vpc", "aws"), []byte("stale"))
+	var fetched []string
+	sync := newTagSync(api, lister, cache, 0, func(org, name, provider, version string) error {
+		fetched = append(fetched, version)
+		return nil
+	})
+
+	sync.syncAll()
+	if got := strings.Join(api.versions, ","); got != "1.0.0,1.0.1,1.1.0" {
+		t.Errorf("versions = %s", got)
+	}
+	if got := strings.Join(api.commits, ","); got != "c3,c2" {
+		t.Errorf("commits = %s", got)
+	}
+	if got := strings.Join(fetched, ","); got != "1.0.1,1.1.0" {
+		t.Errorf("fetched = %s", got)
+	}
+	if _, ok := cache.Get(moduleVersionsKey("acme", "vpc", "aws")); ok {
+		t.Error("cached version list was not invalidated")
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	sync.register(r, func(*gin.Context) {})
+	tests := []struct {
+		name string
+		path string
+		want int
+		body string
+	}{
+		{"up to date", "/terraform/modules/v1/acme/vpc/aws/sync", http.StatusOK, `{"created":[]}`},
+		{"unknown module", "/terraform/modules/v1/acme/eks/aws/sync", http.StatusNotFound, ""},
+		{"filter injection", `/terraform/modules/v1/acme/vpc%22%20or%20name==%22eks/aws/sync`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, nil))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Errorf("body = %s, want %s", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/ilkerispir/terrakubed/internal/auth"
 	"github.com/ilkerispir/terrakubed/internal/client"
@@ -17,8 +18,16 @@ type StatusService interface {
 	SetCompleted(job *model.TerraformJob, success bool, output string) error
 	SetPending(job *model.TerraformJob, output string) error
 	SetApprovalCompleted(job *model.TerraformJob, output string) error
+	SetWaitingPolicyOverride(job *model.TerraformJob, output string) error
 	UpdateCommitId(job *model.TerraformJob, commitId string) error
+	UpdatePlanSummary(job *model.TerraformJob, planChanges bool, add, change, destroy, replace int) error
 	CreateHistory(job *model.TerraformJob, stateURL string) error
+	UpdateDriftStatus(job *model.TerraformJob, driftStatus model.DriftStatus, checkedAt time.Time) error
+	CreateAddresses(job *model.TerraformJob, addresses []model.ResourceAddress) error
+	GetPolicySets(job *model.TerraformJob) ([]model.PolicySet, error)
+	GetNotifications(job *model.TerraformJob) ([]model.Notification, error)
+	GetPullRequest(job *model.TerraformJob) (*model.PullRequest, error)
+	GetVcsToken(job *model.TerraformJob) (string, error)
 }
 
 type Service struct {
@@ -99,14 +108,88 @@ func (s *Service) SetApprovalCompleted(job *model.TerraformJob, output string) e
 	return s.client.UpdateJobStatus(job.OrganizationId, job.JobId, "pending", "")
 }
 
+// SetWaitingPolicyOverride completes the plan step but parks the job in
+// "waitingPolicyOverride": a soft-mandatory policy failed and a member of the
+// policy set's override team must release it (the API sets the job back to
+// "pending"). The regular approval flow cannot release this status.
+func (s *Service) SetWaitingPolicyOverride(job *model.TerraformJob, output string) error {
+	outputPath := s.saveOutput(job.OrganizationId, job.JobId, job.StepId, output)
+	if err := s.client.UpdateStepStatus(job.OrganizationId, job.JobId, job.StepId, "completed", outputPath); err != nil {
+		return fmt.Errorf("failed to update step status: %w", err)
+	}
+	return s.client.UpdateJobStatus(job.OrganizationId, job.JobId, "waitingPolicyOverride", "")
+}
+
 func (s *Service) UpdateCommitId(job *model.TerraformJob, commitId string) error {
 	return s.client.UpdateJobCommitId(job.OrganizationId, job.JobId, commitId)
 }
 
+func (s *Service) UpdatePlanSummary(job *model.TerraformJob, planChanges bool, add, change, destroy, replace int) error {
+	return s.client.UpdateJobPlanSummary(job.OrganizationId, job.JobId, planChanges, add, change, destroy, replace)
+}
+
 func (s *Service) CreateHistory(job *model.TerraformJob, stateURL string) error {
 	return s.client.CreateHistory(job.OrganizationId, job.WorkspaceId, stateURL)
 }
 
+func (s *Service) UpdateDriftStatus(job *model.TerraformJob, driftStatus model.DriftStatus, checkedAt time.Time) error {
+	return s.client.UpdateWorkspaceDrift(job.OrganizationId, job.WorkspaceId, driftStatus, checkedAt)
+}
+
+func (s *Service) GetPolicySets(job *model.TerraformJob) ([]model.PolicySet, error) {
+	return s.client.GetPolicySets(job.OrganizationId)
+}
+
+// GetVcsToken returns a current access token of the job's VCS connection,
+// refreshed by the API when it has expired.
+func (s *Service) GetVcsToken(job *model.TerraformJob) (string, error) {
+	return s.client.GetWorkspaceVcsToken(job.OrganizationId, job.WorkspaceId)
+}
+
+// GetPullRequest returns the pull request of a speculative plan job, with the
+// API URL of the workspace's VCS connection, or nil for regular jobs.
+func (s *Service) GetPullRequest(job *model.TerraformJob) (*model.PullRequest, error) {
+	pr, err := s.client.GetJobPullRequest(job.OrganizationId, job.JobId)
+	if err != nil || pr == nil {
+		return pr, err
+	}
+	if pr.VcsApiUrl, err = s.client.GetWorkspaceVcsApiUrl(job.OrganizationId, job.WorkspaceId); err != nil {
+		log.Printf("Failed to read VCS API URL, using the provider default: %v", err)
+	}
+	return pr, nil
+}
+
+// GetNotifications returns the enabled notification destinations of the
+// organization that apply to the job's workspace.
+func (s *Service) GetNotifications(job *model.TerraformJob) ([]model.Notification, error) {
+	all, err := s.client.GetNotifications(job.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+	var result []model.Notification
+	for _, n := range all {
+		if n.Enabled && (n.WorkspaceId == "" || n.WorkspaceId == job.WorkspaceId) {
+			result = append(result, n)
+		}
+	}
+	return result, nil
+}
+
+// CreateAddresses records every address in the job's address table. It keeps
+// going after a failed POST and returns the first error seen.
+func (s *Service) CreateAddresses(job *model.TerraformJob, addresses []model.ResourceAddress) error {
+	var firstErr error
+	for _, addr := range addresses {
+		if err := s.client.CreateAddress(job.OrganizationId, job.JobId, addr.Name, addr.Type); err != nil {
+			log.Printf("Failed to create address %s: %v", addr.Name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
 // saveOutput uploads the terraform log output to object storage and returns the output URL path.
 // If upload fails, falls back to returning truncated raw output text so logs are still somewhat visible.
 func (s *Service) saveOutput(orgId, jobId, stepId, output string) string {
@@ -75,8 +75,8 @@ func NewAWSStorageService(ctx context.Context, region, bucket, hostname, endpoin
 	}, nil
 }
 
-func (s *AWSStorageService) SearchModule(org, module, provider, version, source, vcsType, accessToken, tagPrefix, folder string) (string, error) {
-	key := fmt.Sprintf("registry/%s/%s/%s/%s/module.zip", org, module, provider, version)
+func (s *AWSStorageService) SearchModule(org, orgId, module, provider, version, source, vcsType, accessToken, tagPrefix, folder string) (string, error) {
+	key := ModuleKey(org, module, provider, version)
 	path := fmt.Sprintf("%s/terraform/modules/v1/download/%s/%s/%s/%s/module.zip", s.Hostname, org, module, provider, version)
 
 	// Check if object exists
@@ -92,7 +92,7 @@ func (s *AWSStorageService) SearchModule(org, module, provider, version, source,
 	log.Printf("Module %s not found in storage, initiating clone...", key)
 
 	// Clone
-	cloneDir, err := s.GitService.CloneRepository(source, version, vcsType, accessToken, tagPrefix, folder)
+	cloneDir, err := s.GitService.CloneRepository(orgId, source, version, vcsType, accessToken, tagPrefix, folder)
 	if err != nil {
 		return "", fmt.Errorf("failed to clone repository: %w", err)
 	}
@@ -128,7 +128,7 @@ func (s *AWSStorageService) SearchModule(org, module, provider, version, source,
 }
 
 func (s *AWSStorageService) DownloadModule(org, module, provider, version string) (io.ReadCloser, error) {
-	key := fmt.Sprintf("registry/%s/%s/%s/%s/module.zip", org, module, provider, version)
+	key := ModuleKey(org, module, provider, version)
 
 	output, err := s.Client.GetObject(context.TODO(), &s3.GetObjectInput{
 		Bucket: aws.String(s.BucketName),
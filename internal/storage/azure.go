@@ -41,8 +41,8 @@ func NewAzureStorageService(accountName, accountKey, containerName, hostname str
 	}, nil
 }
 
-func (s *AzureStorageService) SearchModule(org, module, provider, version, source, vcsType, accessToken, tagPrefix, folder string) (string, error) {
-	key := fmt.Sprintf("registry/%s/%s/%s/%s/module.zip", org, module, provider, version)
+func (s *AzureStorageService) SearchModule(org, orgId, module, provider, version, source, vcsType, accessToken, tagPrefix, folder string) (string, error) {
+	key := ModuleKey(org, module, provider, version)
 	path := fmt.Sprintf("%s/terraform/modules/v1/download/%s/%s/%s/%s/module.zip", s.Hostname, org, module, provider, version)
 
 	// Check if object exists
@@ -58,7 +58,7 @@ func (s *AzureStorageService) SearchModule(org, module, provider, version, sourc
 	log.Printf("Module %s not found in Azure storage, initiating clone...", key)
 
 	// Clone
-	cloneDir, err := s.GitService.CloneRepository(source, version, vcsType, accessToken, tagPrefix, folder)
+	cloneDir, err := s.GitService.CloneRepository(orgId, source, version, vcsType, accessToken, tagPrefix, folder)
 	if err != nil {
 		return "", fmt.Errorf("failed to clone repository: %w", err)
 	}
@@ -88,7 +88,7 @@ func (s *AzureStorageService) SearchModule(org, module, provider, version, sourc
 }
 
 func (s *AzureStorageService) DownloadModule(org, module, provider, version string) (io.ReadCloser, error) {
-	key := fmt.Sprintf("registry/%s/%s/%s/%s/module.zip", org, module, provider, version)
+	key := ModuleKey(org, module, provider, version)
 
 	resp, err := s.Client.DownloadStream(context.TODO(), s.ContainerName, key, nil)
 	if err != nil {
@@ -52,8 +52,8 @@ func NewGCPStorageService(ctx context.Context, projectId, bucketName, credential
 	}, nil
 }
 
-func (s *GCPStorageService) SearchModule(org, module, provider, version, source, vcsType, accessToken, tagPrefix, folder string) (string, error) {
-	key := fmt.Sprintf("registry/%s/%s/%s/%s/module.zip", org, module, provider, version)
+func (s *GCPStorageService) SearchModule(org, orgId, module, provider, version, source, vcsType, accessToken, tagPrefix, folder string) (string, error) {
+	key := ModuleKey(org, module, provider, version)
 	path := fmt.Sprintf("%s/terraform/modules/v1/download/%s/%s/%s/%s/module.zip", s.Hostname, org, module, provider, version)
 
 	ctx := context.TODO()
@@ -69,7 +69,7 @@ func (s *GCPStorageService) SearchModule(org, module, provider, version, source,
 	log.Printf("Module %s not found in GCP storage, initiating clone...", key)
 
 	// Clone
-	cloneDir, err := s.GitService.CloneRepository(source, version, vcsType, accessToken, tagPrefix, folder)
+	cloneDir, err := s.GitService.CloneRepository(orgId, source, version, vcsType, accessToken, tagPrefix, folder)
 	if err != nil {
 		return "", fmt.Errorf("failed to clone repository: %w", err)
 	}
@@ -102,7 +102,7 @@ func (s *GCPStorageService) SearchModule(org, module, provider, version, source,
 }
 
 func (s *GCPStorageService) DownloadModule(org, module, provider, version string) (io.ReadCloser, error) {
-	key := fmt.Sprintf("registry/%s/%s/%s/%s/module.zip", org, module, provider, version)
+	key := ModuleKey(org, module, provider, version)
 
 	r, err := s.Client.Bucket(s.BucketName).Object(key).NewReader(context.TODO())
 	if err != nil {
@@ -1,13 +1,20 @@
 package storage
 
 import (
+	"fmt"
 	"io"
 )
 
 type StorageService interface {
-	SearchModule(org, module, provider, version, source, vcsType, accessToken, tagPrefix, folder string) (string, error)
+	SearchModule(org, orgId, module, provider, version, source, vcsType, accessToken, tagPrefix, folder string) (string, error)
 	DownloadModule(org, module, provider, version string) (io.ReadCloser, error)
 
 	UploadFile(path string, content io.Reader) error
 	DownloadFile(path string) (io.ReadCloser, error)
 }
+
+// ModuleKey is the storage key of a module version's archive, whether it was
+// cloned from a tag or uploaded.
+func ModuleKey(org, module, provider, version string) string {
+	return fmt.Sprintf("registry/%s/%s/%s/%s/module.zip", org, module, provider, version)
+}
@@ -0,0 +1,30 @@
+//go:build !unix
+
+package utils
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	locksMu sync.Mutex
+	locks   = map[string]*sync.Mutex{}
+)
+
+// LockFile falls back to an in-process lock where flock is unavailable.
+func LockFile(path string, wait bool) (func(), error) {
+	locksMu.Lock()
+	mu, ok := locks[path]
+	if !ok {
+		mu = &sync.Mutex{}
+		locks[path] = mu
+	}
+	locksMu.Unlock()
+	if wait {
+		mu.Lock()
+	} else if !mu.TryLock() {
+		return nil, fmt.Errorf("%s is locked", path)
+	}
+	return mu.Unlock, nil
+}
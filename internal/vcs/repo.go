@@ -0,0 +1,68 @@
+package vcs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Repo identifies a repository on its provider.
+type Repo struct {
+	Host string
+	// Path is "owner/name" on GitHub and Bitbucket, the full project path
+	// (with subgroups) on GitLab and "organization/project/repository" on
+	// Azure DevOps.
+	Path string
+}
+
+var schemeRe = regexp.MustCompile(`^[a-zA-Z+]+://`)
+
+// ParseRepo extracts the repository from a workspace source URL (HTTPS, SSH
+// or scp-style).
+func ParseRepo(vcsType, source string) (Repo, error) {
+	u := strings.TrimSpace(source)
+	hasScheme := schemeRe.MatchString(u)
+	u = schemeRe.ReplaceAllString(u, "")
+	if at := strings.Index(u, "@"); at >= 0 && at < strings.Index(u+"/", "/") {
+		u = u[at+1:]
+	}
+	host, path, ok := strings.Cut(u, "/")
+	if !hasScheme {
+		// scp-style git@host:owner/repo
+		if h, p, found := strings.Cut(u, ":"); found {
+			host, path, ok = h, p, true
+		}
+	} else if h, _, found := strings.Cut(host, ":"); found {
+		host = h // drop the port
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if !ok || host == "" || path == "" {
+		return Repo{}, fmt.Errorf("cannot parse repository from %q", source)
+	}
+	host = strings.ToLower(host)
+
+	if vcsType == "AZURE_DEVOPS" || vcsType == "AZURE_SP_MI" {
+		return parseAzureRepo(host, path, source)
+	}
+	if strings.Count(path, "/") < 1 {
+		return Repo{}, fmt.Errorf("cannot parse repository from %q", source)
+	}
+	return Repo{Host: host, Path: path}, nil
+}
+
+// parseAzureRepo handles dev.azure.com/{org}/{project}/_git/{repo},
+// {org}.visualstudio.com/{project}/_git/{repo} and
+// ssh.dev.azure.com:v3/{org}/{project}/{repo}.
+func parseAzureRepo(host, path, source string) (Repo, error) {
+	parts := strings.Split(path, "/")
+	switch {
+	case host == "ssh.dev.azure.com" && len(parts) == 4 && parts[0] == "v3":
+		return Repo{Host: "dev.azure.com", Path: strings.Join(parts[1:], "/")}, nil
+	case host == "dev.azure.com" && len(parts) == 4 && parts[2] == "_git":
+		return Repo{Host: host, Path: parts[0] + "/" + parts[1] + "/" + parts[3]}, nil
+	case strings.HasSuffix(host, ".visualstudio.com") && len(parts) == 3 && parts[1] == "_git":
+		org := strings.TrimSuffix(host, ".visualstudio.com")
+		return Repo{Host: "dev.azure.com", Path: org + "/" + parts[0] + "/" + parts[2]}, nil
+	}
+	return Repo{}, fmt.Errorf("cannot parse Azure DevOps repository from %q", source)
+}
@@ -0,0 +1,221 @@
+// Package vcs reports run results back to the VCS provider: commit statuses
+// and pull request comments for GitHub, GitLab, Bitbucket Cloud and Azure DevOps.
+package vcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// State is a provider-independent commit status state.
+type State string
+
+const (
+	StatePending State = "pending"
+	StateSuccess State = "success"
+	StateFailure State = "failure"
+	StateError   State = "error"
+)
+
+// CommitStatus is a status attached to a commit.
+type CommitStatus struct {
+	State State
+	// Context identifies the check, e.g. "terrakube/network"; statuses with the
+	// same context replace each other.
+	Context     string
+	Description string
+	TargetURL   string
+}
+
+// Client talks to one provider's REST API.
+type Client interface {
+	SetCommitStatus(ctx context.Context, repo Repo, sha string, status CommitStatus) error
+	CommentPullRequest(ctx context.Context, repo Repo, number int, body string) error
+}
+
+// New returns the client for a VCS type. An empty apiURL selects the
+// provider's public API, or the /api path of a self-hosted GitHub Enterprise
+// or GitLab instance when repo is not on the public host.
+func New(vcsType, apiURL, token string, repo Repo) (Client, error) {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL(vcsType, repo.Host)
+	}
+	base := &restClient{baseURL: strings.TrimRight(apiURL, "/"), token: token, http: &http.Client{Timeout: 15 * time.Second}}
+	switch vcsType {
+	case "GITHUB":
+		return &GitHub{base}, nil
+	case "GITLAB":
+		return &GitLab{base}, nil
+	case "BITBUCKET":
+		return &Bitbucket{base}, nil
+	case "AZURE_DEVOPS", "AZURE_SP_MI":
+		return &AzureDevOps{base}, nil
+	default:
+		return nil, fmt.Errorf("commit statuses are not supported for VCS type %q", vcsType)
+	}
+}
+
+// DefaultAPIURL returns the REST API base URL for a provider and repository host.
+func DefaultAPIURL(vcsType, host string) string {
+	switch vcsType {
+	case "GITHUB":
+		if host == "" || host == "github.com" {
+			return "https://api.github.com"
+		}
+		return "https://" + host + "/api/v3"
+	case "GITLAB":
+		if host == "" {
+			host = "gitlab.com"
+		}
+		return "https://" + host + "/api/v4"
+	case "BITBUCKET":
+		return "https://api.bitbucket.org/2.0"
+	default:
+		return "https://dev.azure.com"
+	}
+}
+
+// restClient holds what all providers share: a base URL and a bearer token.
+type restClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func (c *restClient) post(ctx context.Context, path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("POST %s: HTTP %d: %s", path, resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	return nil
+}
+
+// ── GitHub ─────────────────────────────────────────
+
+// GitHub uses the REST v3 statuses and issue comments APIs.
+type GitHub struct{ *restClient }
+
+func (g *GitHub) SetCommitStatus(ctx context.Context, repo Repo, sha string, s CommitStatus) error {
+	return g.post(ctx, fmt.Sprintf("/repos/%s/statuses/%s", repo.Path, sha), map[string]string{
+		"state":       string(s.State),
+		"context":     s.Context,
+		"description": truncate(s.Description, 140),
+		"target_url":  s.TargetURL,
+	})
+}
+
+func (g *GitHub) CommentPullRequest(ctx context.Context, repo Repo, number int, body string) error {
+	return g.post(ctx, fmt.Sprintf("/repos/%s/issues/%d/comments", repo.Path, number), map[string]string{"body": body})
+}
+
+// ── GitLab ─────────────────────────────────────────
+
+// GitLab uses the commit statuses and merge request notes APIs.
+type GitLab struct{ *restClient }
+
+var gitlabStates = map[State]string{StatePending: "running", StateSuccess: "success", StateFailure: "failed", StateError: "failed"}
+
+func (g *GitLab) SetCommitStatus(ctx context.Context, repo Repo, sha string, s CommitStatus) error {
+	return g.post(ctx, fmt.Sprintf("/projects/%s/statuses/%s", url.PathEscape(repo.Path), sha), map[string]string{
+		"state":       gitlabStates[s.State],
+		"name":        s.Context,
+		"description": truncate(s.Description, 255),
+		"target_url":  s.TargetURL,
+	})
+}
+
+func (g *GitLab) CommentPullRequest(ctx context.Context, repo Repo, number int, body string) error {
+	return g.post(ctx, fmt.Sprintf("/projects/%s/merge_requests/%d/notes", url.PathEscape(repo.Path), number), map[string]string{"body": body})
+}
+
+// ── Bitbucket Cloud ────────────────────────────────
+
+// Bitbucket uses the build statuses and pull request comments APIs.
+type Bitbucket struct{ *restClient }
+
+var bitbucketStates = map[State]string{StatePending: "INPROGRESS", StateSuccess: "SUCCESSFUL", StateFailure: "FAILED", StateError: "FAILED"}
+
+func (b *Bitbucket) SetCommitStatus(ctx context.Context, repo Repo, sha string, s CommitStatus) error {
+	return b.post(ctx, fmt.Sprintf("/repositories/%s/commit/%s/statuses/build", repo.Path, sha), map[string]string{
+		"state":       bitbucketStates[s.State],
+		"key":         truncate(s.Context, 40),
+		"name":        s.Context,
+		"description": s.Description,
+		"url":         s.TargetURL,
+	})
+}
+
+func (b *Bitbucket) CommentPullRequest(ctx context.Context, repo Repo, number int, body string) error {
+	return b.post(ctx, fmt.Sprintf("/repositories/%s/pullrequests/%d/comments", repo.Path, number),
+		map[string]interface{}{"content": map[string]string{"raw": body}})
+}
+
+// ── Azure DevOps ───────────────────────────────────
+
+// AzureDevOps uses the Git commit statuses and pull request threads APIs.
+type AzureDevOps struct{ *restClient }
+
+const azureAPIVersion = "7.1"
+
+var azureStates = map[State]string{StatePending: "pending", StateSuccess: "succeeded", StateFailure: "failed", StateError: "error"}
+
+func (a *AzureDevOps) SetCommitStatus(ctx context.Context, repo Repo, sha string, s CommitStatus) error {
+	genre, name, _ := strings.Cut(s.Context, "/")
+	if name == "" {
+		genre, name = "terrakube", s.Context
+	}
+	return a.post(ctx, fmt.Sprintf("%s/commits/%s/statuses?api-version=%s", azureRepoPath(repo), sha, azureAPIVersion), map[string]interface{}{
+		"state":       azureStates[s.State],
+		"description": s.Description,
+		"targetUrl":   s.TargetURL,
+		"context":     map[string]string{"genre": genre, "name": name},
+	})
+}
+
+func (a *AzureDevOps) CommentPullRequest(ctx context.Context, repo Repo, number int, body string) error {
+	return a.post(ctx, fmt.Sprintf("%s/pullRequests/%d/threads?api-version=%s", azureRepoPath(repo), number, azureAPIVersion), map[string]interface{}{
+		"comments": []map[string]interface{}{{"parentCommentId": 0, "content": body, "commentType": 1}},
+		"status":   "active",
+	})
+}
+
+// azureRepoPath turns "org/project/repo" into "/org/project/_apis/git/repositories/repo".
+func azureRepoPath(repo Repo) string {
+	parts := strings.Split(repo.Path, "/")
+	if len(parts) != 3 {
+		return "/" + repo.Path
+	}
+	return fmt.Sprintf("/%s/%s/_apis/git/repositories/%s", parts[0], url.PathEscape(parts[1]), url.PathEscape(parts[2]))
+}
+
+func truncate(s string, n int) string {
+	if len([]rune(s)) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
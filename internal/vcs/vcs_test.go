@@ -0,0 +1,117 @@
+package vcs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type recorded struct {
+	path string
+	auth string
+	body map[string]interface{}
+}
+
+// fakeServer records every request and answers 201.
+func fakeServer(t *testing.T) (*httptest.Server, *[]recorded) {
+	var reqs []recorded
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		rec := recorded{path: r.URL.RequestURI(), auth: r.Header.Get("Authorization")}
+		json.Unmarshal(b, &rec.body)
+		reqs = append(reqs, rec)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reqs
+}
+
+func TestClients(t *testing.T) {
+	status := CommitStatus{State: StatePending, Context: "terrakube/network", Description: "Plan running", TargetURL: "https://terrakube.example.com/runs/42"}
+	tests := []struct {
+		vcsType     string
+		source      string
+		statusPath  string
+		stateField  string
+		wantState   string
+		commentPath string
+	}{
+		{"GITHUB", "https://github.com/acme/net.git", "/repos/acme/net/statuses/abc123", "state", "pending", "/repos/acme/net/issues/7/comments"},
+		{"GITLAB", "git@gitlab.com:acme/infra/net.git", "/projects/acme%2Finfra%2Fnet/statuses/abc123", "state", "running", "/projects/acme%2Finfra%2Fnet/merge_requests/7/notes"},
+		{"BITBUCKET", "https://bitbucket.org/acme/net.git", "/repositories/acme/net/commit/abc123/statuses/build", "state", "INPROGRESS", "/repositories/acme/net/pullrequests/7/comments"},
+		{"AZURE_DEVOPS", "https://acme@dev.azure.com/acme/infra/_git/net", "/acme/infra/_apis/git/repositories/net/commits/abc123/statuses?api-version=7.1", "state", "pending", "/acme/infra/_apis/git/repositories/net/pullRequests/7/threads?api-version=7.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vcsType, func(t *testing.T) {
+			srv, reqs := fakeServer(t)
+			repo, err := ParseRepo(tt.vcsType, tt.source)
+			if err != nil {
+				t.Fatalf("ParseRepo() error = %v", err)
+			}
+			client, err := New(tt.vcsType, srv.URL, "tok", repo)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if err := client.SetCommitStatus(context.Background(), repo, "abc123", status); err != nil {
+				t.Fatalf("SetCommitStatus() error = %v", err)
+			}
+			if err := client.CommentPullRequest(context.Background(), repo, 7, "plan output"); err != nil {
+				t.Fatalf("CommentPullRequest() error = %v", err)
+			}
+
+			if len(*reqs) != 2 {
+				t.Fatalf("got %d requests, want 2", len(*reqs))
+			}
+			st, cm := (*reqs)[0], (*reqs)[1]
+			if st.path != tt.statusPath {
+				t.Errorf("status path = %s, want %s", st.path, tt.statusPath)
+			}
+			if st.body[tt.stateField] != tt.wantState {
+				t.Errorf("status state = %v, want %s", st.body[tt.stateField], tt.wantState)
+			}
+			if cm.path != tt.commentPath {
+				t.Errorf("comment path = %s, want %s", cm.path, tt.commentPath)
+			}
+			if st.auth != "Bearer tok" {
+				t.Errorf("Authorization = %q", st.auth)
+			}
+		})
+	}
+}
+
+func TestParseRepo(t *testing.T) {
+	tests := []struct {
+		vcsType, source string
+		want            Repo
+	}{
+		{"GITHUB", "https://github.com/acme/net", Repo{Host: "github.com", Path: "acme/net"}},
+		{"GITHUB", "ssh://git@ghe.example.com:22/acme/net.git", Repo{Host: "ghe.example.com", Path: "acme/net"}},
+		{"GITLAB", "https://gitlab.example.com/group/sub/net.git", Repo{Host: "gitlab.example.com", Path: "group/sub/net"}},
+		{"AZURE_DEVOPS", "git@ssh.dev.azure.com:v3/acme/infra/net", Repo{Host: "dev.azure.com", Path: "acme/infra/net"}},
+		{"AZURE_DEVOPS", "https://acme.visualstudio.com/infra/_git/net", Repo{Host: "dev.azure.com", Path: "acme/infra/net"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRepo(tt.vcsType, tt.source)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseRepo(%q) = %+v, %v; want %+v", tt.source, got, err, tt.want)
+		}
+	}
+	if _, err := ParseRepo("GITHUB", "https://github.com/acme"); err == nil {
+		t.Error("ParseRepo() without repository name: error = nil")
+	}
+}
+
+func TestDefaultAPIURL(t *testing.T) {
+	if got := DefaultAPIURL("GITHUB", "github.com"); got != "https://api.github.com" {
+		t.Errorf("github.com → %s", got)
+	}
+	if got := DefaultAPIURL("GITHUB", "ghe.example.com"); got != "https://ghe.example.com/api/v3" {
+		t.Errorf("GHE → %s", got)
+	}
+	if got := DefaultAPIURL("GITLAB", "gitlab.example.com"); got != "https://gitlab.example.com/api/v4" {
+		t.Errorf("self-hosted GitLab → %s", got)
+	}
+}